@@ -0,0 +1,49 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+
+	"organize/utils"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/log"
+)
+
+// aboutSection shows the club's about_file from the content directory.
+type aboutSection struct {
+	viewport viewport.Model
+}
+
+func (s *aboutSection) title() string { return "About" }
+
+func (s *aboutSection) resize(m *Model, width int, height int) {
+	if s.viewport.Width == 0 {
+		s.viewport = newSectionViewport(m.keys)
+	}
+	s.viewport.Width, s.viewport.Height = width, height
+
+	content, err := os.ReadFile(filepath.Join(m.config.Directory, m.config.AboutFile))
+	if err != nil {
+		log.Warn("could not read about file", "error", err)
+		s.viewport.SetContent("Nothing to tell yet.")
+		return
+	}
+	rendered, err := m.renderers.render(m.theme, utils.Max(1, width-1), utils.FileBody(m.config.AboutFile, string(content)))
+	if err != nil {
+		reportError(m.hub, "could not render about file", err)
+		return
+	}
+	s.viewport.SetContent(rendered)
+}
+
+func (s *aboutSection) update(m *Model, msg tea.KeyMsg) tea.Cmd {
+	var cmd tea.Cmd
+	s.viewport, cmd = s.viewport.Update(msg)
+	return cmd
+}
+
+func (s *aboutSection) view(m Model) string {
+	return s.viewport.View()
+}
@@ -0,0 +1,188 @@
+// Package accesslog writes one line per session and per page view to a file
+// that standard log tooling can read, rotating it as it grows or ages.
+package accesslog
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Formats the log can be written in.
+const (
+	// FormatJSON writes an Entry as a JSON object per line.
+	FormatJSON = "json"
+	// FormatCombined writes the Apache combined log format, with the position
+	// as the request path and the terminal as the user agent.
+	FormatCombined = "combined"
+)
+
+// Events an entry records.
+const (
+	EventSession = "session"
+	EventView    = "view"
+)
+
+// Entry is a session that ended or a position viewed during one.
+type Entry struct {
+	Time        time.Time     `json:"time"`
+	Event       string        `json:"event"`
+	Session     string        `json:"session"`
+	Address     string        `json:"address"`
+	User        string        `json:"user"`
+	Fingerprint string        `json:"fingerprint,omitempty"`
+	Term        string        `json:"term,omitempty"`
+	File        string        `json:"file,omitempty"`
+	Duration    time.Duration `json:"-"`
+}
+
+// MarshalJSON writes the duration in milliseconds.
+func (e Entry) MarshalJSON() ([]byte, error) {
+	type entry Entry
+	return json.Marshal(struct {
+		entry
+		Duration int64 `json:"duration_ms,omitempty"`
+	}{entry: entry(e), Duration: e.Duration.Milliseconds()})
+}
+
+// Options say where the log goes and when it is rotated. A zero MaxSize or
+// MaxAge never rotates for that reason.
+type Options struct {
+	Path    string
+	Format  string
+	MaxSize int64
+	MaxAge  time.Duration
+}
+
+// Writer appends entries to the log. Rotated logs are renamed with the time
+// they were rotated at and left for the operator to compress or delete.
+type Writer struct {
+	mu     sync.Mutex
+	opts   Options
+	file   *os.File
+	size   int64
+	opened time.Time
+}
+
+func Open(opts Options) (*Writer, error) {
+	w := &Writer{opts: opts}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *Writer) open() error {
+	if err := os.MkdirAll(filepath.Dir(w.opts.Path), 0o755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(w.opts.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	w.file, w.size, w.opened = file, info.Size(), time.Now()
+	return nil
+}
+
+// Write appends e to the log, rotating it first if it is due.
+func (w *Writer) Write(e Entry) error {
+	line, err := w.format(e)
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return os.ErrClosed
+	}
+	if w.due(int64(len(line))) {
+		if err := w.rotate(); err != nil {
+			return fmt.Errorf("rotate access log: %w", err)
+		}
+	}
+	n, err := w.file.Write(line)
+	w.size += int64(n)
+	return err
+}
+
+// due reports whether the log should be rotated before n more bytes.
+func (w *Writer) due(n int64) bool {
+	if w.size == 0 {
+		return false
+	}
+	if w.opts.MaxSize > 0 && w.size+n > w.opts.MaxSize {
+		return true
+	}
+	return w.opts.MaxAge > 0 && time.Since(w.opened) >= w.opts.MaxAge
+}
+
+func (w *Writer) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	w.file = nil
+	rotated := w.opts.Path + "." + time.Now().UTC().Format("20060102-150405")
+	if err := os.Rename(w.opts.Path, rotated); err != nil {
+		return err
+	}
+	return w.open()
+}
+
+func (w *Writer) format(e Entry) ([]byte, error) {
+	if w.opts.Format == FormatCombined {
+		return []byte(combined(e) + "\n"), nil
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	return append(line, '\n'), nil
+}
+
+// combined writes e like a web server would, so sessions show up as requests
+// for / and views as requests for the position.
+func combined(e Entry) string {
+	host, _, err := net.SplitHostPort(e.Address)
+	if err != nil {
+		host = e.Address
+	}
+	user := e.User
+	if user == "" {
+		user = "-"
+	}
+	path := "/"
+	if e.Event == EventView {
+		path = "/" + e.File
+	}
+	agent := e.Term
+	if agent == "" {
+		agent = "-"
+	}
+	return fmt.Sprintf(`%s - %s [%s] "%s %s SSH" 200 - "-" %q`,
+		host, strings.ReplaceAll(user, " ", "_"), e.Time.Format("02/Jan/2006:15:04:05 -0700"),
+		strings.ToUpper(e.Event), strings.ReplaceAll(path, " ", "%20"), agent)
+}
+
+// Close closes the log; entries written afterwards are dropped with
+// os.ErrClosed.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
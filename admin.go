@@ -0,0 +1,195 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"organize/components"
+	"organize/utils"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/ssh"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+const recentApplications = 10
+
+func parseAdminKeys(authorizedKeys []string) ([]ssh.PublicKey, error) {
+	admins := make([]ssh.PublicKey, 0, len(authorizedKeys))
+	for _, line := range authorizedKeys {
+		key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(line))
+		if err != nil {
+			return nil, err
+		}
+		admins = append(admins, key)
+	}
+	return admins, nil
+}
+
+func isAdmin(admins []ssh.PublicKey, key ssh.PublicKey) bool {
+	if key == nil {
+		return false
+	}
+	for _, admin := range admins {
+		if ssh.KeysEqual(admin, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// verifiedKeyKey holds, in the connection's context, the public key the
+// visitor proved they hold. ssh.Session.PublicKey can't be trusted for that:
+// it is whatever key was last offered, signed or not, so a client could
+// offer an admin's key and then log in through keyboard-interactive.
+type verifiedKeyKey struct{}
+
+// acceptPublicKey lets every key in and notes it as the visitor's. The server
+// only calls it with the key a successful public key login signed with last.
+func acceptPublicKey(ctx ssh.Context, key ssh.PublicKey) bool {
+	ctx.SetValue(verifiedKeyKey{}, key)
+	return true
+}
+
+// acceptKeyboardInteractive lets visitors without a key in, forgetting any
+// key they offered but never signed with.
+func acceptKeyboardInteractive(ctx ssh.Context, _ gossh.KeyboardInteractiveChallenge) bool {
+	ctx.SetValue(verifiedKeyKey{}, nil)
+	return true
+}
+
+// sessionKey is the public key the visitor on s logged in with, or nil if
+// they logged in without one.
+func sessionKey(s ssh.Session) ssh.PublicKey {
+	key, _ := s.Context().Value(verifiedKeyKey{}).(ssh.PublicKey)
+	return key
+}
+
+// keyFingerprint identifies a visitor by their public key, or returns "" for
+// visitors who connected without one.
+func keyFingerprint(key ssh.PublicKey) string {
+	if key == nil {
+		return ""
+	}
+	return gossh.FingerprintSHA256(key)
+}
+
+func (m *Model) openAdmin() {
+	if m.currentView != adminView {
+		m.previousView = m.currentView
+	}
+	m.currentView = adminView
+	m.viewport.SetContent(m.adminContent())
+	m.viewport.GotoTop()
+}
+
+func (m Model) updateAdmin(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.broadcasting {
+		switch msg.Type {
+		case tea.KeyEnter:
+			message := strings.TrimSpace(m.adminInput.Value())
+			log.Info("admin announcement", "user", m.user)
+			m.announcer.announce(message)
+			m.statusMessage = "Announced to every session"
+			if message == "" {
+				m.statusMessage = "Took the announcement down"
+			}
+			fallthrough
+		case tea.KeyEsc:
+			m.broadcasting = false
+			m.adminInput.Blur()
+			m.adminInput.Reset()
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.adminInput, cmd = m.adminInput.Update(msg)
+		return m, cmd
+	}
+
+	m.statusMessage = ""
+	switch {
+	case key.Matches(msg, m.keys.Quit):
+		return m, tea.Quit
+	case key.Matches(msg, m.keys.Back):
+		m.currentView = m.previousView
+		m.viewport.SetContent(m.renderedContent)
+		m.viewport.GotoTop()
+		return m, nil
+	case key.Matches(msg, m.keys.Reload):
+		log.Info("admin reload", "user", m.user)
+		m.statusMessage = "Reloading content"
+		reload := m.reload
+		return m, func() tea.Msg {
+			reload()
+			return nil
+		}
+	case key.Matches(msg, m.keys.Broadcast):
+		m.broadcasting = true
+		return m, m.adminInput.Focus()
+	case key.Matches(msg, m.keys.Theme):
+		m.cycleTheme()
+		return m, nil
+	case key.Matches(msg, m.keys.Background):
+		m.toggleBackground()
+		return m, nil
+	case key.Matches(msg, m.keys.ASCII):
+		m.toggleASCII()
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.viewport, cmd = m.viewport.Update(msg)
+	return m, cmd
+}
+
+func (m Model) adminContent() string {
+	width := utils.Max(0, m.viewport.Width-2)
+
+	s := components.AdminSectionView(m.theme, width, "Live", []string{
+		fmt.Sprintf("Open sessions: %d", m.browsing),
+	})
+
+	submitted, err := m.applications.Applications()
+	if err != nil {
+		log.Warn("could not load applications", "error", err)
+	}
+	perPosition := make(map[string]int)
+	for _, application := range submitted {
+		perPosition[application.Position]++
+	}
+
+	positions := m.positions()
+	sort.SliceStable(positions, func(i, j int) bool {
+		return m.views.Count(m.fileNames[positions[i]]) > m.views.Count(m.fileNames[positions[j]])
+	})
+	var stats []string
+	for _, i := range positions {
+		name := m.fileNames[i]
+		stats = append(stats, fmt.Sprintf("%-40s %6d views %4d applications",
+			utils.Truncate(m.fileTitles[i], 40), m.views.Count(name), perPosition[name]))
+	}
+	s += components.AdminSectionView(m.theme, width, "Positions", stats)
+
+	var recent []string
+	if err != nil {
+		recent = append(recent, "Could not load applications: "+err.Error())
+	}
+	for i := len(submitted) - 1; i >= 0 && i >= len(submitted)-recentApplications; i-- {
+		application := submitted[i]
+		recent = append(recent, fmt.Sprintf("%s  %s <%s>  %s",
+			application.SubmittedAt.Format("2006-01-02 15:04"), application.Name, application.Email, application.Title))
+	}
+	if len(recent) == 0 {
+		recent = append(recent, "No applications yet.")
+	}
+	s += components.AdminSectionView(m.theme, width, "Recent applications", recent)
+
+	s += components.AdminSectionView(m.theme, width, "Controls", []string{
+		fmt.Sprintf("%s  %s", m.keys.Reload.Help().Key, m.keys.Reload.Help().Desc),
+		fmt.Sprintf("%s  %s (an empty one takes it down)", m.keys.Broadcast.Help().Key, m.keys.Broadcast.Help().Desc),
+		fmt.Sprintf("%s  %s", m.keys.Back.Help().Key, m.keys.Back.Help().Desc),
+	})
+	return s
+}
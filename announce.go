@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/log"
+)
+
+// announcementMsg is the banner shown above every screen until expires. An
+// empty text takes the current banner down.
+type announcementMsg struct {
+	text    string
+	expires time.Time
+}
+
+type announcementExpiredMsg struct {
+	expires time.Time
+}
+
+func expireAnnouncement(announcement announcementMsg) tea.Cmd {
+	return tea.Tick(time.Until(announcement.expires), func(time.Time) tea.Msg {
+		return announcementExpiredMsg{expires: announcement.expires}
+	})
+}
+
+// announcer remembers the running announcement so sessions that connect
+// while it is up still get to see it.
+type announcer struct {
+	mu       sync.Mutex
+	current  announcementMsg
+	duration time.Duration
+	sessions *sessionRegistry
+}
+
+func newAnnouncer(sessions *sessionRegistry, duration time.Duration) *announcer {
+	return &announcer{sessions: sessions, duration: duration}
+}
+
+func (a *announcer) announce(text string) {
+	announcement := announcementMsg{text: text, expires: time.Now().Add(a.duration)}
+	a.mu.Lock()
+	a.current = announcement
+	a.mu.Unlock()
+	log.Info("announcement", "text", text, "expires", announcement.expires)
+	a.sessions.broadcast(announcement)
+}
+
+func (a *announcer) active() (announcementMsg, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.current, a.current.text != "" && time.Now().Before(a.current.expires)
+}
+
+// listenControl accepts one command per line on a unix socket that only the
+// server's user can reach:
+//
+//	announce <text>   show text above every screen
+//	clear             take the announcement down
+//	reload            reload the content directory
+func listenControl(path string, announcer *announcer, reload func()) (net.Listener, error) {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		listener.Close()
+		return nil, err
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go handleControl(conn, announcer, reload)
+		}
+	}()
+	return listener, nil
+}
+
+func handleControl(conn net.Conn, announcer *announcer, reload func()) {
+	defer conn.Close()
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		command, argument, _ := strings.Cut(strings.TrimSpace(scanner.Text()), " ")
+		switch command {
+		case "announce":
+			announcer.announce(strings.TrimSpace(argument))
+		case "clear":
+			announcer.announce("")
+		case "reload":
+			reload()
+		case "":
+			continue
+		default:
+			fmt.Fprintf(conn, "error: unknown command %q\n", command)
+			continue
+		}
+		fmt.Fprintln(conn, "ok")
+	}
+}
+
+// sendControl runs one command against the control socket of a running server.
+func sendControl(path string, command string) error {
+	conn, err := net.DialTimeout("unix", path, 5*time.Second)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := fmt.Fprintln(conn, command); err != nil {
+		return err
+	}
+	reply, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if reply = strings.TrimSpace(reply); reply != "ok" {
+		return errors.New(strings.TrimPrefix(reply, "error: "))
+	}
+	return nil
+}
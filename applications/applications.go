@@ -0,0 +1,114 @@
+package applications
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/mail"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Application struct {
+	Position    string    `json:"position"`
+	Title       string    `json:"title"`
+	Name        string    `json:"name"`
+	Email       string    `json:"email"`
+	GitHub      string    `json:"github"`
+	Answer      string    `json:"answer"`
+	User        string    `json:"user"`
+	SubmittedAt time.Time `json:"submitted_at"`
+}
+
+type Store interface {
+	Save(application Application) error
+	Applications() ([]Application, error)
+}
+
+// FileStore appends every application to a file as one line of JSON, which
+// is easy to grep, tail and import elsewhere.
+type FileStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+func (s *FileStore) Save(application Application) error {
+	line, err := json.Marshal(application)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Applications hold personal details, so only the server's user may read them.
+	file, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Applications returns every application in the order they were sent. A
+// missing file just means nobody has applied yet.
+func (s *FileStore) Applications() ([]Application, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	content, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var submitted []Application
+	for _, line := range strings.Split(string(content), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		var application Application
+		if err := json.Unmarshal([]byte(line), &application); err != nil {
+			return nil, err
+		}
+		submitted = append(submitted, application)
+	}
+	return submitted, nil
+}
+
+var githubUsernamePattern = regexp.MustCompile(`^[A-Za-z0-9](?:-?[A-Za-z0-9]){0,38}$`)
+
+func ValidateRequired(value string) error {
+	if strings.TrimSpace(value) == "" {
+		return errors.New("this field is required")
+	}
+	return nil
+}
+
+func ValidateEmail(value string) error {
+	address, err := mail.ParseAddress(value)
+	if err != nil || address.Address != strings.TrimSpace(value) {
+		return fmt.Errorf("%q is not an email address", value)
+	}
+	return nil
+}
+
+// ValidateGitHub accepts a GitHub username, with or without a leading @.
+func ValidateGitHub(value string) error {
+	if !githubUsernamePattern.MatchString(strings.TrimPrefix(strings.TrimSpace(value), "@")) {
+		return fmt.Errorf("%q is not a GitHub username", value)
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package applications
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// csvHeader names the columns of an export, in order.
+var csvHeader = []string{"submitted_at", "position", "title", "name", "email", "github", "answer", "user"}
+
+// WriteCSV writes applications as a spreadsheet with a header row. Anything
+// an applicant typed that a spreadsheet would take for a formula is quoted
+// with a leading apostrophe, as is anything that already started with one,
+// and ReadCSV takes it off again. Times keep their nanoseconds so Same still
+// matches an imported application with the one it was exported from.
+func WriteCSV(w io.Writer, submitted []Application) error {
+	out := csv.NewWriter(w)
+	if err := out.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, a := range submitted {
+		record := []string{a.SubmittedAt.UTC().Format(time.RFC3339Nano), a.Position, a.Title, a.Name, a.Email, a.GitHub, a.Answer, a.User}
+		for i := range record {
+			record[i] = escapeFormula(record[i])
+		}
+		if err := out.Write(record); err != nil {
+			return err
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// ReadCSV reads applications written by WriteCSV, or any spreadsheet with the
+// same column names in whatever order.
+func ReadCSV(r io.Reader) ([]Application, error) {
+	in := csv.NewReader(r)
+	header, err := in.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"submitted_at", "position", "email"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("missing the %s column", name)
+		}
+	}
+
+	var submitted []Application
+	for {
+		record, err := in.Read()
+		if err == io.EOF {
+			return submitted, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := in.FieldPos(0)
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return unescapeFormula(record[i])
+			}
+			return ""
+		}
+		submittedAt, err := time.Parse(time.RFC3339Nano, field("submitted_at"))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: submitted_at: %w", line, err)
+		}
+		submitted = append(submitted, Application{
+			Position:    field("position"),
+			Title:       field("title"),
+			Name:        field("name"),
+			Email:       field("email"),
+			GitHub:      field("github"),
+			Answer:      field("answer"),
+			User:        field("user"),
+			SubmittedAt: submittedAt,
+		})
+	}
+}
+
+// WriteJSON writes applications as one indented JSON array.
+func WriteJSON(w io.Writer, submitted []Application) error {
+	if submitted == nil {
+		submitted = []Application{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(submitted)
+}
+
+// ReadJSON reads applications from a JSON array, as WriteJSON writes them, or
+// one per line, as the FileStore keeps them.
+func ReadJSON(r io.Reader) ([]Application, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	content = bytes.TrimSpace(content)
+	var submitted []Application
+	if bytes.HasPrefix(content, []byte("[")) {
+		err := json.Unmarshal(content, &submitted)
+		return submitted, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	for decoder.More() {
+		var application Application
+		if err := decoder.Decode(&application); err != nil {
+			return nil, err
+		}
+		submitted = append(submitted, application)
+	}
+	return submitted, nil
+}
+
+// Same says whether a and b are the same application, so importing one that
+// is already there can skip it. Times are compared to the second, as older
+// exports kept them.
+func Same(a Application, b Application) bool {
+	return a.SubmittedAt.Truncate(time.Second).Equal(b.SubmittedAt.Truncate(time.Second)) &&
+		a.Position == b.Position && strings.EqualFold(a.Email, b.Email)
+}
+
+// escapeFormula puts an apostrophe before text a spreadsheet would otherwise
+// run as a formula, and before text that starts with an apostrophe so that
+// one survives unescapeFormula.
+func escapeFormula(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r'", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+func unescapeFormula(value string) string {
+	if len(value) > 1 && value[0] == '\'' && strings.ContainsRune("=+-@\t\r'", rune(value[1])) {
+		return value[1:]
+	}
+	return value
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"organize/applications"
+	"organize/utils"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+)
+
+const maxAnswerLength = 1000
+
+// openApplyForm starts an application for the position being read, unless its
+// frontmatter says applications are closed or its deadline has passed.
+func (m *Model) openApplyForm() tea.Cmd {
+	meta := m.selectedMetadata()
+	if meta.Status == "closed" || meta.Status == "draft" || meta.Expired(time.Now()) || utils.Archived(m.selectedFileName, meta) {
+		m.statusMessage = "This position is not taking applications"
+		return nil
+	}
+
+	m.application = &applications.Application{
+		Position: m.selectedFileName,
+		Title:    m.selectedTitle(),
+		User:     m.user,
+	}
+
+	keyMap := huh.NewDefaultKeyMap()
+	keyMap.Quit = key.NewBinding(key.WithKeys("esc", "ctrl+c"))
+	// The editor would be started on the server, not on the visitor's machine.
+	keyMap.Text.Editor.SetEnabled(false)
+
+	m.applyForm = huh.NewForm(
+		huh.NewGroup(
+			huh.NewInput().
+				Title("Name").
+				Value(&m.application.Name).
+				Validate(applications.ValidateRequired),
+			huh.NewInput().
+				Title("Email").
+				Placeholder("you@example.com").
+				Value(&m.application.Email).
+				Validate(applications.ValidateEmail),
+			huh.NewInput().
+				Title("GitHub username").
+				Value(&m.application.GitHub).
+				Validate(applications.ValidateGitHub),
+		).Title("Apply for "+m.selectedTitle()).
+			Description("Tell us who you are. Press esc at any time to cancel."),
+		huh.NewGroup(
+			huh.NewText().
+				Title(fmt.Sprintf("Why do you want to join as %s?", m.selectedTitle())).
+				CharLimit(maxAnswerLength).
+				Value(&m.application.Answer).
+				Validate(applications.ValidateRequired),
+		),
+	).WithKeyMap(keyMap).
+		WithTheme(m.theme.Form()).
+		WithWidth(utils.Min(m.viewport.Width, 80))
+
+	m.currentView = applyView
+	return m.applyForm.Init()
+}
+
+func (m Model) updateApply(msg tea.Msg) (tea.Model, tea.Cmd) {
+	form, cmd := m.applyForm.Update(msg)
+	m.applyForm = form.(*huh.Form)
+
+	switch m.applyForm.State {
+	case huh.StateCompleted:
+		m.submitApplication()
+	case huh.StateAborted:
+		m.statusMessage = "Application cancelled"
+	default:
+		return m, cmd
+	}
+	m.applyForm = nil
+	m.application = nil
+	m.currentView = fileContentView
+	return m, nil
+}
+
+func (m *Model) submitApplication() {
+	application := *m.application
+	application.Name = strings.TrimSpace(application.Name)
+	application.Email = strings.TrimSpace(application.Email)
+	application.GitHub = strings.TrimPrefix(strings.TrimSpace(application.GitHub), "@")
+	application.Answer = strings.TrimSpace(application.Answer)
+	application.SubmittedAt = time.Now()
+
+	if err := m.applications.Save(application); err != nil {
+		reportError(m.hub, "could not save application", err, "position", application.Position)
+		m.statusMessage = "Could not send your application, please try again later"
+		return
+	}
+	log.Info("application received", "position", application.Position, "user", application.User)
+	for _, notifier := range m.notifiers {
+		notifier.Notify(m.ctx, application)
+	}
+	m.statusMessage = "Thanks! Your application for " + application.Title + " was sent"
+}
+
+func (m Model) applyFormView() string {
+	return lipgloss.NewStyle().Padding(1, 2).Render(m.applyForm.View())
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"organize/config"
+	"organize/utils"
+)
+
+func TestApplyLinksLeaveOutWhatCannotBeAppliedFor(t *testing.T) {
+	cfg := config.Default()
+	cfg.ApplyURL = "https://example.com/apply"
+	m := Model{
+		fileNames:        []string{"Open.md", "Draft.md", "Closed.md", "Expired.md", "logo.png", "archive/", "archive/Old.md"},
+		fileTitles:       []string{"Open", "Draft", "Closed", "Expired", "logo.png", "archive/", "Old"},
+		fileDescriptions: []string{"open role", "secret role", "closed role", "expired role", "Image", "", "old role"},
+		fileMetadata: []utils.Frontmatter{
+			{},
+			{Status: "draft"},
+			{Status: "closed"},
+			{Deadline: time.Now().AddDate(0, 0, -2)},
+			{},
+			{},
+			{},
+		},
+		config: &cfg,
+	}
+
+	positions := m.takingApplications()
+	if len(positions) != 1 || positions[0] != 0 {
+		t.Fatalf("got %v, want only the open position", positions)
+	}
+	links := m.applyLinks(positions)
+	for _, hidden := range []string{"Draft", "secret", "Closed", "Expired", "logo", "Old"} {
+		if strings.Contains(links, hidden) {
+			t.Errorf("the links mention %q:\n%s", hidden, links)
+		}
+	}
+	if !strings.Contains(links, "- Open (open role): https://example.com/apply") {
+		t.Errorf("the open position is missing:\n%s", links)
+	}
+
+	m.admin = true
+	if positions := m.takingApplications(); len(positions) != 2 || positions[1] != 1 {
+		t.Errorf("admins got %v, want the open position and the draft", positions)
+	}
+}
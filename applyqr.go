@@ -0,0 +1,47 @@
+package main
+
+import (
+	"organize/components"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// openApplyQR shows the open position's apply_url as a QR code, for visitors
+// to carry on applying on their phone.
+func (m *Model) openApplyQR() {
+	url := m.selectedMetadata().ApplyURL
+	if url == "" {
+		m.statusMessage = "This position has no application link of its own"
+		return
+	}
+	code, err := m.cache.qrCode(url, m.qrOptions())
+	if err != nil {
+		m.statusMessage = "Could not draw the QR code"
+		return
+	}
+	m.applyQR = code
+	m.applyQROpen = true
+}
+
+func (m Model) updateApplyQR(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Quit):
+		return m, tea.Quit
+	case key.Matches(msg, m.keys.ApplyQR), key.Matches(msg, m.keys.Back), key.Matches(msg, m.keys.Enter):
+		m.applyQROpen = false
+	}
+	return m, nil
+}
+
+// applyQRView is the QR code in the middle of the reader, or just the link
+// when the terminal is too small to scan it from.
+func (m Model) applyQRView() string {
+	url := m.selectedMetadata().ApplyURL
+	card := components.ApplyQRView(m.theme, m.selectedTitle(), m.applyQR, url)
+	if lipgloss.Width(card) > m.viewport.Width || lipgloss.Height(card) > m.viewport.Height {
+		card = components.ApplyQRView(m.theme, m.selectedTitle(), "", url)
+	}
+	return lipgloss.Place(m.viewport.Width, m.viewport.Height, lipgloss.Center, lipgloss.Center, card)
+}
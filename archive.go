@@ -0,0 +1,149 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"organize/components"
+	"organize/utils"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// archivedPosition is a closed position as the Archive tab lists it.
+type archivedPosition struct {
+	fileName    string
+	title       string
+	description string
+}
+
+// archiveSection keeps closed positions readable without them crowding the
+// grid, greyed out so nobody mistakes them for open ones.
+type archiveSection struct {
+	viewport  viewport.Model
+	positions []archivedPosition
+	cursor    int
+	open      bool
+	// listOffset is where the list was scrolled to before a position was
+	// opened.
+	listOffset int
+}
+
+func (s *archiveSection) title() string { return "Archive" }
+
+func (s *archiveSection) resize(m *Model, width int, height int) {
+	if s.viewport.Width == 0 {
+		s.viewport = newSectionViewport(m.keys)
+	}
+	s.viewport.Width, s.viewport.Height = width, height
+
+	s.positions = s.positions[:0]
+	for _, i := range m.archivedPositions() {
+		s.positions = append(s.positions, archivedPosition{fileName: m.fileNames[i], title: m.fileTitles[i], description: m.fileDescriptions[i]})
+	}
+	s.cursor = utils.Min(s.cursor, utils.Max(0, len(s.positions)-1))
+	s.open = s.open && len(s.positions) > 0
+	s.refresh(m)
+}
+
+// refresh draws the list or the open position into the viewport.
+func (s *archiveSection) refresh(m *Model) {
+	if s.open {
+		position := s.positions[s.cursor]
+		s.viewport.SetContent(components.DimmedView(m.theme, position.title+" (closed)\n\n"+s.render(m, position.fileName)))
+		return
+	}
+
+	cards := make([]string, len(s.positions))
+	top := 0
+	for i, position := range s.positions {
+		cards[i] = components.ArchivedCardView(m.theme, s.viewport.Width, position.title, position.description, i == s.cursor)
+		if i < s.cursor {
+			top += lipgloss.Height(cards[i])
+		}
+	}
+	s.viewport.SetContent(strings.Join(cards, "\n"))
+	if len(cards) == 0 {
+		return
+	}
+	// Keep the selected position on screen.
+	bottom := top + lipgloss.Height(cards[s.cursor])
+	if top < s.viewport.YOffset {
+		s.viewport.SetYOffset(top)
+	} else if bottom > s.viewport.YOffset+s.viewport.Height {
+		s.viewport.SetYOffset(bottom - s.viewport.Height)
+	}
+}
+
+// render reads a closed position and renders it like the reader does, for
+// DimmedView to grey out.
+func (s *archiveSection) render(m *Model, fileName string) string {
+	content, err := os.ReadFile(filepath.Join(m.config.Directory, fileName))
+	if err != nil {
+		return "Error reading file"
+	}
+	body := utils.FileBody(fileName, string(content))
+	var rendered string
+	if utils.CodeLanguage(fileName) != "" {
+		rendered, err = highlightCode(m.theme, fileName, body)
+	} else {
+		rendered, err = m.renderers.render(m.theme, utils.Max(1, s.viewport.Width-1), body)
+	}
+	if err != nil {
+		reportError(m.hub, "could not render archived position", err, "file", fileName)
+		return body
+	}
+	return rendered
+}
+
+func (s *archiveSection) update(m *Model, msg tea.KeyMsg) tea.Cmd {
+	if len(s.positions) == 0 {
+		return nil
+	}
+	if s.open {
+		if key.Matches(msg, m.keys.Back) {
+			s.open = false
+			s.refresh(m)
+			s.viewport.SetYOffset(s.listOffset)
+			return nil
+		}
+		var cmd tea.Cmd
+		s.viewport, cmd = s.viewport.Update(msg)
+		return cmd
+	}
+
+	switch {
+	case key.Matches(msg, m.keys.Up):
+		s.cursor = utils.Max(0, s.cursor-1)
+	case key.Matches(msg, m.keys.Down):
+		s.cursor = utils.Min(len(s.positions)-1, s.cursor+1)
+	case key.Matches(msg, m.keys.Top):
+		s.cursor = 0
+	case key.Matches(msg, m.keys.Bottom):
+		s.cursor = len(s.positions) - 1
+	case key.Matches(msg, m.keys.Enter):
+		s.open = true
+		s.listOffset = s.viewport.YOffset
+		s.refresh(m)
+		s.viewport.GotoTop()
+		return nil
+	default:
+		var cmd tea.Cmd
+		s.viewport, cmd = s.viewport.Update(msg)
+		return cmd
+	}
+	s.refresh(m)
+	return nil
+}
+
+func (s *archiveSection) view(m Model) string {
+	if len(s.positions) == 0 {
+		empty := lipgloss.NewStyle().Faint(true).Render("No closed positions yet.")
+		return lipgloss.Place(s.viewport.Width, s.viewport.Height, lipgloss.Center, lipgloss.Center, empty)
+	}
+	return s.viewport.View()
+}
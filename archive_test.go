@@ -0,0 +1,50 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"organize/config"
+)
+
+func TestArchivedPositionsStayOutOfSearchAndApplications(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "archive"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"Mentor.md":         "# Mentor\nHelp new members.\n",
+		"Closed.md":         "---\nstatus: closed\n---\n# Closed mentor\nNo longer needed.\n",
+		"archive/Former.md": "# Former mentor\nFilled last year.\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	meta, index, err := newContentCache().positions(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.Default()
+	m := Model{
+		fileNames:    meta.FileNames,
+		fileTitles:   meta.FileTitles,
+		fileMetadata: meta.FileMetadata,
+		searchIndex:  index,
+		config:       &cfg,
+	}
+	m.theme = pickTheme(&cfg, m.preferences, terminal{})
+
+	results := m.searchPositions("mentor")
+	if len(results) != 1 || results[0].FileName != "Mentor.md" {
+		t.Errorf("search found %v, want only Mentor.md", results)
+	}
+
+	for _, fileName := range []string{"Closed.md", "archive/Former.md"} {
+		m.selectedFileName = fileName
+		if m.openApplyForm(); m.applyForm != nil {
+			t.Errorf("the apply form opened for %s", fileName)
+		}
+	}
+}
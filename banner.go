@@ -0,0 +1,84 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"organize/config"
+	"organize/utils"
+
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/ssh"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// bannerData is what a banner template can show: {{.Date}}, {{.Time}},
+// {{.Positions}} for how many positions are open and {{.Sessions}} for how
+// many people are connected.
+type bannerData struct {
+	Date      string
+	Time      string
+	Positions int
+	Sessions  int
+}
+
+// openPositions counts the positions a visitor would see in the grid.
+func openPositions(meta *utils.PositionMeta, now time.Time) int {
+	count := 0
+	for i, fileName := range meta.FileNames {
+		f := meta.FileMetadata[i]
+		if utils.IsDirEntry(fileName) || utils.IsImage(fileName) || f.Draft() || utils.Archived(fileName, f) || f.Expired(now) {
+			continue
+		}
+		count++
+	}
+	return count
+}
+
+// renderBanner fills in the banner template at banner_file, if one is set.
+// It runs for every connection before login, so it only stats the file,
+// parsing it again when it changed, and counts positions on the board already
+// in memory instead of reading the directory.
+func renderBanner(cfg *config.Config, svc *services) (string, error) {
+	if cfg.BannerFile == "" {
+		return "", nil
+	}
+	tmpl, err := svc.content.bannerTemplate(cfg.BannerFile)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	data := bannerData{Date: now.Format("Monday 2 January 2006"), Time: now.Format("15:04 MST"), Sessions: svc.sessions.count()}
+	if meta := svc.content.lastBoard(); meta != nil {
+		data.Positions = openPositions(meta, now)
+	}
+	var banner strings.Builder
+	if err := tmpl.Execute(&banner, data); err != nil {
+		return "", err
+	}
+	// Clients print the banner as is, so lines need their carriage returns.
+	text := strings.ReplaceAll(strings.TrimRight(banner.String(), "\n"), "\n", "\r\n")
+	return text + "\r\n", nil
+}
+
+// withBanner sends the banner before authentication, so every client sees
+// it, including scp, sftp and commands run without a terminal.
+func withBanner(svc *services) ssh.Option {
+	return func(s *ssh.Server) error {
+		s.ServerConfigCallback = func(ssh.Context) *gossh.ServerConfig {
+			return &gossh.ServerConfig{
+				BannerCallback: func(gossh.ConnMetadata) string {
+					cfg := svc.config.load()
+					banner, err := renderBanner(cfg, svc)
+					if err != nil {
+						log.Warn("could not render banner", "path", cfg.BannerFile, "error", err)
+						return ""
+					}
+					return banner
+				},
+			}
+		}
+		return nil
+	}
+}
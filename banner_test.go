@@ -0,0 +1,56 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"organize/config"
+	"organize/utils"
+)
+
+func TestBannerReadsOnlyWhatChanged(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "banner.txt")
+	if err := os.WriteFile(path, []byte("{{.Positions}} open\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.Default()
+	cfg.BannerFile = path
+	// The directory is never read for the banner, only the board in memory.
+	cfg.Directory = filepath.Join(t.TempDir(), "missing")
+	svc := &services{content: newContentCache(), sessions: newSessionRegistry()}
+
+	render := func(want string) {
+		t.Helper()
+		banner, err := renderBanner(&cfg, svc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if banner != want {
+			t.Errorf("got %q, want %q", banner, want)
+		}
+	}
+	render("0 open\r\n")
+
+	svc.content.setPositions(&utils.PositionMeta{
+		FileNames:    []string{"Mentor.md", "Closed.md"},
+		FileMetadata: []utils.Frontmatter{{}, {Status: "closed"}},
+	}, nil)
+	render("1 open\r\n")
+
+	first := svc.content.banner.tmpl
+	render("1 open\r\n")
+	if svc.content.banner.tmpl != first {
+		t.Error("an unchanged banner was parsed again")
+	}
+
+	if err := os.WriteFile(path, []byte("{{.Positions}} positions open\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	render("1 positions open\r\n")
+}
@@ -0,0 +1,355 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"text/template"
+	"time"
+
+	"organize/components"
+	"organize/config"
+	"organize/qr"
+	"organize/search"
+	"organize/termimage"
+	"organize/utils"
+
+	"github.com/charmbracelet/log"
+	"github.com/muesli/termenv"
+)
+
+// maxCachedRenders bounds the rendered positions kept; past it the cache
+// starts over.
+const maxCachedRenders = 1024
+
+// warmWidths are the terminal widths positions are rendered at before anyone
+// connects.
+var warmWidths = []int{80, 100, 120}
+
+// contentCache keeps the files sessions open, read and rendered, so opening a
+// position again costs a stat instead of a read and a glamour render. Files
+// are checked against their modification time and everything is dropped when
+// the content is reloaded. It also keeps the board new sessions start from,
+// the logo and QR code they draw and the banner template.
+type contentCache struct {
+	mu       sync.Mutex
+	files    map[string]cachedFile
+	rendered map[renderedKey]string
+	logos    map[logoKey]string
+	codes    map[qrKey]string
+	banner   *cachedBanner
+	board    *board
+	// live is set while the content watcher runs, which is what keeps board
+	// up to date.
+	live bool
+}
+
+// board is the positions and their search index as last read.
+type board struct {
+	meta  *utils.PositionMeta
+	index *search.Index
+}
+
+type logoKey struct {
+	path    string
+	modTime time.Time
+	mode    termimage.Mode
+}
+
+type qrKey struct {
+	content string
+	opts    qr.Options
+}
+
+// cachedBanner is the banner template as it was when it was parsed.
+type cachedBanner struct {
+	path    string
+	modTime time.Time
+	size    int64
+	tmpl    *template.Template
+}
+
+// cachedFile is a file as it was when it was read.
+type cachedFile struct {
+	modTime time.Time
+	size    int64
+	raw     string
+	// body is what FileBody shows of it.
+	body string
+}
+
+// renderedKey tells apart the renders of one version of a file.
+type renderedKey struct {
+	fileName string
+	modTime  time.Time
+	renderer rendererKey
+	raw      bool
+}
+
+func newContentCache() *contentCache {
+	return &contentCache{
+		files:    make(map[string]cachedFile),
+		rendered: make(map[renderedKey]string),
+		logos:    make(map[logoKey]string),
+		codes:    make(map[qrKey]string),
+	}
+}
+
+// newRenderedKey tells apart the renders of the version of fileName from
+// modTime.
+func newRenderedKey(fileName string, modTime time.Time, theme components.Theme, width int, raw bool) renderedKey {
+	return renderedKey{fileName: fileName, modTime: modTime, renderer: newRendererKey(theme, width), raw: raw}
+}
+
+// watching keeps the board between reloads from now on.
+func (c *contentCache) watching() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.live = true
+}
+
+// positions is the board a new session starts from. While the content
+// watcher runs it is read once and kept until the next reload; without it
+// every session reads the directory itself, and the copy kept is only for
+// lastBoard.
+func (c *contentCache) positions(dir string) (*utils.PositionMeta, *search.Index, error) {
+	c.mu.Lock()
+	b, live := c.board, c.live
+	c.mu.Unlock()
+	if live && b != nil {
+		return b.meta, b.index, nil
+	}
+
+	meta, err := utils.GetPositionMeta(dir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("can't read directory: %w", err)
+	}
+	index, err := search.Build(dir, meta)
+	if err != nil {
+		return nil, nil, fmt.Errorf("can't index directory: %w", err)
+	}
+	c.setPositions(meta, index)
+	return meta, index, nil
+}
+
+// lastBoard is the board as last read, without touching the directory, or nil
+// before anything read it.
+func (c *contentCache) lastBoard() *utils.PositionMeta {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.board == nil {
+		return nil
+	}
+	return c.board.meta
+}
+
+// setPositions replaces the board after a reload.
+func (c *contentCache) setPositions(meta *utils.PositionMeta, index *search.Index) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.board = &board{meta: meta, index: index}
+}
+
+// logo renders the logo at path in mode, once for each version of the file.
+func (c *contentCache) logo(path string, mode termimage.Mode) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	key := logoKey{path: path, modTime: info.ModTime(), mode: mode}
+	c.mu.Lock()
+	logo, ok := c.logos[key]
+	c.mu.Unlock()
+	if ok {
+		return logo, nil
+	}
+
+	logo, err = renderLogo(path, logoHeight, logoPadding, mode)
+	if err != nil {
+		return "", err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.logos[key] = logo
+	return logo, nil
+}
+
+// bannerTemplate parses the banner template at path, once for each version of
+// the file.
+func (c *contentCache) bannerTemplate(path string) (*template.Template, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	banner := c.banner
+	c.mu.Unlock()
+	if banner != nil && banner.path == path && banner.modTime.Equal(info.ModTime()) && banner.size == info.Size() {
+		return banner.tmpl, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := template.New("banner").Parse(string(content))
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.banner = &cachedBanner{path: path, modTime: info.ModTime(), size: info.Size(), tmpl: tmpl}
+	return tmpl, nil
+}
+
+// qrCode renders content as a QR code, once for each set of options, so
+// sessions share the club's links and apply URLs instead of each drawing
+// their own.
+func (c *contentCache) qrCode(content string, opts qr.Options) (string, error) {
+	key := qrKey{content: content, opts: opts}
+	c.mu.Lock()
+	code, ok := c.codes[key]
+	c.mu.Unlock()
+	if ok {
+		return code, nil
+	}
+
+	code, err := qr.Render(content, opts)
+	if err != nil {
+		return "", err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.codes[key] = code
+	return code, nil
+}
+
+// read returns fileName from dir, reading it again only when it changed since
+// the last time. Links out of dir are refused, in case one appeared after the
+// list was read.
+func (c *contentCache) read(dir string, fileName string) (cachedFile, error) {
+	if !utils.InsideRoot(dir, fileName) {
+		return cachedFile{}, &fs.PathError{Op: "open", Path: fileName, Err: fs.ErrNotExist}
+	}
+	path := filepath.Join(dir, fileName)
+	info, err := os.Stat(path)
+	if err != nil {
+		return cachedFile{}, err
+	}
+
+	c.mu.Lock()
+	cached, ok := c.files[path]
+	c.mu.Unlock()
+	if ok && cached.modTime.Equal(info.ModTime()) && cached.size == info.Size() {
+		return cached, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return cachedFile{}, err
+	}
+	cached = cachedFile{modTime: info.ModTime(), size: info.Size(), raw: string(content), body: utils.FileBody(fileName, string(content))}
+	c.mu.Lock()
+	c.files[path] = cached
+	c.mu.Unlock()
+	return cached, nil
+}
+
+// render returns the render stored under key, or stores what render makes of
+// it. Failed renders are not kept.
+func (c *contentCache) render(key renderedKey, render func() (string, error)) (string, error) {
+	c.mu.Lock()
+	rendered, ok := c.rendered[key]
+	c.mu.Unlock()
+	if ok {
+		return rendered, nil
+	}
+
+	rendered, err := render()
+	if err != nil {
+		return rendered, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.rendered) >= maxCachedRenders {
+		c.rendered = make(map[renderedKey]string)
+	}
+	c.rendered[key] = rendered
+	return rendered, nil
+}
+
+// clear drops the board and the positions, for when the content directory
+// changed. The logo and QR code live outside it.
+func (c *contentCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.files = make(map[string]cachedFile)
+	c.rendered = make(map[renderedKey]string)
+	c.board = nil
+}
+
+// warmCache reads the board and draws the logo, the QR codes for the club's
+// links and every position ahead of the first session, so nobody waits on a
+// cold start. The positions are rendered in the configured theme on a dark
+// background at each of warmWidths.
+func warmCache(cfg *config.Config, svc *services) {
+	started := time.Now()
+	cache := svc.content
+	meta, _, err := cache.positions(cfg.Directory)
+	if err != nil {
+		log.Warn("could not warm the cache", "error", err)
+		return
+	}
+
+	for _, mode := range []termimage.Mode{termimage.TrueColor, termimage.ANSI256, termimage.ASCII} {
+		if _, err := cache.logo(cfg.LogoPath, mode); err != nil {
+			log.Warn("could not prerender the logo", "error", err)
+			break
+		}
+	}
+	urls := []string{cfg.DiscordURL}
+	for _, link := range clubLinks(cfg) {
+		urls = append(urls, link.URL)
+	}
+	for _, url := range urls {
+		for _, ascii := range []bool{false, true} {
+			for _, plain := range []bool{false, true} {
+				opts := qr.Options{ModuleSize: cfg.QR.ModuleSize, QuietZone: cfg.QR.QuietZone, ASCII: ascii, Plain: plain}
+				if _, err := cache.qrCode(url, opts); err != nil {
+					log.Warn("could not prerender qr code", "url", url, "error", err)
+				}
+			}
+		}
+	}
+
+	renders := 0
+	for _, fileName := range meta.FileNames {
+		if utils.IsDirEntry(fileName) || utils.IsImage(fileName) {
+			continue
+		}
+		file, err := cache.read(cfg.Directory, fileName)
+		if err != nil {
+			log.Warn("could not prerender position", "file", fileName, "error", err)
+			continue
+		}
+		for _, profile := range []termenv.Profile{termenv.TrueColor, termenv.ANSI256} {
+			theme := defaultTheme(cfg)
+			theme.Profile = profile
+			theme = theme.ForBackground(false)
+			for _, width := range warmWidths {
+				// Sessions wrap a column short of the terminal, see wrapWidth.
+				wrap := width - 1
+				_, err := cache.render(newRenderedKey(fileName, file.modTime, theme, wrap, false), func() (string, error) {
+					return renderText(svc.renderers, theme, fileName, wrap, false, file.body)
+				})
+				if err != nil {
+					log.Warn("could not prerender position", "file", fileName, "error", err)
+				}
+				renders++
+			}
+		}
+	}
+	log.Info("cache warmed", "positions", meta.PositionCount(), "renders", renders, "took", time.Since(started).Round(time.Millisecond))
+}
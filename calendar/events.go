@@ -0,0 +1,139 @@
+package calendar
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"organize/utils"
+)
+
+// Event is something the club has planned.
+type Event struct {
+	Title string
+	Start time.Time
+	// AllDay events only have a date.
+	AllDay   bool
+	Location string
+	// Body is markdown telling more about the event.
+	Body string
+	// File is where the event was read from.
+	File string
+}
+
+type frontmatter struct {
+	Title    string    `yaml:"title"`
+	Date     time.Time `yaml:"date"`
+	Location string    `yaml:"location"`
+}
+
+// Load reads the markdown events and .ics calendars in dir and returns the
+// ones that have not been and gone before now, soonest first. Events happen
+// until the end of the day they start on. A missing dir has no events; a file
+// that cannot be read is left out and the first such error returned with the
+// rest of the events.
+func Load(dir string, now time.Time) ([]Event, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var events []Event
+	var firstErr error
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		var found []Event
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".md":
+			var event Event
+			event, err = loadMarkdown(path)
+			found = []Event{event}
+		case ".ics":
+			found, err = loadCalendar(path)
+		default:
+			continue
+		}
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%s: %w", path, err)
+			}
+			continue
+		}
+		events = append(events, found...)
+	}
+
+	upcoming := events[:0]
+	for _, event := range events {
+		if !startOfDay(event.Start.In(now.Location())).AddDate(0, 0, 1).Before(now) {
+			upcoming = append(upcoming, event)
+		}
+	}
+	sort.SliceStable(upcoming, func(i, j int) bool {
+		if upcoming[i].Start.Equal(upcoming[j].Start) {
+			return upcoming[i].Title < upcoming[j].Title
+		}
+		return upcoming[i].Start.Before(upcoming[j].Start)
+	})
+	return upcoming, firstErr
+}
+
+func loadMarkdown(path string) (Event, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Event{}, err
+	}
+	var meta frontmatter
+	body, err := utils.ParseFrontmatter(string(content), &meta)
+	if err != nil {
+		return Event{}, err
+	}
+	if meta.Date.IsZero() {
+		return Event{}, errors.New("event has no date in its frontmatter")
+	}
+	if meta.Title == "" {
+		meta.Title = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return Event{
+		Title:    meta.Title,
+		Start:    meta.Date,
+		AllDay:   meta.Date.Equal(startOfDay(meta.Date)),
+		Location: meta.Location,
+		Body:     body,
+		File:     path,
+	}, nil
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// Countdown says how long there is until start, e.g. "in 3 days".
+func Countdown(start time.Time, now time.Time) string {
+	now = now.In(start.Location())
+	days := int(startOfDay(start).Sub(startOfDay(now)).Hours()+12) / 24
+	until := start.Sub(now)
+	switch {
+	case days > 1:
+		return fmt.Sprintf("in %d days", days)
+	case days == 1:
+		return "tomorrow"
+	case until >= 2*time.Hour:
+		return fmt.Sprintf("in %d hours", int(until.Hours()))
+	case until >= time.Hour:
+		return "in an hour"
+	case until > time.Minute:
+		return fmt.Sprintf("in %d minutes", int(until.Minutes()))
+	default:
+		return "today"
+	}
+}
@@ -0,0 +1,107 @@
+package calendar
+
+import (
+	"os"
+	"strings"
+	"time"
+)
+
+// property is a content line of an iCalendar file, see RFC 5545 section 3.1.
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// loadCalendar reads the VEVENTs of an iCalendar file. Their DESCRIPTION is
+// the body, each of its lines a paragraph.
+func loadCalendar(path string) ([]Event, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []Event
+	var event *Event
+	for _, p := range parseCalendar(string(content)) {
+		switch {
+		case p.name == "BEGIN" && p.value == "VEVENT":
+			event = &Event{File: path}
+		case event == nil:
+			continue
+		case p.name == "END" && p.value == "VEVENT":
+			if !event.Start.IsZero() {
+				events = append(events, *event)
+			}
+			event = nil
+		case p.name == "SUMMARY":
+			event.Title = unescapeText(p.value)
+		case p.name == "LOCATION":
+			event.Location = unescapeText(p.value)
+		case p.name == "DESCRIPTION":
+			event.Body = strings.ReplaceAll(unescapeText(p.value), "\n", "\n\n")
+		case p.name == "DTSTART":
+			event.Start, event.AllDay, err = parseDateTime(p)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return events, nil
+}
+
+// parseCalendar unfolds the content lines of an iCalendar file and splits
+// them into properties.
+func parseCalendar(content string) []property {
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	var properties []property
+	for _, line := range lines {
+		head, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Split(head, ";")
+		p := property{name: strings.ToUpper(fields[0]), params: make(map[string]string), value: value}
+		for _, param := range fields[1:] {
+			name, paramValue, _ := strings.Cut(param, "=")
+			p.params[strings.ToUpper(name)] = strings.Trim(paramValue, `"`)
+		}
+		properties = append(properties, p)
+	}
+	return properties
+}
+
+// parseDateTime reads a DATE or DATE-TIME value. Times that are neither UTC
+// nor given a TZID are taken as UTC, like dates in frontmatter.
+func parseDateTime(p property) (time.Time, bool, error) {
+	if p.params["VALUE"] == "DATE" || len(p.value) == len("20060102") {
+		t, err := time.Parse("20060102", p.value)
+		return t, true, err
+	}
+	if strings.HasSuffix(p.value, "Z") {
+		t, err := time.Parse("20060102T150405Z", p.value)
+		return t, false, err
+	}
+	location := time.UTC
+	if tzid := p.params["TZID"]; tzid != "" {
+		if loaded, err := time.LoadLocation(tzid); err == nil {
+			location = loaded
+		}
+	}
+	t, err := time.ParseInLocation("20060102T150405", p.value, location)
+	return t, false, err
+}
+
+var textEscapes = strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`)
+
+func unescapeText(s string) string {
+	return textEscapes.Replace(s)
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"organize/components"
+	"organize/utils"
+
+	"github.com/alecthomas/chroma"
+	"github.com/alecthomas/chroma/formatters"
+	"github.com/alecthomas/chroma/lexers"
+	"github.com/alecthomas/chroma/styles"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// codeStyles are the chroma styles that go with each theme on a dark
+// background. Light backgrounds all get github.
+var codeStyles = map[string]string{
+	"jodc":          "monokai",
+	"catppuccin":    "doom-one",
+	"dracula":       "dracula",
+	"high-contrast": "hr_high_contrast",
+}
+
+// highlightCode renders a source file with syntax highlighting and line
+// numbers. It is drawn in full colour like everything else and downgraded
+// with the rest of the view.
+func highlightCode(theme components.Theme, fileName string, content string) (string, error) {
+	lexer := lexers.Get(strings.ToLower(utils.CodeLanguage(fileName)))
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+	style := styles.Get(codeStyles[theme.Name])
+	if theme.Light {
+		style = styles.Get("github")
+	}
+
+	// Tabs would throw off the viewport's idea of how wide a line is.
+	content = strings.ReplaceAll(strings.TrimRight(content, "\n"), "\t", "    ")
+	iterator, err := chroma.Coalesce(lexer).Tokenise(nil, content)
+	if err != nil {
+		return "", err
+	}
+	lines := chroma.SplitTokensIntoLines(iterator.Tokens())
+
+	gutter := lipgloss.NewStyle().Foreground(theme.Muted)
+	digits := len(fmt.Sprint(len(lines)))
+	formatter := formatters.Get("terminal16m")
+	var b strings.Builder
+	for i, tokens := range lines {
+		if n := len(tokens); n > 0 {
+			tokens[n-1].Value = strings.TrimSuffix(tokens[n-1].Value, "\n")
+		}
+		var line bytes.Buffer
+		if err := formatter.Format(&line, style, chroma.Literator(tokens...)); err != nil {
+			return "", err
+		}
+		b.WriteString(gutter.Render(fmt.Sprintf("%*d │ ", digits, i+1)))
+		b.WriteString(line.String())
+		b.WriteString("\n")
+	}
+	return b.String(), nil
+}
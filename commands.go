@@ -0,0 +1,397 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"organize/applications"
+	"organize/config"
+	"organize/storage"
+	"organize/utils"
+
+	"github.com/charmbracelet/glamour"
+)
+
+const usage = `Usage: %[1]s <command> [flags]
+
+Commands:
+  serve            start the SSH server (default)
+  validate         check every position file for problems
+  preview <file>   render a single position in this terminal
+  announce <text>  show an announcement in every session of the running server
+  export applications [-format csv|json] [-o file]
+                   write every application out, for spreadsheets or moving hosts
+  import applications <file>
+                   add the applications in a csv or json export, skipping any
+                   already there
+
+Run "%[1]s <command> -h" for the flags of a command.
+`
+
+func runCommand(args []string) error {
+	command := "serve"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
+
+	switch command {
+	case "serve":
+		return serveCommand(args)
+	case "validate":
+		return validateCommand(args)
+	case "preview":
+		return previewCommand(args)
+	case "announce":
+		return announceCommand(args)
+	case "export":
+		return exportCommand(args)
+	case "import":
+		return importCommand(args)
+	case "help":
+		fmt.Printf(usage, filepath.Base(os.Args[0]))
+		return nil
+	}
+	fmt.Fprintf(os.Stderr, usage, filepath.Base(os.Args[0]))
+	return fmt.Errorf("unknown command %q", command)
+}
+
+func newFlagSet(name string) (*flag.FlagSet, *string) {
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	configPath := os.Getenv("JODC_CONFIG")
+	if configPath == "" {
+		configPath = config.DefaultPath
+	}
+	return flags, flags.String("config", configPath, "path to the configuration file")
+}
+
+func loadConfig(path string) (*config.Config, error) {
+	cfg, err := config.Load(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration in %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+func serveCommand(args []string) error {
+	flags, configPath := newFlagSet("serve")
+	host := flags.String("host", "", "address to listen on, overrides host and SSH_HOST")
+	port := flags.Int("port", 0, "port to listen on, overrides port and SSH_PORT")
+	flags.Parse(args)
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	if *host != "" {
+		cfg.Host = *host
+	}
+	if *port != 0 {
+		if cfg.PublicPort == cfg.Port {
+			cfg.PublicPort = *port
+		}
+		cfg.Port = *port
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid flags: %w", err)
+	}
+	serve(cfg, *configPath)
+	return nil
+}
+
+func announceCommand(args []string) error {
+	flags, configPath := newFlagSet("announce")
+	takeDown := flags.Bool("clear", false, "take the current announcement down")
+	flags.Parse(args)
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	if cfg.ControlSocket == "" {
+		return errors.New("control_socket is not set, so there is no server to talk to")
+	}
+
+	command := "clear"
+	if !*takeDown {
+		text := strings.TrimSpace(strings.Join(flags.Args(), " "))
+		if text == "" {
+			return errors.New("usage: announce <text> or announce -clear")
+		}
+		command = "announce " + strings.Join(strings.Fields(text), " ")
+	}
+	return sendControl(cfg.ControlSocket, command)
+}
+
+// openApplications is where the server keeps applications: the database when
+// there is one, otherwise applications_path.
+func openApplications(cfg *config.Config) (applications.Store, func() error, error) {
+	if cfg.DatabasePath != "" {
+		db, err := storage.Open(cfg.DatabasePath)
+		if err != nil {
+			return nil, nil, err
+		}
+		return db, db.Close, nil
+	}
+	return applications.NewFileStore(cfg.ApplicationsPath), func() error { return nil }, nil
+}
+
+// transferArgs parses flags wherever they come among the arguments, so
+// "applications -format csv" and "-format csv applications" alike work, and
+// returns the rest: what to move, then any file.
+func transferArgs(flags *flag.FlagSet, args []string) []string {
+	var positional []string
+	for {
+		flags.Parse(args)
+		args = flags.Args()
+		if len(args) == 0 {
+			return positional
+		}
+		positional, args = append(positional, args[0]), args[1:]
+	}
+}
+
+func exportCommand(args []string) error {
+	flags, configPath := newFlagSet("export")
+	format := flags.String("format", "csv", "csv for spreadsheets or json")
+	output := flags.String("o", "", "file to write to instead of standard output")
+	if rest := transferArgs(flags, args); len(rest) != 1 || rest[0] != "applications" {
+		return errors.New("usage: export applications [-format csv|json] [-o file]")
+	}
+	if *format != "csv" && *format != "json" {
+		return fmt.Errorf("unknown format %q, use csv or json", *format)
+	}
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	store, closeStore, err := openApplications(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+	submitted, err := store.Applications()
+	if err != nil {
+		return err
+	}
+
+	out := os.Stdout
+	if *output != "" {
+		// The export holds applicants' personal details like the store does.
+		if out, err = os.OpenFile(*output, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600); err != nil {
+			return err
+		}
+		defer out.Close()
+	}
+	if *format == "json" {
+		err = applications.WriteJSON(out, submitted)
+	} else {
+		err = applications.WriteCSV(out, submitted)
+	}
+	if err != nil {
+		return err
+	}
+	if *output != "" {
+		fmt.Fprintf(os.Stderr, "exported %d applications to %s\n", len(submitted), *output)
+	}
+	return nil
+}
+
+func importCommand(args []string) error {
+	flags, configPath := newFlagSet("import")
+	format := flags.String("format", "", "csv or json, guessed from the file name when empty")
+	rest := transferArgs(flags, args)
+	if len(rest) != 2 || rest[0] != "applications" {
+		return errors.New("usage: import applications [-format csv|json] <file>, or - for standard input")
+	}
+	path := rest[1]
+	if *format == "" {
+		*format = "csv"
+		if ext := strings.ToLower(filepath.Ext(path)); ext == ".json" || ext == ".jsonl" {
+			*format = "json"
+		}
+	}
+	if *format != "csv" && *format != "json" {
+		return fmt.Errorf("unknown format %q, use csv or json", *format)
+	}
+
+	in := os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		in = file
+	}
+	var incoming []applications.Application
+	var err error
+	if *format == "json" {
+		incoming, err = applications.ReadJSON(in)
+	} else {
+		incoming, err = applications.ReadCSV(in)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	store, closeStore, err := openApplications(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+	existing, err := store.Applications()
+	if err != nil {
+		return err
+	}
+
+	imported, skipped := 0, 0
+	for _, application := range incoming {
+		duplicate := false
+		for _, have := range existing {
+			if applications.Same(application, have) {
+				duplicate = true
+				break
+			}
+		}
+		if duplicate {
+			skipped++
+			continue
+		}
+		if err := store.Save(application); err != nil {
+			return fmt.Errorf("imported %d applications before failing: %w", imported, err)
+		}
+		existing = append(existing, application)
+		imported++
+	}
+	fmt.Printf("imported %d applications, skipped %d already there\n", imported, skipped)
+	return nil
+}
+
+func validateCommand(args []string) error {
+	flags, configPath := newFlagSet("validate")
+	flags.Parse(args)
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+
+	problems := 0
+	err = filepath.WalkDir(cfg.Directory, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != cfg.Directory && strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(path) != ".md" {
+			return nil
+		}
+		name, _ := filepath.Rel(cfg.Directory, path)
+		for _, problem := range validatePosition(path) {
+			fmt.Printf("%s: %s\n", filepath.ToSlash(name), problem)
+			problems++
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if problems > 0 {
+		return fmt.Errorf("found %d problem(s) in %s", problems, cfg.Directory)
+	}
+	fmt.Printf("all positions in %s look good\n", cfg.Directory)
+	return nil
+}
+
+var positionStatuses = map[string]bool{"": true, "open": true, "draft": true, "closed": true}
+
+func validatePosition(path string) []string {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return []string{err.Error()}
+	}
+
+	if !strings.HasPrefix(string(content), "---") {
+		return []string{"missing frontmatter block (title, description, tags, deadline, status, updated)"}
+	}
+	frontmatter, body, err := utils.ParsePosition(string(content))
+	if err != nil {
+		return []string{"frontmatter does not parse: " + err.Error()}
+	}
+
+	var problems []string
+	if strings.TrimSpace(frontmatter.Title) == "" {
+		problems = append(problems, "frontmatter is missing a title")
+	}
+	if strings.TrimSpace(frontmatter.Description) == "" {
+		problems = append(problems, "frontmatter is missing a description")
+	}
+	if !positionStatuses[frontmatter.Status] {
+		problems = append(problems, fmt.Sprintf("unknown status %q, use open, draft or closed", frontmatter.Status))
+	}
+	if strings.TrimSpace(body) == "" {
+		problems = append(problems, "position has no content")
+	}
+	if _, err := glamour.Render(body, "dark"); err != nil {
+		problems = append(problems, "markdown does not render: "+err.Error())
+	}
+	return problems
+}
+
+func previewCommand(args []string) error {
+	flags, configPath := newFlagSet("preview")
+	style := flags.String("style", "", "glamour style to render with, defaults to markdown_style or dark")
+	width := flags.Int("width", 80, "column to wrap the rendered markdown at")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		return errors.New("preview needs exactly one position file")
+	}
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(cfg.Directory, flags.Arg(0))
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		path = flags.Arg(0)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	styleOption := glamour.WithStandardStyle("dark")
+	switch {
+	case *style != "":
+		styleOption = glamour.WithStandardStyle(*style)
+	case cfg.MarkdownStyle != "":
+		styleOption = glamour.WithStylesFromJSONFile(cfg.MarkdownStyle)
+	}
+	renderer, err := glamour.NewTermRenderer(styleOption, glamour.WithWordWrap(*width))
+	if err != nil {
+		return err
+	}
+	rendered, err := renderer.Render(utils.PositionBody(string(content)))
+	if err != nil {
+		return err
+	}
+	fmt.Print(rendered)
+	return nil
+}
@@ -1,54 +1,276 @@
 package components
 
 import (
+	"fmt"
 	"math"
+	"strings"
+	"time"
+
+	"organize/calendar"
+	"organize/gitsync"
+	"organize/guestbook"
+	"organize/search"
+	"organize/utils"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
 )
 
-func TextWithBackgroundView(backgroundColor string, text string, outerPadding bool, blink bool ) string {
+func TextWithBackgroundView(theme Theme, backgroundColor lipgloss.Color, text string, outerPadding bool, blink bool ) string {
 	outerContainerStyle := lipgloss.NewStyle()
 	if outerPadding {
 		outerContainerStyle = outerContainerStyle.Padding(1)
 	}
 	innerContainerStyle := lipgloss.NewStyle().
 		Padding(0, 0).
-		Background(lipgloss.
-			Color(backgroundColor))
+		Background(backgroundColor)
 	textStyle := lipgloss.NewStyle().
 		Bold(true).
-		Foreground(lipgloss.Color("#000000")).
+		Foreground(theme.OnAccent).
 		Blink(blink)
 
 	return outerContainerStyle.Render(innerContainerStyle.Render(textStyle.Render(text))) + "\n"
 }
 
+// Breakpoints for small terminals such as phone SSH clients. Below
+// NarrowWidth text and cards take the whole width; below CompactWidth the
+// banner shortens and only the selected card keeps its description.
+const (
+	NarrowWidth  = 80
+	CompactWidth = 60
+)
+
+// ColumnWidth is how wide text and single cards are drawn in width.
+func ColumnWidth(width int) int {
+	if width < NarrowWidth {
+		// Cards are two cells wider than this for their border.
+		return utils.Max(1, width-2)
+	}
+	return int(math.Round(float64(width) * 0.6))
+}
+
+// cardDescription drops the description of unselected cards on compact
+// terminals, so more positions fit on the screen.
+func cardDescription(width int, description string, selected bool) string {
+	if width < CompactWidth && !selected {
+		return ""
+	}
+	return description
+}
+
+func BannerView(theme Theme, width int) string {
+	if width < CompactWidth {
+		return TextWithBackgroundView(theme, theme.Accent, bannerText(width), false, false) + "\n"
+	}
+	return TextWithBackgroundView(theme, theme.Accent, bannerText(width), true, false)
+}
+
+func bannerText(width int) string {
+	if width < CompactWidth {
+		return " JODC "
+	}
+	return " __THE_SUPREME_AND_POWERFUL_JODC_GANG__ "
+}
+
+// SplashView is the banner typed out up to its typed'th character in the
+// middle of a width by height screen.
+func SplashView(theme Theme, width int, height int, typed int) string {
+	text := []rune(bannerText(width))
+	shown := string(text[:utils.Min(typed, len(text))])
+	if typed < len(text) {
+		// The rest stays blank so the banner doesn't move while it grows.
+		shown += strings.Repeat(" ", len(text)-typed)
+	}
+	banner := TextWithBackgroundView(theme, theme.Accent, shown, false, false)
+	return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, strings.TrimSuffix(banner, "\n"))
+}
+
+func BrandingView(width int, logo string, qr string) string {
+	fits := func(s string) bool {
+		return s != "" && lipgloss.Width(s) <= width
+	}
+
+	if qr == "" {
+		if fits(logo) {
+			return logo + "\n"
+		}
+		return ""
+	}
+
+	sideBySide := lipgloss.JoinHorizontal(lipgloss.Top, logo, qr)
+	switch {
+	case fits(sideBySide):
+		return sideBySide + "\n"
+	case fits(logo) && fits(qr):
+		return lipgloss.JoinVertical(lipgloss.Left, logo, qr) + "\n"
+	case fits(qr):
+		return qr + "\n"
+	}
+	return ""
+}
+
 func IntroDescriptionView(width int) string {
 	return lipgloss.NewStyle().
-		Width(int(math.Round(float64(width)*0.6))).
+		Width(ColumnWidth(width)).
 		Padding(0, 1).
 		Render("We are the JIIT OPEN SOURCE DEVELOPERS CLUB\n\nTo participate and learn more aboout us, join our discord!!\n\nGet started at the README. Use arrow keys or vim keys to navigate & enter to select.") + "\n\n"
 }
 
-func PositionListItemView(maxWidth int, title string, description string, selected bool) string {
-	titleTextStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("205")).
+func PrivacyNoticeView(width int, notice string) string {
+	return lipgloss.NewStyle().
+		Width(ColumnWidth(width)).
+		Padding(0, 1).
+		Faint(true).
+		Italic(true).
+		Render(notice) + "\n\n"
+}
+
+const MinGridCardWidth = 20
+
+// autoGridCardWidth is the card width the grid aims for when it picks the
+// number of columns itself.
+const autoGridCardWidth = 30
+
+func HelpSectionView(theme Theme, width int, title string, description string, bindings [][2]string) string {
+	titleStyle := lipgloss.NewStyle().
+		Foreground(theme.Accent).
+		Bold(true)
+	descriptionStyle := lipgloss.NewStyle().
+		Width(width).
+		Faint(true)
+	keyStyle := lipgloss.NewStyle().
+		Foreground(theme.Title).
+		Width(12)
+
+	s := titleStyle.Render(title) + "\n"
+	s += descriptionStyle.Render(description) + "\n\n"
+	for _, binding := range bindings {
+		s += "  " + keyStyle.Render(binding[0]) + binding[1] + "\n"
+	}
+	return lipgloss.NewStyle().Padding(0, 1).Render(s) + "\n\n"
+}
+
+func FarewellView(theme Theme, reconnect string, discord string) string {
+	return lipgloss.NewStyle().
+		Foreground(theme.Accent).
+		Bold(true).
+		Render("Thanks for visiting JODC! ") +
+		lipgloss.NewStyle().
+			Faint(true).
+			Render(fmt.Sprintf("Reconnect: %s · Discord: %s", reconnect, discord))
+}
+
+func BreadcrumbView(theme Theme, crumbs []string) string {
+	parts := make([]string, len(crumbs))
+	for i, crumb := range crumbs {
+		style := lipgloss.NewStyle().Faint(true)
+		if i == len(crumbs)-1 {
+			style = lipgloss.NewStyle().Foreground(theme.Accent).Bold(true)
+		}
+		parts[i] = style.Render(crumb)
+	}
+	return lipgloss.NewStyle().Padding(0, 1).Render(strings.Join(parts, " › ")) + "\n\n"
+}
+
+func AdminSectionView(theme Theme, width int, title string, lines []string) string {
+	titleStyle := lipgloss.NewStyle().
+		Foreground(theme.Accent).
+		Bold(true)
+	lineStyle := lipgloss.NewStyle().
+		MaxWidth(width)
+
+	s := titleStyle.Render(title) + "\n"
+	for _, line := range lines {
+		s += "  " + lineStyle.Render(line) + "\n"
+	}
+	return lipgloss.NewStyle().Padding(0, 1).Render(s) + "\n\n"
+}
+
+func BrowsingView(count int) string {
+	people := "people"
+	if count == 1 {
+		people = "person"
+	}
+	return lipgloss.NewStyle().
+		Padding(0, 1).
+		Faint(true).
+		Render(fmt.Sprintf("👀 %d %s browsing right now", count, people))
+}
+
+func AnnouncementView(theme Theme, width int, text string) string {
+	return lipgloss.NewStyle().
+		Width(width).
+		MaxWidth(width).
+		MaxHeight(1).
+		Padding(0, 1).
+		Bold(true).
+		Foreground(theme.OnAccent).
+		Background(theme.Accent).
+		Render("📣 " + text)
+}
+
+func NoticeView(theme Theme, width int, notice string) string {
+	if notice == "" {
+		return ""
+	}
+	return lipgloss.NewStyle().
+		MaxWidth(width).
+		Padding(0, 1).
+		Bold(true).
+		Foreground(theme.OnAccent).
+		Background(theme.Danger).
+		Render(notice) + "\n\n"
+}
+
+func StatusMessageView(theme Theme, message string) string {
+	return lipgloss.NewStyle().
+		Padding(0, 1).
+		Foreground(theme.Accent).
+		Render(message) + "\n"
+}
+
+func PositionListItemView(theme Theme, maxWidth int, title string, description string, selected bool) string {
+	return positionCardView(theme, ColumnWidth(maxWidth), title, cardDescription(maxWidth, description, selected), selected)
+}
+
+func positionTitleStyle(theme Theme) lipgloss.Style {
+	return lipgloss.NewStyle().
+		Foreground(theme.Title).
+		Bold(true)
+}
+
+func matchHighlightStyle(theme Theme) lipgloss.Style {
+	return lipgloss.NewStyle().
+		Foreground(theme.Accent).
+		Underline(true).
 		Bold(true)
+}
+
+func positionCardView(theme Theme, width int, title string, description string, selected bool) string {
+	return styledPositionCardView(theme, width, positionTitleStyle(theme).Render(title), description, selected)
+}
+
+func styledPositionCardView(theme Theme, width int, titleContent string, descriptionTextContent string, selected bool) string {
 	containerStyle := lipgloss.NewStyle().
 		BorderStyle(lipgloss.ThickBorder()).
-		BorderForeground(lipgloss.Color("63")).
-		Width(int(math.Round(float64(maxWidth) * 0.6)))
+		BorderForeground(theme.Border).
+		Width(width)
 	if selected {
 		containerStyle = containerStyle.
-			BorderForeground(lipgloss.Color("#fcd34d"))
+			BorderForeground(theme.Accent)
+		// Without colours the accent is lost, so the border has to show it.
+		if theme.Profile == termenv.Ascii {
+			containerStyle = containerStyle.BorderStyle(lipgloss.DoubleBorder())
+		}
 	}
 	innerContainerStyle := lipgloss.NewStyle().
 		PaddingLeft(2).
 		PaddingRight(2)
 
-	titleContent := titleTextStyle.Render(title)
-	descriptionTextContent := lipgloss.NewStyle().Render(description)
-	textContent := titleContent + "\n" + descriptionTextContent
+	textContent := titleContent
+	if descriptionTextContent != "" {
+		textContent += "\n" + descriptionTextContent
+	}
 
 	innerContainerContent := innerContainerStyle.Render(textContent)
 	containerContent := containerStyle.Render(innerContainerContent)
@@ -56,41 +278,682 @@ func PositionListItemView(maxWidth int, title string, description string, select
 	return containerContent
 }
 
-var (
-	HeaderStyle = func() lipgloss.Style {
-		b := lipgloss.RoundedBorder()
-		b.Right = "├"
+// HighlightMatches renders text with base, picking out the runes at the given
+// offsets so fuzzy matches stand out.
+func HighlightMatches(theme Theme, text string, matches []int, base lipgloss.Style) string {
+	if len(matches) == 0 {
+		return base.Render(text)
+	}
+
+	matched := make(map[int]bool, len(matches))
+	for _, i := range matches {
+		matched[i] = true
+	}
+
+	highlight := matchHighlightStyle(theme)
+	var s string
+	for i, r := range []rune(text) {
+		if matched[i] {
+			s += highlight.Render(string(r))
+		} else {
+			s += base.Render(string(r))
+		}
+	}
+	return s
+}
+
+// HighlightQuery marks every case-insensitive occurrence of query in a plain
+// text line. The current match gets the accent colour, others are reversed.
+func HighlightQuery(theme Theme, line string, query string, current bool) string {
+	style := lipgloss.NewStyle().Reverse(true)
+	if current {
+		style = lipgloss.NewStyle().
+			Background(theme.Accent).
+			Foreground(theme.OnAccent)
+	}
+
+	lower := strings.ToLower(line)
+	query = strings.ToLower(query)
+	if query == "" || len(lower) != len(line) {
+		return style.Render(line)
+	}
+
+	var b strings.Builder
+	for {
+		i := strings.Index(lower, query)
+		if i < 0 {
+			b.WriteString(line)
+			return b.String()
+		}
+		b.WriteString(line[:i])
+		b.WriteString(style.Render(line[i : i+len(query)]))
+		line, lower = line[i+len(query):], lower[i+len(query):]
+	}
+}
+
+func FilteredPositionsView(theme Theme, width int, titles []string, descriptions []string, titleMatches [][]int, descriptionMatches [][]int, cursor int) string {
+	if len(titles) == 0 {
 		return lipgloss.NewStyle().
-			BorderStyle(b).
-			BorderForeground(lipgloss.Color("#fcd34d")).
 			Padding(0, 1).
-			Bold(true)
-	}()
-
-	FooterStyle = func() lipgloss.Style {
-		b := lipgloss.RoundedBorder()
-		b.Left = "┤"
-		return HeaderStyle.Copy().BorderStyle(b)
-	}()
-)
+			Faint(true).
+			Render("No positions match this filter.") + "\n"
+	}
 
-func OpenPositionsGrid(width int, fileNames []string, fileDescriptions []string, cursor int) string {
+	cardWidth := ColumnWidth(width)
 	var rows []string
+	for i := range titles {
+		title := HighlightMatches(theme, titles[i], titleMatches[i], positionTitleStyle(theme))
+		description := HighlightMatches(theme, descriptions[i], descriptionMatches[i], lipgloss.NewStyle())
+		rows = append(rows, styledPositionCardView(theme, cardWidth, title, cardDescription(width, description, i == cursor), i == cursor))
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, rows...) + "\n"
+}
+
+func HeaderStyle(theme Theme) lipgloss.Style {
+	b := lipgloss.RoundedBorder()
+	b.Right = "├"
+	return lipgloss.NewStyle().
+		BorderStyle(b).
+		BorderForeground(theme.Accent).
+		Padding(0, 1).
+		Bold(true)
+}
+
+func FooterStyle(theme Theme) lipgloss.Style {
+	b := lipgloss.RoundedBorder()
+	b.Left = "┤"
+	return HeaderStyle(theme).BorderStyle(b)
+}
+
+// OpenPositionsGrid lays out the visible positions in order, in as many
+// columns as fit in width when columns is 0. When pinned is set the first one
+// goes above the banner as the place to start.
+func OpenPositionsGrid(theme Theme, width int, columns int, fileNames []string, fileDescriptions []string, badges []string, visible []int, pinned bool, cursor int) string {
+	if len(visible) == 0 {
+		return lipgloss.NewStyle().
+			Padding(0, 1).
+			Faint(true).
+			Render("Nothing to see here yet.") + "\n"
+	}
+
+	var rows []string
+	for _, row := range gridLayout(theme, width, columns, fileNames, fileDescriptions, badges, visible, pinned, cursor) {
+		rows = append(rows, row.view())
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, rows...)
+}
+
+// GridPositionAt is the position whose card covers column x of line y of the
+// grid OpenPositionsGrid draws with the same arguments, or -1 when there is
+// none there.
+func GridPositionAt(theme Theme, width int, columns int, fileNames []string, fileDescriptions []string, badges []string, visible []int, pinned bool, cursor int, x int, y int) int {
+	top := 0
+	for _, row := range gridLayout(theme, width, columns, fileNames, fileDescriptions, badges, visible, pinned, cursor) {
+		left := 0
+		for i, card := range row.cards {
+			if x >= left && x < left+lipgloss.Width(card) && y >= top && y < top+lipgloss.Height(card) {
+				return row.positions[i]
+			}
+			left += lipgloss.Width(card)
+		}
+		top += lipgloss.Height(row.view())
+	}
+	return -1
+}
+
+// GridPages splits the grid OpenPositionsGrid would draw into pages of whole
+// rows, each listing the positions on it. The first page is at most
+// firstHeight lines tall and the rest height. Every page gets at least one
+// row, however short they are.
+func GridPages(theme Theme, width int, columns int, fileNames []string, fileDescriptions []string, badges []string, visible []int, pinned bool, firstHeight int, height int) [][]int {
+	if width < CompactWidth {
+		// The selected card shows its description, a line more than the rest.
+		firstHeight--
+		height--
+	}
+	var pages [][]int
+	var page []int
+	used := 0
+	for _, row := range gridLayout(theme, width, columns, fileNames, fileDescriptions, badges, visible, pinned, -1) {
+		rowHeight := lipgloss.Height(row.view())
+		limit := height
+		if len(pages) == 0 {
+			limit = firstHeight
+		}
+		if len(page) > 0 && used+rowHeight > limit {
+			pages = append(pages, page)
+			page, used = nil, 0
+		}
+		page = append(page, row.positions...)
+		used += rowHeight
+	}
+	if len(page) > 0 || len(pages) == 0 {
+		pages = append(pages, page)
+	}
+	return pages
+}
+
+// PagerView shows which page of the grid is on screen, with dots when there
+// are few enough pages, and the keys that turn it.
+func PagerView(theme Theme, width int, dots string, page int, pages int, keys string) string {
+	hint := fmt.Sprintf("page %d of %d · %s", page, pages, keys)
+	if dots != "" {
+		hint = " " + hint
+	}
+	hint = lipgloss.NewStyle().Foreground(theme.Muted).Render(utils.Truncate(hint, width-2-lipgloss.Width(dots)))
+	return lipgloss.NewStyle().Padding(0, 1).Render(dots + hint)
+}
+
+// gridRow is one row of cards in the grid, with the positions they show.
+type gridRow struct {
+	cards     []string
+	positions []int
+	// below is drawn under the cards, like the banner under a pinned card.
+	below string
+}
+
+func (r gridRow) view() string {
+	return lipgloss.JoinHorizontal(lipgloss.Top, r.cards...) + r.below
+}
+
+func gridLayout(theme Theme, width int, columns int, fileNames []string, fileDescriptions []string, badges []string, visible []int, pinned bool, cursor int) []gridRow {
+	var rows []gridRow
 	var maxWidth = width
 
-	readmeSelected := cursor == 0
-	styledReadme := PositionListItemView(maxWidth, fileNames[0], fileDescriptions[0], readmeSelected) + "\n\n\n"
-	openPositions := TextWithBackgroundView("#C48FDC", "  WORK WITH US!!", false, true)
-	startHere := styledReadme + openPositions
-	rows = append(rows, startHere)
-
-	for i := 1; i < len(fileNames); i++ {
-		var row string
-		selected := cursor == i
-		styledFileName := PositionListItemView(maxWidth, fileNames[i], fileDescriptions[i], selected)
-		row = lipgloss.JoinHorizontal(lipgloss.Top, row, styledFileName)
+	if len(visible) == 0 {
+		return nil
+	}
+	if pinned {
+		readmeSelected := cursor == visible[0]
+		first := visible[0]
+		styledReadme := gridCardView(theme, ColumnWidth(maxWidth), fileNames[first], cardDescription(maxWidth, fileDescriptions[first], readmeSelected), badges[first], readmeSelected)
+		openPositions := TextWithBackgroundView(theme, theme.Banner, "  WORK WITH US!!", false, true)
+		rows = append(rows, gridRow{cards: []string{styledReadme}, positions: []int{first}, below: "\n\n\n" + openPositions})
+		visible = visible[1:]
+	}
+
+	if columns == 0 {
+		columns = width / (autoGridCardWidth + 2)
+	}
+	if columns > 1 {
+		// Each card carries a two-cell border, so shed columns until every
+		// card keeps a readable width.
+		for columns > 1 && width/columns-2 < MinGridCardWidth {
+			columns--
+		}
+	}
+	if columns <= 1 {
+		for _, i := range visible {
+			selected := cursor == i
+			styledFileName := gridCardView(theme, ColumnWidth(maxWidth), fileNames[i], cardDescription(maxWidth, fileDescriptions[i], selected), badges[i], selected)
+			rows = append(rows, gridRow{cards: []string{styledFileName}, positions: []int{i}})
+		}
+		return rows
+	}
+
+	cardWidth := width/columns - 2
+	for start := 0; start < len(visible); start += columns {
+		var row gridRow
+		for _, i := range visible[start:utils.Min(start+columns, len(visible))] {
+			row.cards = append(row.cards, gridCardView(theme, cardWidth, fileNames[i], fileDescriptions[i], badges[i], cursor == i))
+			row.positions = append(row.positions, i)
+		}
 		rows = append(rows, row)
 	}
+	return rows
+}
 
-	return lipgloss.JoinVertical(lipgloss.Left, rows...)
+func ViewBadge(theme Theme, views int) string {
+	style := lipgloss.NewStyle().
+		Foreground(theme.Warning)
+	if views == 1 {
+		return style.Render("🔥 1 view")
+	}
+	return style.Render(fmt.Sprintf("🔥 %d views", views))
+}
+
+// DeadlineBadge says how long is left to apply, standing out once the
+// deadline is close, or that applications have closed.
+func DeadlineBadge(theme Theme, deadline time.Time, expired bool, soon bool, now time.Time) string {
+	if expired {
+		return lipgloss.NewStyle().Foreground(theme.Muted).Render("closed")
+	}
+	style := lipgloss.NewStyle().Foreground(theme.Muted)
+	if soon {
+		style = style.Foreground(theme.Danger)
+	}
+	return style.Render("⏳ closes " + calendar.Countdown(deadline, now))
+}
+
+// DraftBadge flags a draft, which only admins see.
+func DraftBadge(theme Theme) string {
+	return lipgloss.NewStyle().
+		Foreground(theme.OnAccent).
+		Background(theme.Warning).
+		Bold(true).
+		Padding(0, 1).
+		Render("DRAFT")
+}
+
+func NewBadge(theme Theme) string {
+	return lipgloss.NewStyle().
+		Foreground(theme.OnAccent).
+		Background(theme.Success).
+		Bold(true).
+		Padding(0, 1).
+		Render("NEW")
+}
+
+func gridCardView(theme Theme, width int, title string, description string, badge string, selected bool) string {
+	titleContent := positionTitleStyle(theme).Render(title)
+	if badge != "" {
+		titleContent += "  " + badge
+	}
+	return styledPositionCardView(theme, width, titleContent, description, selected)
+}
+
+// TabBarView is a line of numbered tabs with the active one highlighted.
+// Tabs that don't fit in width only show their number, wrapping onto further
+// lines if even the numbers don't fit.
+func TabBarView(theme Theme, width int, titles []string, active int) string {
+	tabStyle := lipgloss.NewStyle().
+		Padding(0, 1).
+		Foreground(theme.Muted)
+	activeTabStyle := tabStyle.Copy().
+		Foreground(theme.OnAccent).
+		Background(theme.Accent).
+		Bold(true)
+
+	render := func(short bool) string {
+		tabs := make([]string, len(titles))
+		for i, title := range titles {
+			label := fmt.Sprintf("%d %s", i+1, title)
+			if short && i != active {
+				label = fmt.Sprint(i + 1)
+			}
+			style := tabStyle
+			if i == active {
+				style = activeTabStyle
+			}
+			tabs[i] = style.Render(label)
+		}
+		var lines []string
+		line := ""
+		for _, tab := range tabs {
+			if line != "" && lipgloss.Width(line)+1+lipgloss.Width(tab) > width-2 {
+				lines = append(lines, line)
+				line = ""
+			}
+			if line != "" {
+				line += " "
+			}
+			line += tab
+		}
+		lines = append(lines, line)
+		return lipgloss.NewStyle().Padding(0, 1).Render(strings.Join(lines, "\n"))
+	}
+	bar := render(false)
+	if lipgloss.Height(bar) > 1 {
+		bar = render(true)
+	}
+	return bar + "\n\n"
+}
+
+// CategoryBarView renders one pill per category, wrapping onto further lines
+// when they don't fit in width.
+func CategoryBarView(theme Theme, width int, categories []string, active int) string {
+	categoryStyle := lipgloss.NewStyle().
+		Padding(0, 1).
+		MarginRight(1).
+		Foreground(theme.Accent).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(theme.Muted)
+	activeCategoryStyle := categoryStyle.Copy().
+		Foreground(theme.OnAccent).
+		Background(theme.Accent).
+		BorderForeground(theme.Accent).
+		Bold(true)
+
+	var rows []string
+	var pills []string
+	rowWidth := 0
+	for i, category := range categories {
+		style := categoryStyle
+		if i == active {
+			style = activeCategoryStyle
+		}
+		pill := style.Render(category)
+		if len(pills) > 0 && rowWidth+lipgloss.Width(pill) > width-2 {
+			rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top, pills...))
+			pills, rowWidth = nil, 0
+		}
+		pills = append(pills, pill)
+		rowWidth += lipgloss.Width(pill)
+	}
+	if len(pills) > 0 {
+		rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top, pills...))
+	}
+	return lipgloss.NewStyle().Padding(0, 1).Render(lipgloss.JoinVertical(lipgloss.Left, rows...)) + "\n"
+}
+
+// SortView says how the grid is sorted and which key changes it, wrapped to
+// width.
+func SortView(theme Theme, width int, order string, key string) string {
+	return lipgloss.NewStyle().
+		Foreground(theme.Muted).
+		Padding(0, 1).
+		Width(width).
+		Render(fmt.Sprintf("Sorted by %s · %s to change", order, key)) + "\n"
+}
+
+// ContentsView is a position's table of contents in a box width wide and
+// height tall, scrolled to keep the cursor in view. Headings are indented by
+// level and the one being read is marked.
+func ContentsView(theme Theme, width int, height int, titles []string, levels []int, cursor int, current int) string {
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(theme.Accent).
+		Padding(0, 1)
+	box = box.Width(width - box.GetHorizontalBorderSize())
+	innerWidth := utils.Max(1, width-box.GetHorizontalFrameSize())
+	rows := utils.Max(1, height-box.GetVerticalFrameSize()-1)
+
+	first := utils.Max(0, utils.Min(cursor-rows/2, len(titles)-rows))
+	lines := []string{lipgloss.NewStyle().Foreground(theme.Accent).Bold(true).Render("Contents")}
+	for i := first; i < len(titles) && i < first+rows; i++ {
+		marker := "  "
+		if i == current {
+			marker = "• "
+		}
+		indent := strings.Repeat("  ", utils.Max(0, levels[i]-1))
+		line := utils.Truncate(marker+indent+titles[i], innerWidth)
+		style := lipgloss.NewStyle().Width(innerWidth)
+		if i == cursor {
+			style = style.Foreground(theme.OnAccent).Background(theme.Accent).Bold(true)
+		}
+		lines = append(lines, style.Render(line))
+	}
+	return box.Height(height - box.GetVerticalFrameSize()).Render(strings.Join(lines, "\n"))
+}
+
+// ApplyQRView is a box with a QR code for applying to the position called
+// title at url, or only the url when code is empty.
+func ApplyQRView(theme Theme, title string, code string, url string) string {
+	heading := lipgloss.NewStyle().Foreground(theme.Accent).Bold(true).Render("Apply for " + title)
+	link := lipgloss.NewStyle().Faint(true).Render(url)
+	lines := []string{heading, ""}
+	if code != "" {
+		lines = append(lines, "Scan to carry on on your phone", "", code, "")
+	} else {
+		lines = append(lines, "Make the terminal bigger to scan a QR code, or open", "")
+	}
+	lines = append(lines, link)
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(theme.Accent).
+		Padding(0, 2).
+		Render(lipgloss.JoinVertical(lipgloss.Center, lines...))
+}
+
+// LinksView lists the names of the links with the one under the cursor
+// highlighted, and its url and QR code code next to the list, or under it
+// when they don't fit side by side in width.
+func LinksView(theme Theme, width int, names []string, url string, code string, cursor int) string {
+	itemStyle := lipgloss.NewStyle().Padding(0, 1)
+	selectedStyle := itemStyle.Copy().
+		Foreground(theme.OnAccent).
+		Background(theme.Accent).
+		Bold(true)
+
+	items := make([]string, len(names))
+	for i, name := range names {
+		items[i] = itemStyle.Render(name)
+		if i == cursor {
+			items[i] = selectedStyle.Render(name)
+		}
+	}
+	list := lipgloss.NewStyle().Padding(1, 2).Render(lipgloss.JoinVertical(lipgloss.Left, items...))
+	link := lipgloss.NewStyle().Padding(1, 2).Render(lipgloss.JoinVertical(lipgloss.Left,
+		lipgloss.NewStyle().Faint(true).Render(url), "", code))
+
+	if lipgloss.Width(list)+lipgloss.Width(link) > width {
+		return lipgloss.JoinVertical(lipgloss.Left, list, link)
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Top, list, link)
+}
+
+// EventCardView is an event on the Events tab with how long is left until it,
+// when and where.
+func EventCardView(theme Theme, width int, event calendar.Event, now time.Time, selected bool) string {
+	title := positionTitleStyle(theme).Render(event.Title) + "  " +
+		lipgloss.NewStyle().Foreground(theme.Accent).Render(calendar.Countdown(event.Start, now))
+	details := []string{EventDate(event)}
+	if event.Location != "" {
+		details = append(details, event.Location)
+	}
+	return styledPositionCardView(theme, ColumnWidth(width), title, cardDescription(width, strings.Join(details, " · "), selected), selected)
+}
+
+// EventDate is when an event starts, without a time for all-day events.
+func EventDate(event calendar.Event) string {
+	if event.AllDay {
+		return event.Start.Format("Monday 2 January 2006")
+	}
+	return event.Start.Format("Monday 2 January 2006, 15:04 MST")
+}
+
+// ArchivedCardView is a closed position on the Archive tab, greyed out so it
+// doesn't pass for one still open.
+func ArchivedCardView(theme Theme, width int, title string, description string, selected bool) string {
+	dim := lipgloss.NewStyle().Foreground(theme.Muted)
+	description = cardDescription(width, description, selected)
+	if description != "" {
+		description = dim.Render(description)
+	}
+	return styledPositionCardView(theme, ColumnWidth(width), dim.Copy().Bold(true).Render(title), description, selected)
+}
+
+// DimmedView greys out rendered content, dropping its own colours, for
+// reading closed positions.
+func DimmedView(theme Theme, content string) string {
+	dim := lipgloss.NewStyle().Foreground(theme.Muted)
+	lines := strings.Split(utils.StripANSI(content), "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			lines[i] = dim.Render(line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// TeamCardWidth is how wide each member's card is on the Team tab, and
+// AvatarHeight how many rows their avatar takes up in it.
+const (
+	TeamCardWidth = 28
+	AvatarHeight  = 6
+)
+
+// TeamView lays the team's cards out in as many columns as fit in width.
+// Members without an avatar get their initials in its place.
+func TeamView(theme Theme, width int, names []string, roles []string, handles []string, avatars []string) string {
+	cardStyle := lipgloss.NewStyle().
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(theme.Border).
+		Width(TeamCardWidth-2).
+		Align(lipgloss.Center)
+	initialsStyle := lipgloss.NewStyle().
+		Width(AvatarHeight*2).
+		Height(AvatarHeight).
+		Align(lipgloss.Center, lipgloss.Center).
+		Foreground(theme.OnAccent).
+		Background(theme.Accent).
+		Bold(true)
+
+	cards := make([]string, len(names))
+	for i, name := range names {
+		avatar := avatars[i]
+		if avatar == "" {
+			avatar = initialsStyle.Render(initials(name))
+		}
+		lines := []string{avatar, "", positionTitleStyle(theme).Render(name)}
+		if roles[i] != "" {
+			lines = append(lines, lipgloss.NewStyle().Foreground(theme.Muted).Render(roles[i]))
+		}
+		if handles[i] != "" {
+			lines = append(lines, lipgloss.NewStyle().Foreground(theme.Accent).Render("@"+handles[i]))
+		}
+		cards[i] = cardStyle.Render(strings.Join(lines, "\n"))
+	}
+
+	columns := utils.Max(1, width/(TeamCardWidth+1))
+	var rows []string
+	for start := 0; start < len(cards); start += columns {
+		row := cards[start:utils.Min(len(cards), start+columns)]
+		spaced := make([]string, 0, 2*len(row))
+		for _, card := range row {
+			spaced = append(spaced, card, " ")
+		}
+		rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top, spaced...))
+	}
+	return lipgloss.NewStyle().Padding(0, 1).Render(lipgloss.JoinVertical(lipgloss.Left, rows...))
+}
+
+// initials are the first letters of up to two words of name.
+func initials(name string) string {
+	var letters []rune
+	for _, word := range strings.Fields(name) {
+		if len(letters) == 2 {
+			break
+		}
+		letters = append(letters, []rune(strings.ToUpper(word))[0])
+	}
+	return string(letters)
+}
+
+// GuestbookView lists what visitors wrote in the guestbook, each message
+// under who signed it, with which key and when. An empty one asks for a
+// first message, signed with signKey.
+func GuestbookView(theme Theme, width int, entries []guestbook.Entry, now time.Time, signKey string) string {
+	if len(entries) == 0 {
+		return lipgloss.NewStyle().
+			Padding(1, 1).
+			Faint(true).
+			Render("Nobody has signed yet. Press " + signKey + " to be the first!")
+	}
+
+	signatureStyle := lipgloss.NewStyle().Foreground(theme.Muted)
+	messageStyle := lipgloss.NewStyle().Width(ColumnWidth(width))
+	var rows []string
+	for _, entry := range entries {
+		signature := positionTitleStyle(theme).Render(utils.Printable(entry.User)) + " " +
+			signatureStyle.Render(utils.Truncate(entry.Fingerprint, 20)+" · "+timeAgo(entry.SignedAt, now))
+		rows = append(rows, signature+"\n"+messageStyle.Render(utils.Printable(entry.Message)))
+	}
+	return lipgloss.NewStyle().Padding(1, 1).Render(strings.Join(rows, "\n\n"))
+}
+
+// HistoryView lists the commits that changed a position, newest first, each
+// subject under who made it and when, with a hint to diffKey for the latest
+// change.
+func HistoryView(theme Theme, width int, commits []gitsync.Commit, now time.Time, diffKey string) string {
+	signatureStyle := lipgloss.NewStyle().Foreground(theme.Muted)
+	subjectStyle := lipgloss.NewStyle().Width(ColumnWidth(width))
+	var rows []string
+	for _, commit := range commits {
+		signature := positionTitleStyle(theme).Render(utils.Printable(commit.Author)) + " " +
+			signatureStyle.Render(commit.Hash+" · "+timeAgo(commit.Date, now))
+		rows = append(rows, signature+"\n"+subjectStyle.Render(utils.Printable(commit.Subject)))
+	}
+	hint := lipgloss.NewStyle().Faint(true).Render("Press " + diffKey + " to see what the latest change did.")
+	return lipgloss.NewStyle().Padding(1, 1).Render(strings.Join(rows, "\n\n") + "\n\n" + hint)
+}
+
+// DiffView colours a patch the way git does: added lines in green, removed
+// ones in red and the hunk headers muted.
+func DiffView(theme Theme, width int, patch string) string {
+	added := lipgloss.NewStyle().Foreground(theme.Success)
+	removed := lipgloss.NewStyle().Foreground(theme.Danger)
+	hunk := lipgloss.NewStyle().Foreground(theme.Accent)
+	header := lipgloss.NewStyle().Foreground(theme.Muted)
+	lines := strings.Split(patch, "\n")
+	for i, line := range lines {
+		line = utils.Truncate(utils.Printable(strings.ReplaceAll(line, "\t", "    ")), utils.Max(1, width-2))
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"), strings.HasPrefix(line, "diff "), strings.HasPrefix(line, "index "):
+			line = header.Render(line)
+		case strings.HasPrefix(line, "@@"):
+			line = hunk.Render(line)
+		case strings.HasPrefix(line, "+"):
+			line = added.Render(line)
+		case strings.HasPrefix(line, "-"):
+			line = removed.Render(line)
+		}
+		lines[i] = line
+	}
+	return lipgloss.NewStyle().Padding(0, 1).Render(strings.Join(lines, "\n"))
+}
+
+// timeAgo is how long before now t was, roughly.
+func timeAgo(t time.Time, now time.Time) string {
+	since := now.Sub(t)
+	plural := func(n int, unit string) string {
+		if n == 1 {
+			return fmt.Sprintf("1 %s ago", unit)
+		}
+		return fmt.Sprintf("%d %ss ago", n, unit)
+	}
+	switch {
+	case since < time.Minute:
+		return "just now"
+	case since < time.Hour:
+		return plural(int(since.Minutes()), "minute")
+	case since < 24*time.Hour:
+		return plural(int(since.Hours()), "hour")
+	case since < 30*24*time.Hour:
+		return plural(int(since.Hours()/24), "day")
+	}
+	return t.Format("2 January 2006")
+}
+
+// ScrollbarView is a column height lines tall for a document total lines
+// long, with the thumb over the lines from offset on that are on screen.
+func ScrollbarView(theme Theme, height int, total int, offset int) string {
+	if height <= 0 {
+		return ""
+	}
+	thumb := utils.Max(1, height*height/utils.Max(1, total))
+	top := 0
+	if last := total - height; last > 0 {
+		top = int(math.Round(float64((height-thumb)*utils.Min(offset, last)) / float64(last)))
+	}
+
+	track := lipgloss.NewStyle().Foreground(theme.Muted).Render("│")
+	bar := lipgloss.NewStyle().Foreground(theme.Accent).Render("█")
+	lines := make([]string, height)
+	for i := range lines {
+		lines[i] = track
+		if i >= top && i < top+thumb {
+			lines[i] = bar
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func SearchResultsView(theme Theme, width int, results []search.Result, cursor int) string {
+	if len(results) == 0 {
+		return lipgloss.NewStyle().
+			Padding(0, 1).
+			Faint(true).
+			Render("No matches yet. Start typing to search every position.") + "\n"
+	}
+
+	var rows []string
+	for i, result := range results {
+		description := fmt.Sprintf("%s · score %d", result.FileName, result.Score)
+		if result.Snippet != "" {
+			description = fmt.Sprintf("%s:%d · score %d · %s", result.FileName, result.Line+1, result.Score, result.Snippet)
+		}
+		rows = append(rows, PositionListItemView(theme, width, result.Title, description, i == cursor))
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, rows...) + "\n"
 }
@@ -0,0 +1,43 @@
+package components
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// block is a w by h rectangle of c, standing in for the logo or QR code.
+func block(c string, w int, h int) string {
+	return strings.TrimSuffix(strings.Repeat(strings.Repeat(c, w)+"\n", h), "\n")
+}
+
+func TestBrandingViewFitsNarrowTerminals(t *testing.T) {
+	logo, qr := block("#", 40, 10), block("█", 25, 12)
+	for width := 0; width <= 100; width++ {
+		for _, qr := range []string{"", qr} {
+			for i, line := range strings.Split(BrandingView(width, logo, qr), "\n") {
+				if w := lipgloss.Width(line); w > width {
+					t.Errorf("at %d with qr=%t, line %d is %d wide", width, qr != "", i+1, w)
+				}
+			}
+		}
+	}
+}
+
+func TestBrandingViewLayouts(t *testing.T) {
+	logo, qr := block("#", 40, 10), block("█", 25, 12)
+	for _, test := range []struct {
+		width int
+		want  string
+	}{
+		{80, lipgloss.JoinHorizontal(lipgloss.Top, logo, qr) + "\n"},
+		{50, lipgloss.JoinVertical(lipgloss.Left, logo, qr) + "\n"},
+		{30, qr + "\n"},
+		{20, ""},
+	} {
+		if got := BrandingView(test.width, logo, qr); got != test.want {
+			t.Errorf("at %d got\n%s\nwant\n%s", test.width, got, test.want)
+		}
+	}
+}
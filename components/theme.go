@@ -0,0 +1,231 @@
+package components
+
+import (
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/glamour/ansi"
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// Theme is the palette every view draws with, so one session can read the
+// board in Dracula while the next one keeps the JODC gold.
+type Theme struct {
+	Name string
+	// Light is set on the variant drawn for terminals with a light background.
+	Light bool
+	// ASCII is set for terminals that garble box drawing and emoji.
+	ASCII bool
+	// Profile is the colour depth of the terminal; termenv.Ascii means none
+	// at all.
+	Profile termenv.Profile
+	// Accent marks headings, the selection and anything asking for attention.
+	Accent lipgloss.Color
+	// OnAccent is the text colour on top of Accent and the other fills.
+	OnAccent lipgloss.Color
+	Title    lipgloss.Color
+	Border   lipgloss.Color
+	Muted    lipgloss.Color
+	Banner   lipgloss.Color
+	Danger   lipgloss.Color
+	Success  lipgloss.Color
+	Warning  lipgloss.Color
+
+	markdown ansi.StyleConfig
+	form     func() *huh.Theme
+}
+
+var (
+	JODCTheme = Theme{
+		Name:     "jodc",
+		Accent:   "#fcd34d",
+		OnAccent: "#000000",
+		Title:    "205",
+		Border:   "63",
+		Muted:    "240",
+		Banner:   "#C48FDC",
+		Danger:   "#f87171",
+		Success:  "#4ade80",
+		Warning:  "#fb923c",
+		markdown: glamour.DarkStyleConfig,
+		form:     huh.ThemeCharm,
+	}
+
+	CatppuccinTheme = Theme{
+		Name:     "catppuccin",
+		Accent:   "#cba6f7",
+		OnAccent: "#1e1e2e",
+		Title:    "#f5c2e7",
+		Border:   "#585b70",
+		Muted:    "#6c7086",
+		Banner:   "#89b4fa",
+		Danger:   "#f38ba8",
+		Success:  "#a6e3a1",
+		Warning:  "#fab387",
+		markdown: glamour.DarkStyleConfig,
+		form:     huh.ThemeCatppuccin,
+	}
+
+	DraculaTheme = Theme{
+		Name:     "dracula",
+		Accent:   "#bd93f9",
+		OnAccent: "#282a36",
+		Title:    "#ff79c6",
+		Border:   "#6272a4",
+		Muted:    "#6272a4",
+		Banner:   "#8be9fd",
+		Danger:   "#ff5555",
+		Success:  "#50fa7b",
+		Warning:  "#ffb86c",
+		markdown: glamour.DraculaStyleConfig,
+		form:     huh.ThemeDracula,
+	}
+
+	HighContrastTheme = Theme{
+		Name:     "high-contrast",
+		Accent:   "#ffff00",
+		OnAccent: "#000000",
+		Title:    "#ffffff",
+		Border:   "#ffffff",
+		Muted:    "#ffffff",
+		Banner:   "#00ffff",
+		Danger:   "#ff0000",
+		Success:  "#00ff00",
+		Warning:  "#ff8800",
+		markdown: glamour.DarkStyleConfig,
+		form:     huh.ThemeBase16,
+	}
+
+	// Themes lists the built-in themes in the order the theme key cycles them.
+	Themes = []Theme{JODCTheme, CatppuccinTheme, DraculaTheme, HighContrastTheme}
+
+	// lightThemes are the same themes for terminals with a light background.
+	lightThemes = map[string]Theme{
+		"jodc": {
+			Name:     "jodc",
+			Light:    true,
+			Accent:   "#b45309",
+			OnAccent: "#ffffff",
+			Title:    "162",
+			Border:   "63",
+			Muted:    "245",
+			Banner:   "#9d5cc0",
+			Danger:   "#dc2626",
+			Success:  "#16a34a",
+			Warning:  "#c2410c",
+			markdown: glamour.LightStyleConfig,
+			form:     huh.ThemeBase,
+		},
+		"catppuccin": {
+			Name:     "catppuccin",
+			Light:    true,
+			Accent:   "#8839ef",
+			OnAccent: "#eff1f5",
+			Title:    "#ea76cb",
+			Border:   "#acb0be",
+			Muted:    "#9ca0b0",
+			Banner:   "#1e66f5",
+			Danger:   "#d20f39",
+			Success:  "#40a02b",
+			Warning:  "#fe640b",
+			markdown: glamour.LightStyleConfig,
+			form:     huh.ThemeBase,
+		},
+		"dracula": {
+			Name:     "dracula",
+			Light:    true,
+			Accent:   "#644ac9",
+			OnAccent: "#fffbeb",
+			Title:    "#a3144d",
+			Border:   "#635d97",
+			Muted:    "#635d97",
+			Banner:   "#036a96",
+			Danger:   "#cb3a2a",
+			Success:  "#14710a",
+			Warning:  "#a34d14",
+			markdown: glamour.LightStyleConfig,
+			form:     huh.ThemeBase,
+		},
+		"high-contrast": {
+			Name:     "high-contrast",
+			Light:    true,
+			Accent:   "#0000cc",
+			OnAccent: "#ffffff",
+			Title:    "#000000",
+			Border:   "#000000",
+			Muted:    "#000000",
+			Banner:   "#006666",
+			Danger:   "#cc0000",
+			Success:  "#006600",
+			Warning:  "#884400",
+			markdown: glamour.LightStyleConfig,
+			form:     huh.ThemeBase,
+		},
+	}
+)
+
+// ThemeByName looks a built-in theme up by its name. It returns the variant
+// for dark backgrounds.
+func ThemeByName(name string) (Theme, bool) {
+	for _, theme := range Themes {
+		if theme.Name == name {
+			return theme, true
+		}
+	}
+	return Theme{}, false
+}
+
+// ThemeNames lists the names of the built-in themes.
+func ThemeNames() []string {
+	names := make([]string, len(Themes))
+	for i, theme := range Themes {
+		names[i] = theme.Name
+	}
+	return names
+}
+
+// Next returns the theme after t, wrapping around to the first one, for the
+// same background as t.
+func (t Theme) Next() Theme {
+	for i, theme := range Themes {
+		if theme.Name == t.Name {
+			return Themes[(i+1)%len(Themes)].ForBackground(t.Light)
+		}
+	}
+	return Themes[0].ForBackground(t.Light)
+}
+
+// ForBackground returns the variant of t for a light or a dark background.
+func (t Theme) ForBackground(light bool) Theme {
+	theme, ok := ThemeByName(t.Name)
+	if light {
+		theme, ok = lightThemes[t.Name]
+	}
+	if !ok {
+		return t
+	}
+	theme.ASCII = t.ASCII
+	theme.Profile = t.Profile
+	return theme
+}
+
+// Markdown is the glamour style for positions, with headings and links in
+// the theme's colours.
+func (t Theme) Markdown() ansi.StyleConfig {
+	style := t.markdown
+	if t.ASCII {
+		style = glamour.ASCIIStyleConfig
+	}
+	accent, onAccent, title := string(t.Accent), string(t.OnAccent), string(t.Title)
+	style.Heading.Color = &accent
+	style.H1.Color = &onAccent
+	style.H1.BackgroundColor = &accent
+	style.Link.Color = &title
+	style.LinkText.Color = &accent
+	return style
+}
+
+// Form is the huh theme for the application form.
+func (t Theme) Form() *huh.Theme {
+	return t.form()
+}
@@ -0,0 +1,697 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/ssh"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	DefaultPath    = "jodc.yaml"
+	MaxGridColumns = 6
+)
+
+type QR struct {
+	ModuleSize int `yaml:"module_size"`
+	QuietZone  int `yaml:"quiet_zone"`
+}
+
+// HostKey is a host key offered alongside host_key_path, such as an RSA key
+// for clients too old for ed25519. Type is ed25519, rsa or ecdsa and only
+// matters when the key is generated.
+type HostKey struct {
+	Path string `yaml:"path"`
+	Type string `yaml:"type"`
+}
+
+// Link is a place to find the club, shown with a QR code on the links page.
+type Link struct {
+	Name string `yaml:"name"`
+	URL  string `yaml:"url"`
+}
+
+type RateLimit struct {
+	PerMinute int `yaml:"per_minute"`
+	Burst     int `yaml:"burst"`
+}
+
+// AccessLog is a log of sessions and page views for stats tooling. It is off
+// while Path is empty or Tracking is off; a zero max_size_mb or max_age_hours
+// never rotates for that reason.
+type AccessLog struct {
+	Path        string `yaml:"path"`
+	Format      string `yaml:"format"`
+	MaxSizeMB   int    `yaml:"max_size_mb"`
+	MaxAgeHours int    `yaml:"max_age_hours"`
+}
+
+// IPFilter decides which addresses may connect at all. Allow and Deny take
+// CIDRs or single addresses; with Allow set only those addresses get in, and
+// Deny wins over it. BanAfterDrops bans an address for BanMinutes once it
+// opens and drops that many connections within a minute without logging in,
+// 0 to never ban.
+type IPFilter struct {
+	Allow         []string `yaml:"allow"`
+	Deny          []string `yaml:"deny"`
+	BanAfterDrops int      `yaml:"ban_after_drops"`
+	BanMinutes    int      `yaml:"ban_minutes"`
+}
+
+// ParseNetwork reads a CIDR, or a single address as the network of just it.
+func ParseNetwork(s string) (*net.IPNet, error) {
+	if ip := net.ParseIP(s); ip != nil {
+		bits := 8 * net.IPv6len
+		if ip4 := ip.To4(); ip4 != nil {
+			ip, bits = ip4, 8*net.IPv4len
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+	_, network, err := net.ParseCIDR(s)
+	return network, err
+}
+
+// Tracing exports OpenTelemetry spans for sessions, content loads, renders and
+// notifications to an OTLP/HTTP collector. It is off while Endpoint is empty;
+// SampleRatio is the share of sessions traced, from 0 to 1.
+type Tracing struct {
+	Endpoint    string  `yaml:"endpoint"`
+	Insecure    bool    `yaml:"insecure"`
+	SampleRatio float64 `yaml:"sample_ratio"`
+}
+
+// Sentry reports recovered panics, render failures and storage errors to a
+// Sentry project. It is off while DSN is empty.
+type Sentry struct {
+	DSN         string `yaml:"dsn"`
+	Environment string `yaml:"environment"`
+}
+
+// DiscordBot runs a bot that posts new and updated positions to ChannelID
+// and answers /positions, registered in GuildID or, when that is empty, in
+// every server the bot is in. It is off while Token is empty.
+type DiscordBot struct {
+	Token     string `yaml:"token"`
+	ChannelID string `yaml:"channel_id"`
+	GuildID   string `yaml:"guild_id"`
+}
+
+// ContentRepo serves the positions from a git repository, GitHub's
+// owner/name or any git URL, so they can be changed through pull requests.
+// Branch is cloned into Checkout and pulled every IntervalMinutes, or only
+// on the push webhook when that is 0. Path is the positions folder in the
+// repository; with a repository set it replaces Directory. Token reads
+// private repositories.
+type ContentRepo struct {
+	Repository      string `yaml:"repository"`
+	Branch          string `yaml:"branch"`
+	Path            string `yaml:"path"`
+	Checkout        string `yaml:"checkout"`
+	IntervalMinutes int    `yaml:"interval_minutes"`
+	Token           string `yaml:"token"`
+}
+
+// ContentSource is where the positions are read from: the local Directory,
+// an S3-compatible bucket, or the files listed in index.json under an HTTPS
+// BaseURL. Remote sources are fetched into CacheDir, which then replaces
+// Directory, at startup and every IntervalMinutes, so the server needs no
+// disk of its own to keep between runs.
+type ContentSource struct {
+	Type            string `yaml:"type"`
+	BaseURL         string `yaml:"base_url"`
+	S3              S3     `yaml:"s3"`
+	CacheDir        string `yaml:"cache_dir"`
+	IntervalMinutes int    `yaml:"interval_minutes"`
+}
+
+// S3 is a bucket on AWS or any S3-compatible store. Without an access key the
+// AWS environment variables and then the instance role are used.
+type S3 struct {
+	Endpoint        string `yaml:"endpoint"`
+	Region          string `yaml:"region"`
+	Bucket          string `yaml:"bucket"`
+	Prefix          string `yaml:"prefix"`
+	AccessKeyID     string `yaml:"access_key_id"`
+	SecretAccessKey string `yaml:"secret_access_key"`
+	Insecure        bool   `yaml:"insecure"`
+}
+
+type SessionLimits struct {
+	Max   int `yaml:"max"`
+	PerIP int `yaml:"per_ip"`
+}
+
+type SMTP struct {
+	Host        string `yaml:"host"`
+	Port        int    `yaml:"port"`
+	Username    string `yaml:"username"`
+	Password    string `yaml:"password"`
+	From        string `yaml:"from"`
+	Maintainers string `yaml:"maintainers"`
+}
+
+type Config struct {
+	Host          string `yaml:"host"`
+	Port          int    `yaml:"port"`
+	UnixSocket    string `yaml:"unix_socket"`
+	PublicHost    string `yaml:"public_host"`
+	PublicPort    int    `yaml:"public_port"`
+	PublicURL     string `yaml:"public_url"`
+	HostKeyPath   string `yaml:"host_key_path"`
+	Directory     string `yaml:"directory"`
+	AboutFile     string `yaml:"about_file"`
+	EventsDir     string `yaml:"events_dir"`
+	TeamFile      string `yaml:"team_file"`
+	LogoPath      string `yaml:"logo_path"`
+	DiscordURL    string `yaml:"discord_url"`
+	ApplyURL      string `yaml:"apply_url"`
+	GridColumns   int    `yaml:"grid_columns"`
+	Theme         string `yaml:"theme"`
+	MarkdownStyle string `yaml:"markdown_style"`
+	ReduceMotion  bool   `yaml:"reduce_motion"`
+	HideExpired   bool   `yaml:"hide_expired"`
+	Tracking      bool   `yaml:"tracking"`
+	LogFormat     string `yaml:"log_format"`
+	Downloads     bool   `yaml:"downloads"`
+	PrivacyNotice string `yaml:"privacy_notice"`
+	BannerFile    string `yaml:"banner_file"`
+	PprofAddr     string `yaml:"pprof_addr"`
+	HealthAddr    string `yaml:"health_addr"`
+	HTTPAddr      string `yaml:"http_addr"`
+	WebhookSecret string `yaml:"webhook_secret"`
+	ShutdownGrace int    `yaml:"shutdown_grace"`
+	QR            QR     `yaml:"qr"`
+
+	ContentSource ContentSource `yaml:"content_source"`
+	ContentRepo   ContentRepo   `yaml:"content_repo"`
+
+	ApplicationsPath string `yaml:"applications_path"`
+	DatabasePath     string `yaml:"database_path"`
+	ViewsPath        string `yaml:"views_path"`
+	PreferencesPath  string `yaml:"preferences_path"`
+	GuestbookPath    string `yaml:"guestbook_path"`
+	FeedbackPath     string `yaml:"feedback_path"`
+
+	DiscordWebhookURL string `yaml:"discord_webhook_url"`
+	FeedbackToDiscord bool   `yaml:"feedback_to_discord"`
+	DeadLetterPath    string `yaml:"dead_letter_path"`
+	SMTP              SMTP   `yaml:"smtp"`
+
+	DiscordBot DiscordBot `yaml:"discord_bot"`
+
+	RateLimit     RateLimit     `yaml:"rate_limit"`
+	SessionLimits SessionLimits `yaml:"session_limits"`
+	AccessLog     AccessLog     `yaml:"access_log"`
+	IPFilter      IPFilter      `yaml:"ip_filter"`
+	Tracing       Tracing       `yaml:"tracing"`
+	Sentry        Sentry        `yaml:"sentry"`
+	ProxyProtocol bool          `yaml:"proxy_protocol"`
+	// TrustedProxies are the balancers, as CIDRs or addresses, allowed to
+	// send a PROXY header. None are trusted until they are listed.
+	TrustedProxies []string `yaml:"trusted_proxies"`
+
+	AdminKeys            []string `yaml:"admin_keys"`
+	RecordingsDir        string   `yaml:"recordings_dir"`
+	ControlSocket        string   `yaml:"control_socket"`
+	AnnouncementDuration int      `yaml:"announcement_duration"`
+
+	// Keys remaps keybindings, from the name of an action such as up or
+	// back to the keys that trigger it.
+	Keys map[string][]string `yaml:"keys"`
+
+	// Links are listed on the links page after the Discord invite.
+	Links []Link `yaml:"links"`
+
+	// HostKeys are offered alongside HostKeyPath.
+	HostKeys []HostKey `yaml:"host_keys"`
+}
+
+func Default() Config {
+	return Config{
+		Host:             "0.0.0.0",
+		Port:             23234,
+		PublicHost:       "localhost",
+		HostKeyPath:      ".ssh/term_info_ed25519",
+		Directory:        "directory",
+		AboutFile:        "README.md",
+		EventsDir:        "events",
+		TeamFile:         "team.yaml",
+		LogoPath:         "jodc_logo.jpeg",
+		DiscordURL:       "https://discord.gg/WW2sttvbVG",
+		Theme:            "jodc",
+		LogFormat:        "text",
+		Downloads:        true,
+		PrivacyNotice:    "Privacy notice: this server logs your SSH username, key fingerprint, address, session duration and the positions you open.",
+		ApplicationsPath: "applications.jsonl",
+		DeadLetterPath:   "dead_letters.jsonl",
+		ViewsPath:        "views.json",
+		PreferencesPath:  "preferences.json",
+		GuestbookPath:    "guestbook.jsonl",
+		FeedbackPath:     "feedback.jsonl",
+		ShutdownGrace:    5,
+
+		AnnouncementDuration: 600,
+
+		QR: QR{
+			ModuleSize: 1,
+			QuietZone:  2,
+		},
+		RateLimit: RateLimit{
+			PerMinute: 10,
+			Burst:     5,
+		},
+		SessionLimits: SessionLimits{
+			Max:   100,
+			PerIP: 5,
+		},
+		SMTP: SMTP{
+			Port: 587,
+		},
+		IPFilter: IPFilter{
+			BanMinutes: 15,
+		},
+		AccessLog: AccessLog{
+			Format:      "json",
+			MaxSizeMB:   100,
+			MaxAgeHours: 24,
+		},
+		Tracing: Tracing{
+			SampleRatio: 1,
+		},
+		ContentSource: ContentSource{
+			Type:            "local",
+			S3:              S3{Endpoint: "s3.amazonaws.com"},
+			CacheDir:        ".content-cache",
+			IntervalMinutes: 5,
+		},
+		ContentRepo: ContentRepo{
+			Branch:          "main",
+			Checkout:        ".content-repo",
+			IntervalMinutes: 5,
+		},
+	}
+}
+
+// Load reads the YAML file at path on top of the defaults, applies
+// environment overrides and validates the result. A missing file is not an
+// error, so the server still runs on defaults and environment alone.
+func Load(path string) (*Config, error) {
+	cfg := Default()
+
+	content, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil {
+		if err := yaml.Unmarshal(content, &cfg); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+	if cfg.PublicPort == 0 {
+		cfg.PublicPort = cfg.Port
+	}
+	if cfg.ApplyURL == "" {
+		cfg.ApplyURL = cfg.DiscordURL
+	}
+	if cfg.ContentRepo.Repository != "" {
+		cfg.Directory = filepath.Join(cfg.ContentRepo.Checkout, cfg.ContentRepo.Path)
+	}
+	if cfg.ContentSource.Type == "s3" || cfg.ContentSource.Type == "http" {
+		cfg.Directory = cfg.ContentSource.CacheDir
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+func (c *Config) applyEnv() error {
+	if sshFolderPath := os.Getenv("SSH_FOLDER_PATH"); sshFolderPath != "" {
+		c.HostKeyPath = filepath.Join(sshFolderPath, "term_info_ed25519")
+	}
+	envString(&c.Host, "SSH_HOST")
+	envString(&c.UnixSocket, "UNIX_SOCKET")
+	envString(&c.HostKeyPath, "HOST_KEY_PATH")
+	if hostKeys := os.Getenv("HOST_KEYS"); hostKeys != "" {
+		c.HostKeys = nil
+		for _, hostKey := range strings.Split(hostKeys, ",") {
+			keyType, path, found := strings.Cut(strings.TrimSpace(hostKey), ":")
+			if !found {
+				return fmt.Errorf("HOST_KEYS takes type:path pairs, got %q", hostKey)
+			}
+			c.HostKeys = append(c.HostKeys, HostKey{Path: path, Type: keyType})
+		}
+	}
+	envString(&c.Directory, "CONTENT_DIR")
+	envString(&c.ContentSource.Type, "CONTENT_SOURCE")
+	envString(&c.ContentSource.BaseURL, "CONTENT_BASE_URL")
+	envString(&c.ContentSource.CacheDir, "CONTENT_CACHE_DIR")
+	envString(&c.ContentSource.S3.Endpoint, "S3_ENDPOINT")
+	envString(&c.ContentSource.S3.Region, "S3_REGION")
+	envString(&c.ContentSource.S3.Bucket, "S3_BUCKET")
+	envString(&c.ContentSource.S3.Prefix, "S3_PREFIX")
+	envString(&c.ContentSource.S3.AccessKeyID, "S3_ACCESS_KEY_ID")
+	envString(&c.ContentSource.S3.SecretAccessKey, "S3_SECRET_ACCESS_KEY")
+	envString(&c.ContentRepo.Repository, "CONTENT_REPO")
+	envString(&c.ContentRepo.Branch, "CONTENT_REPO_BRANCH")
+	envString(&c.ContentRepo.Path, "CONTENT_REPO_PATH")
+	envString(&c.ContentRepo.Checkout, "CONTENT_REPO_CHECKOUT")
+	envString(&c.ContentRepo.Token, "CONTENT_REPO_TOKEN")
+	envString(&c.AboutFile, "ABOUT_FILE")
+	envString(&c.EventsDir, "EVENTS_DIR")
+	envString(&c.TeamFile, "TEAM_FILE")
+	envString(&c.LogoPath, "LOGO_PATH")
+	envString(&c.DiscordURL, "DISCORD_URL")
+	envString(&c.ApplyURL, "APPLY_URL")
+	envString(&c.Theme, "THEME")
+	envString(&c.MarkdownStyle, "MARKDOWN_STYLE")
+	envString(&c.PublicHost, "PUBLIC_HOST")
+	envString(&c.PublicURL, "PUBLIC_URL")
+	envString(&c.PrivacyNotice, "PRIVACY_NOTICE")
+	envString(&c.BannerFile, "BANNER_FILE")
+	envString(&c.LogFormat, "LOG_FORMAT")
+	envString(&c.AccessLog.Path, "ACCESS_LOG_PATH")
+	envString(&c.AccessLog.Format, "ACCESS_LOG_FORMAT")
+	envString(&c.Tracing.Endpoint, "TRACING_ENDPOINT")
+	envString(&c.Sentry.DSN, "SENTRY_DSN")
+	envString(&c.Sentry.Environment, "SENTRY_ENVIRONMENT")
+	envString(&c.PprofAddr, "PPROF_ADDR")
+	envString(&c.HealthAddr, "HEALTH_ADDR")
+	envString(&c.HTTPAddr, "HTTP_ADDR")
+	envString(&c.WebhookSecret, "WEBHOOK_SECRET")
+	envString(&c.ApplicationsPath, "APPLICATIONS_PATH")
+	envString(&c.DatabasePath, "DATABASE_PATH")
+	envString(&c.ViewsPath, "VIEWS_PATH")
+	envString(&c.PreferencesPath, "PREFERENCES_PATH")
+	envString(&c.GuestbookPath, "GUESTBOOK_PATH")
+	envString(&c.FeedbackPath, "FEEDBACK_PATH")
+	envString(&c.DiscordWebhookURL, "DISCORD_WEBHOOK_URL")
+	envString(&c.DiscordBot.Token, "DISCORD_BOT_TOKEN")
+	envString(&c.DiscordBot.ChannelID, "DISCORD_BOT_CHANNEL_ID")
+	envString(&c.DiscordBot.GuildID, "DISCORD_BOT_GUILD_ID")
+	envString(&c.DeadLetterPath, "DEAD_LETTER_PATH")
+	envString(&c.SMTP.Host, "SMTP_HOST")
+	envString(&c.SMTP.Username, "SMTP_USERNAME")
+	envString(&c.SMTP.Password, "SMTP_PASSWORD")
+	envString(&c.SMTP.From, "SMTP_FROM")
+	envString(&c.SMTP.Maintainers, "SMTP_MAINTAINERS")
+	envList(&c.AdminKeys, "ADMIN_KEYS")
+	envString(&c.RecordingsDir, "RECORDINGS_DIR")
+	envList(&c.IPFilter.Allow, "IP_ALLOW")
+	envList(&c.IPFilter.Deny, "IP_DENY")
+	envList(&c.TrustedProxies, "TRUSTED_PROXIES")
+	envString(&c.ControlSocket, "CONTROL_SOCKET")
+
+	return joinErrors(
+		envInt(&c.Port, "SSH_PORT"),
+		envInt(&c.PublicPort, "PUBLIC_PORT"),
+		envInt(&c.GridColumns, "GRID_COLUMNS"),
+		envInt(&c.QR.ModuleSize, "QR_MODULE_SIZE"),
+		envInt(&c.QR.QuietZone, "QR_QUIET_ZONE"),
+		envInt(&c.ShutdownGrace, "SHUTDOWN_GRACE"),
+		envInt(&c.AnnouncementDuration, "ANNOUNCEMENT_DURATION"),
+		envInt(&c.SMTP.Port, "SMTP_PORT"),
+		envInt(&c.RateLimit.PerMinute, "RATE_LIMIT_PER_MINUTE"),
+		envInt(&c.RateLimit.Burst, "RATE_LIMIT_BURST"),
+		envInt(&c.SessionLimits.Max, "MAX_SESSIONS"),
+		envInt(&c.SessionLimits.PerIP, "MAX_SESSIONS_PER_IP"),
+		envInt(&c.IPFilter.BanAfterDrops, "IP_BAN_AFTER_DROPS"),
+		envInt(&c.IPFilter.BanMinutes, "IP_BAN_MINUTES"),
+		envInt(&c.AccessLog.MaxSizeMB, "ACCESS_LOG_MAX_SIZE_MB"),
+		envInt(&c.AccessLog.MaxAgeHours, "ACCESS_LOG_MAX_AGE_HOURS"),
+		envInt(&c.ContentRepo.IntervalMinutes, "CONTENT_REPO_INTERVAL_MINUTES"),
+		envInt(&c.ContentSource.IntervalMinutes, "CONTENT_SOURCE_INTERVAL_MINUTES"),
+		envBool(&c.Tracking, "TRACKING_ENABLED"),
+		envBool(&c.Downloads, "DOWNLOADS_ENABLED"),
+		envBool(&c.ReduceMotion, "REDUCE_MOTION"),
+		envBool(&c.HideExpired, "HIDE_EXPIRED"),
+		envBool(&c.FeedbackToDiscord, "FEEDBACK_TO_DISCORD"),
+		envBool(&c.ProxyProtocol, "PROXY_PROTOCOL"),
+		envBool(&c.Tracing.Insecure, "TRACING_INSECURE"),
+		envBool(&c.ContentSource.S3.Insecure, "S3_INSECURE"),
+		envFloat(&c.Tracing.SampleRatio, "TRACING_SAMPLE_RATIO"),
+	)
+}
+
+func (c *Config) Validate() error {
+	var errs []error
+	if c.Host == "" {
+		errs = append(errs, errors.New("host must be set"))
+	}
+	if c.Port < 1 || c.Port > 65535 {
+		errs = append(errs, fmt.Errorf("port must be between 1 and 65535, got %d", c.Port))
+	}
+	if c.PublicPort < 1 || c.PublicPort > 65535 {
+		errs = append(errs, fmt.Errorf("public_port must be between 1 and 65535, got %d", c.PublicPort))
+	}
+	if c.HostKeyPath == "" {
+		errs = append(errs, errors.New("host_key_path must be set"))
+	}
+	for i, hostKey := range c.HostKeys {
+		if hostKey.Path == "" {
+			errs = append(errs, fmt.Errorf("host_keys[%d] needs a path", i))
+		}
+		switch hostKey.Type {
+		case "ed25519", "rsa", "ecdsa":
+		default:
+			errs = append(errs, fmt.Errorf("host_keys[%d].type must be ed25519, rsa or ecdsa, got %q", i, hostKey.Type))
+		}
+	}
+	if c.Directory == "" {
+		errs = append(errs, errors.New("directory must be set"))
+	}
+	if c.ApplicationsPath == "" {
+		errs = append(errs, errors.New("applications_path must be set"))
+	}
+	if c.ViewsPath == "" {
+		errs = append(errs, errors.New("views_path must be set"))
+	}
+	if c.PreferencesPath == "" {
+		errs = append(errs, errors.New("preferences_path must be set"))
+	}
+	if c.GuestbookPath == "" {
+		errs = append(errs, errors.New("guestbook_path must be set"))
+	}
+	if c.FeedbackPath == "" {
+		errs = append(errs, errors.New("feedback_path must be set"))
+	}
+	if c.FeedbackToDiscord && c.DiscordWebhookURL == "" {
+		errs = append(errs, errors.New("feedback_to_discord needs discord_webhook_url"))
+	}
+	if c.DeadLetterPath == "" {
+		errs = append(errs, errors.New("dead_letter_path must be set"))
+	}
+	if c.DiscordURL == "" {
+		errs = append(errs, errors.New("discord_url must be set"))
+	}
+	if c.GridColumns < 0 || c.GridColumns > MaxGridColumns {
+		errs = append(errs, fmt.Errorf("grid_columns must be between 0 and %d, got %d", MaxGridColumns, c.GridColumns))
+	}
+	if c.QR.ModuleSize < 1 {
+		errs = append(errs, fmt.Errorf("qr.module_size must be at least 1, got %d", c.QR.ModuleSize))
+	}
+	if c.QR.QuietZone < 0 {
+		errs = append(errs, fmt.Errorf("qr.quiet_zone must not be negative, got %d", c.QR.QuietZone))
+	}
+	if c.ShutdownGrace < 0 {
+		errs = append(errs, fmt.Errorf("shutdown_grace must not be negative, got %d", c.ShutdownGrace))
+	}
+	if c.RateLimit.PerMinute < 0 {
+		errs = append(errs, fmt.Errorf("rate_limit.per_minute must not be negative, got %d", c.RateLimit.PerMinute))
+	}
+	if c.RateLimit.PerMinute > 0 && c.RateLimit.Burst < 1 {
+		errs = append(errs, fmt.Errorf("rate_limit.burst must be at least 1, got %d", c.RateLimit.Burst))
+	}
+	if c.SessionLimits.Max < 0 || c.SessionLimits.PerIP < 0 {
+		errs = append(errs, errors.New("session_limits must not be negative"))
+	}
+	switch c.LogFormat {
+	case "text", "json", "logfmt":
+	default:
+		errs = append(errs, fmt.Errorf("log_format must be text, json or logfmt, got %q", c.LogFormat))
+	}
+	for i, network := range c.IPFilter.Allow {
+		if _, err := ParseNetwork(network); err != nil {
+			errs = append(errs, fmt.Errorf("ip_filter.allow[%d]: %w", i, err))
+		}
+	}
+	for i, network := range c.IPFilter.Deny {
+		if _, err := ParseNetwork(network); err != nil {
+			errs = append(errs, fmt.Errorf("ip_filter.deny[%d]: %w", i, err))
+		}
+	}
+	for i, network := range c.TrustedProxies {
+		if _, err := ParseNetwork(network); err != nil {
+			errs = append(errs, fmt.Errorf("trusted_proxies[%d]: %w", i, err))
+		}
+	}
+	if c.ProxyProtocol && len(c.TrustedProxies) == 0 {
+		errs = append(errs, errors.New("trusted_proxies must list the balancers when proxy_protocol is on"))
+	}
+	if c.IPFilter.BanAfterDrops < 0 {
+		errs = append(errs, fmt.Errorf("ip_filter.ban_after_drops must not be negative, got %d", c.IPFilter.BanAfterDrops))
+	}
+	if c.IPFilter.BanAfterDrops > 0 && c.IPFilter.BanMinutes < 1 {
+		errs = append(errs, fmt.Errorf("ip_filter.ban_minutes must be at least 1, got %d", c.IPFilter.BanMinutes))
+	}
+	if c.AccessLog.Path != "" {
+		if c.AccessLog.Format != "json" && c.AccessLog.Format != "combined" {
+			errs = append(errs, fmt.Errorf("access_log.format must be json or combined, got %q", c.AccessLog.Format))
+		}
+		if c.AccessLog.MaxSizeMB < 0 || c.AccessLog.MaxAgeHours < 0 {
+			errs = append(errs, errors.New("access_log rotation must not be negative"))
+		}
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		errs = append(errs, fmt.Errorf("tracing.sample_ratio must be between 0 and 1, got %v", c.Tracing.SampleRatio))
+	}
+	if c.AnnouncementDuration < 1 {
+		errs = append(errs, fmt.Errorf("announcement_duration must be at least 1, got %d", c.AnnouncementDuration))
+	}
+	for i, authorizedKey := range c.AdminKeys {
+		if _, _, _, _, err := ssh.ParseAuthorizedKey([]byte(authorizedKey)); err != nil {
+			errs = append(errs, fmt.Errorf("admin_keys[%d]: %w", i, err))
+		}
+	}
+	for i, link := range c.Links {
+		if link.Name == "" || link.URL == "" {
+			errs = append(errs, fmt.Errorf("links[%d] needs a name and a url", i))
+		}
+	}
+	switch c.ContentSource.Type {
+	case "local":
+	case "s3", "http":
+		if c.ContentRepo.Repository != "" {
+			errs = append(errs, fmt.Errorf("content_repo and a content_source of type %s can't both be set", c.ContentSource.Type))
+		}
+		if c.ContentSource.CacheDir == "" {
+			errs = append(errs, errors.New("content_source.cache_dir must be set for a remote source"))
+		}
+		if c.ContentSource.IntervalMinutes < 0 {
+			errs = append(errs, fmt.Errorf("content_source.interval_minutes must not be negative, got %d", c.ContentSource.IntervalMinutes))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("content_source.type must be local, s3 or http, got %q", c.ContentSource.Type))
+	}
+	if c.ContentSource.Type == "s3" && (c.ContentSource.S3.Bucket == "" || c.ContentSource.S3.Endpoint == "") {
+		errs = append(errs, errors.New("content_source.s3 needs an endpoint and a bucket"))
+	}
+	if c.ContentSource.Type == "http" {
+		if u, err := url.Parse(c.ContentSource.BaseURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("content_source.base_url must be an http or https URL, got %q", c.ContentSource.BaseURL))
+		}
+	}
+	if c.ContentRepo.Repository != "" {
+		if c.ContentRepo.Branch == "" {
+			errs = append(errs, errors.New("content_repo.branch must be set when content_repo.repository is"))
+		}
+		if c.ContentRepo.Checkout == "" {
+			errs = append(errs, errors.New("content_repo.checkout must be set when content_repo.repository is"))
+		}
+		if c.ContentRepo.IntervalMinutes < 0 {
+			errs = append(errs, fmt.Errorf("content_repo.interval_minutes must not be negative, got %d", c.ContentRepo.IntervalMinutes))
+		}
+		if c.ContentRepo.IntervalMinutes == 0 && c.WebhookSecret == "" {
+			errs = append(errs, errors.New("content_repo needs interval_minutes or a webhook_secret, or it would never update"))
+		}
+	}
+	if c.SMTP.Host != "" {
+		if c.SMTP.Port < 1 || c.SMTP.Port > 65535 {
+			errs = append(errs, fmt.Errorf("smtp.port must be between 1 and 65535, got %d", c.SMTP.Port))
+		}
+		if c.SMTP.From == "" {
+			errs = append(errs, errors.New("smtp.from must be set when smtp.host is"))
+		}
+		if c.SMTP.Maintainers != "" {
+			if _, err := mail.ParseAddressList(c.SMTP.Maintainers); err != nil {
+				errs = append(errs, fmt.Errorf("smtp.maintainers: %w", err))
+			}
+		}
+	}
+	return joinErrors(errs...)
+}
+
+func joinErrors(errs ...error) error {
+	var messages []string
+	for _, err := range errs {
+		if err != nil {
+			messages = append(messages, err.Error())
+		}
+	}
+	if len(messages) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(messages, "; "))
+}
+
+func envString(field *string, name string) {
+	if value := os.Getenv(name); value != "" {
+		*field = value
+	}
+}
+
+func envList(field *[]string, name string) {
+	value := os.Getenv(name)
+	if value == "" {
+		return
+	}
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	*field = list
+}
+
+func envInt(field *int, name string) error {
+	value := os.Getenv(name)
+	if value == "" {
+		return nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("%s must be a number: %w", name, err)
+	}
+	*field = n
+	return nil
+}
+
+func envFloat(field *float64, name string) error {
+	value := os.Getenv(name)
+	if value == "" {
+		return nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return fmt.Errorf("%s must be a number: %w", name, err)
+	}
+	*field = f
+	return nil
+}
+
+func envBool(field *bool, name string) error {
+	value := os.Getenv(name)
+	if value == "" {
+		return nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return fmt.Errorf("%s must be true or false: %w", name, err)
+	}
+	*field = b
+	return nil
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"time"
+
+	"organize/config"
+	"organize/contentsource"
+	"organize/gitsync"
+
+	"github.com/charmbracelet/log"
+	"github.com/getsentry/sentry-go"
+)
+
+// contentSyncTimeout bounds one sync, so a stalled bucket or server can't
+// hold up the next one.
+const contentSyncTimeout = 5 * time.Minute
+
+// contentSync keeps the content directory in step with where the positions
+// are kept: a git repository changed through pull requests, a bucket, a web
+// server, or the directory itself.
+type contentSync struct {
+	source   contentsource.Source
+	interval time.Duration
+	// branch is the git branch pushes to which refresh the board, or empty
+	// for a push to anything.
+	branch string
+}
+
+// newContentSource picks the source the config asks for.
+func newContentSource(cfg *config.Config) (*contentSync, error) {
+	if repo := cfg.ContentRepo; repo.Repository != "" {
+		return &contentSync{
+			source: gitsync.New(gitsync.Options{
+				Repository: repo.Repository,
+				Branch:     repo.Branch,
+				Dir:        repo.Checkout,
+				Token:      repo.Token,
+			}),
+			interval: time.Duration(repo.IntervalMinutes) * time.Minute,
+			branch:   repo.Branch,
+		}, nil
+	}
+	interval := time.Duration(cfg.ContentSource.IntervalMinutes) * time.Minute
+	switch cfg.ContentSource.Type {
+	case "s3":
+		s3 := cfg.ContentSource.S3
+		source, err := contentsource.NewS3(contentsource.S3Options{
+			Endpoint:        s3.Endpoint,
+			Region:          s3.Region,
+			Bucket:          s3.Bucket,
+			Prefix:          s3.Prefix,
+			AccessKeyID:     s3.AccessKeyID,
+			SecretAccessKey: s3.SecretAccessKey,
+			Insecure:        s3.Insecure,
+		}, cfg.Directory)
+		if err != nil {
+			return nil, err
+		}
+		return &contentSync{source: source, interval: interval}, nil
+	case "http":
+		return &contentSync{source: contentsource.NewHTTP(cfg.ContentSource.BaseURL, cfg.Directory), interval: interval}, nil
+	}
+	return &contentSync{source: contentsource.Local{Dir: cfg.Directory}}, nil
+}
+
+// open brings the content directory up to date before anything reads the
+// positions. A source that can't be reached only stops the server when there
+// is no earlier copy to serve in the meantime.
+func (c *contentSync) open(dir string) {
+	ctx, cancel := context.WithTimeout(context.Background(), contentSyncTimeout)
+	defer cancel()
+	if _, err := c.source.Sync(ctx); err != nil {
+		if _, statErr := os.Stat(dir); statErr != nil {
+			log.Fatal("could not fetch the content", "source", c.source.String(), "error", err)
+		}
+		reportError(sentry.CurrentHub(), "could not update the content, serving the last copy", err, "source", c.source.String())
+		return
+	}
+	if _, local := c.source.(contentsource.Local); local {
+		return
+	}
+	if repo, ok := c.source.(*gitsync.Repo); ok {
+		head, _ := repo.Head()
+		log.Info("Serving content from repository", "source", c.source.String(), "commit", head)
+		return
+	}
+	log.Info("Serving content from remote source", "source", c.source.String(), "cache", dir)
+}
+
+// pull updates the content directory and says whether anything changed.
+func (c *contentSync) pull() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), contentSyncTimeout)
+	defer cancel()
+	changed, err := c.source.Sync(ctx)
+	if err != nil {
+		reportError(sentry.CurrentHub(), "could not update the content", err, "source", c.source.String())
+		return false
+	}
+	if changed {
+		if repo, ok := c.source.(*gitsync.Repo); ok {
+			head, _ := repo.Head()
+			log.Info("Content repository updated", "commit", head)
+		} else {
+			log.Info("Content updated", "source", c.source.String())
+		}
+	}
+	return changed
+}
+
+// poll pulls every interval until stop is closed, reloading the board when
+// something changed. Without an interval updates are left to the webhook.
+func (c *contentSync) poll(reload func(), stop <-chan struct{}) {
+	if c.interval == 0 {
+		return
+	}
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if c.pull() {
+				reload()
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// pushWebhook refreshes the board whenever the content is pushed to: it
+// syncs the content source first, then re-reads and re-indexes the positions
+// and drops every cached render, whether or not the sync brought anything,
+// so a push is always visible straight away.
+func pushWebhook(secret string, content *contentSync, reload func()) http.Handler {
+	return gitsync.PushHandler(secret, content.branch, func() {
+		log.Info("Push webhook received, refreshing content")
+		content.pull()
+		reload()
+	})
+}
@@ -0,0 +1,169 @@
+// Package contentsource fetches the positions from wherever they are kept,
+// an S3-compatible bucket or a plain HTTPS server, into a local directory the
+// board reads like any other. The directory is only a cache: a container can
+// start from nothing and fill it on boot.
+package contentsource
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Source is somewhere the positions are kept.
+type Source interface {
+	// Sync brings the local copy up to date and says whether any file in it
+	// changed.
+	Sync(ctx context.Context) (changed bool, err error)
+	// String says where the positions come from, for the logs.
+	String() string
+}
+
+// Local is a directory on disk, read in place. There is nothing to fetch;
+// the content watcher notices changes to it.
+type Local struct {
+	Dir string
+}
+
+func (l Local) Sync(context.Context) (bool, error) {
+	return false, nil
+}
+
+func (l Local) String() string {
+	return l.Dir
+}
+
+// remoteFile is a file as a remote source lists it. version changes whenever
+// the file does, such as its ETag.
+type remoteFile struct {
+	name    string
+	version string
+}
+
+// mirror keeps dir holding exactly the files a remote source lists, fetching
+// only those whose version moved since the last sync.
+type mirror struct {
+	dir string
+	// versions are what each file was at when last fetched. They start out
+	// empty, so the first sync after a restart fetches everything again.
+	versions map[string]string
+}
+
+func newMirror(dir string) *mirror {
+	return &mirror{dir: dir, versions: make(map[string]string)}
+}
+
+// fetchFunc opens a listed file, given the version it was last fetched at,
+// and returns it with its version now. A nil body means it hasn't changed
+// after all.
+type fetchFunc func(ctx context.Context, file remoteFile, last string) (body io.ReadCloser, version string, err error)
+
+// update fetches the listed files that changed and removes the ones that are
+// no longer listed.
+func (m *mirror) update(ctx context.Context, files []remoteFile, fetch fetchFunc) (bool, error) {
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return false, err
+	}
+	changed := false
+	listed := make(map[string]bool, len(files))
+	for _, file := range files {
+		name, ok := localName(file.name)
+		if !ok {
+			continue
+		}
+		listed[name] = true
+		last, fetched := m.versions[name]
+		if fetched && file.version != "" && file.version == last {
+			continue
+		}
+		body, version, err := fetch(ctx, file, last)
+		if err != nil {
+			return changed, fmt.Errorf("%s: %w", file.name, err)
+		}
+		if body == nil {
+			continue
+		}
+		err = writeFile(filepath.Join(m.dir, filepath.FromSlash(name)), body)
+		body.Close()
+		if err != nil {
+			return changed, err
+		}
+		m.versions[name] = version
+		changed = true
+	}
+
+	removed, err := m.prune(listed)
+	return changed || removed, err
+}
+
+// prune deletes every file in the mirror the source no longer lists, and the
+// folders that leaves empty.
+func (m *mirror) prune(listed map[string]bool) (bool, error) {
+	removed := false
+	var dirs []string
+	err := filepath.WalkDir(m.dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(m.dir, p)
+		if err != nil || rel == "." {
+			return err
+		}
+		if d.IsDir() {
+			dirs = append(dirs, p)
+			return nil
+		}
+		if name := filepath.ToSlash(rel); !listed[name] {
+			delete(m.versions, name)
+			removed = true
+			return os.Remove(p)
+		}
+		return nil
+	})
+	// Deepest first, so a folder's subfolders are gone before it is tried.
+	sort.Sort(sort.Reverse(sort.StringSlice(dirs)))
+	for _, dir := range dirs {
+		os.Remove(dir) // only succeeds once it is empty
+	}
+	return removed, err
+}
+
+// localName is where a listed file goes inside the mirror, if anywhere:
+// folders, and names that are absolute or would climb out of it, are skipped.
+func localName(name string) (string, bool) {
+	clean := path.Clean(name)
+	if clean != name || clean == "." || clean == ".." || path.IsAbs(clean) || strings.HasPrefix(clean, "../") {
+		return "", false
+	}
+	return clean, true
+}
+
+// writeFile replaces the file at p with body all at once, so a session never
+// reads one half written.
+func writeFile(p string, body io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".fetch-*")
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(tmp, body)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), p)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package contentsource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// indexFile lists the files an HTTP source serves, as a JSON array of paths
+// relative to the base URL, since plain web servers can't be asked for a
+// directory listing.
+const indexFile = "index.json"
+
+const httpTimeout = 30 * time.Second
+
+// HTTP copies the files listed in the index.json at a base URL into a
+// directory, asking for each one again only if it changed, so a static site
+// or a CDN can serve the positions.
+type HTTP struct {
+	baseURL string
+	client  *http.Client
+	mu      sync.Mutex
+	mirror  *mirror
+}
+
+func NewHTTP(baseURL string, dir string) *HTTP {
+	return &HTTP{
+		baseURL: strings.TrimSuffix(baseURL, "/") + "/",
+		client:  &http.Client{Timeout: httpTimeout},
+		mirror:  newMirror(dir),
+	}
+}
+
+func (h *HTTP) Sync(ctx context.Context) (bool, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	index, _, err := h.get(ctx, indexFile, "")
+	if err != nil {
+		return false, err
+	}
+	var names []string
+	err = json.NewDecoder(index).Decode(&names)
+	index.Close()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", indexFile, err)
+	}
+	files := make([]remoteFile, len(names))
+	for i, name := range names {
+		files[i] = remoteFile{name: name}
+	}
+	return h.mirror.update(ctx, files, func(ctx context.Context, file remoteFile, last string) (io.ReadCloser, string, error) {
+		return h.get(ctx, file.name, last)
+	})
+}
+
+func (h *HTTP) String() string {
+	return h.baseURL
+}
+
+// get fetches name from under the base URL, unless it is still at version, an
+// ETag or Last-Modified date from an earlier fetch, when it returns no body.
+func (h *HTTP) get(ctx context.Context, name string, version string) (io.ReadCloser, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.baseURL+strings.TrimPrefix(name, "/"), nil)
+	if err != nil {
+		return nil, "", err
+	}
+	if strings.HasPrefix(version, "etag:") {
+		req.Header.Set("If-None-Match", strings.TrimPrefix(version, "etag:"))
+	} else if strings.HasPrefix(version, "modified:") {
+		req.Header.Set("If-Modified-Since", strings.TrimPrefix(version, "modified:"))
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		resp.Body.Close()
+		return nil, version, nil
+	default:
+		resp.Body.Close()
+		return nil, "", fmt.Errorf("GET %s: %s", req.URL, resp.Status)
+	}
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		return resp.Body, "etag:" + etag, nil
+	}
+	if modified := resp.Header.Get("Last-Modified"); modified != "" {
+		return resp.Body, "modified:" + modified, nil
+	}
+	return resp.Body, "", nil
+}
@@ -0,0 +1,86 @@
+package contentsource
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Options say which bucket, on AWS or any S3-compatible store such as
+// MinIO or R2, holds the positions.
+type S3Options struct {
+	Endpoint string
+	Region   string
+	Bucket   string
+	// Prefix is the folder in the bucket the positions are under.
+	Prefix string
+	// Without an access key the usual AWS environment variables are tried,
+	// then the instance or task role.
+	AccessKeyID     string
+	SecretAccessKey string
+	// Insecure talks plain HTTP, for a store on the same private network.
+	Insecure bool
+}
+
+// S3 copies the objects under a prefix of a bucket into a directory.
+type S3 struct {
+	opts   S3Options
+	client *minio.Client
+	mu     sync.Mutex
+	mirror *mirror
+}
+
+func NewS3(opts S3Options, dir string) (*S3, error) {
+	creds := credentials.NewChainCredentials([]credentials.Provider{
+		&credentials.EnvAWS{},
+		&credentials.IAM{},
+	})
+	if opts.AccessKeyID != "" {
+		creds = credentials.NewStaticV4(opts.AccessKeyID, opts.SecretAccessKey, "")
+	}
+	client, err := minio.New(opts.Endpoint, &minio.Options{
+		Creds:  creds,
+		Secure: !opts.Insecure,
+		Region: opts.Region,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &S3{opts: opts, client: client, mirror: newMirror(dir)}, nil
+}
+
+func (s *S3) Sync(ctx context.Context) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prefix := s.prefix()
+	var files []remoteFile
+	for object := range s.client.ListObjects(ctx, s.opts.Bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if object.Err != nil {
+			return false, object.Err
+		}
+		files = append(files, remoteFile{name: strings.TrimPrefix(object.Key, prefix), version: object.ETag})
+	}
+	return s.mirror.update(ctx, files, func(ctx context.Context, file remoteFile, _ string) (io.ReadCloser, string, error) {
+		object, err := s.client.GetObject(ctx, s.opts.Bucket, prefix+file.name, minio.GetObjectOptions{})
+		return object, file.version, err
+	})
+}
+
+func (s *S3) String() string {
+	return "s3://" + s.opts.Bucket + "/" + s.prefix()
+}
+
+// prefix is Prefix as a folder, ending in a slash unless it is the whole
+// bucket.
+func (s *S3) prefix() string {
+	prefix := strings.Trim(s.opts.Prefix, "/")
+	if prefix == "" {
+		return ""
+	}
+	return prefix + "/"
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"organize/config"
+	"organize/search"
+	"organize/utils"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/charmbracelet/log"
+)
+
+const (
+	// discordFieldLimit is the most fields Discord shows in one embed.
+	discordFieldLimit = 25
+	// discordSearchLimit is how many matches /positions with a search lists.
+	discordSearchLimit = 10
+
+	discordNewColor     = 0x34d399
+	discordUpdatedColor = 0x60a5fa
+)
+
+// positionsCommand is the /positions slash command.
+var positionsCommand = &discordgo.ApplicationCommand{
+	Name:        "positions",
+	Description: "List the open positions",
+	Options: []*discordgo.ApplicationCommandOption{{
+		Type:        discordgo.ApplicationCommandOptionString,
+		Name:        "search",
+		Description: "Only positions mentioning this",
+	}},
+}
+
+// discordBot keeps a Discord server in step with the board: it posts
+// positions to a channel as they are added or updated and answers
+// /positions, both from the content the SSH sessions are shown.
+type discordBot struct {
+	cfg     config.DiscordBot
+	live    *liveConfig
+	content *contentCache
+	session *discordgo.Session
+
+	mu sync.Mutex
+	// posted is when each position was last updated as of the last post, so a
+	// reload only announces what changed.
+	posted map[string]time.Time
+}
+
+func newDiscordBot(cfg config.DiscordBot, live *liveConfig, content *contentCache) (*discordBot, error) {
+	session, err := discordgo.New("Bot " + cfg.Token)
+	if err != nil {
+		return nil, err
+	}
+	// Slash commands need no privileged intents.
+	session.Identify.Intents = discordgo.IntentsGuilds
+	b := &discordBot{cfg: cfg, live: live, content: content, session: session, posted: make(map[string]time.Time)}
+	session.AddHandler(b.ready)
+	session.AddHandler(b.interaction)
+	return b, nil
+}
+
+// open connects to Discord. The positions already on the board are taken as
+// posted, so a restart doesn't announce them all again.
+func (b *discordBot) open() error {
+	cfg := b.live.load()
+	positionMeta, _, err := b.content.positions(cfg.Directory)
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	for _, position := range publishedPositions(cfg, positionMeta) {
+		b.posted[position.File] = position.Updated
+	}
+	b.mu.Unlock()
+	return b.session.Open()
+}
+
+func (b *discordBot) close() error {
+	return b.session.Close()
+}
+
+func (b *discordBot) ready(s *discordgo.Session, r *discordgo.Ready) {
+	if _, err := s.ApplicationCommandCreate(r.User.ID, b.cfg.GuildID, positionsCommand); err != nil {
+		log.Error("could not register the discord slash command", "error", err)
+		return
+	}
+	log.Info("discord bot connected", "user", r.User.Username, "guilds", len(r.Guilds))
+}
+
+// contentChanged posts the open positions that are new or were updated since
+// the last post. A nil bot does nothing, for when there is no bot.
+func (b *discordBot) contentChanged(positionMeta *utils.PositionMeta) {
+	if b == nil {
+		return
+	}
+	cfg := b.live.load()
+	b.mu.Lock()
+	var embeds []*discordgo.MessageEmbed
+	for _, position := range publishedPositions(cfg, positionMeta) {
+		last, seen := b.posted[position.File]
+		b.posted[position.File] = position.Updated
+		if position.Status != "open" || (seen && !position.Updated.After(last)) {
+			continue
+		}
+		embed := positionEmbed(cfg, position)
+		if seen {
+			embed.Title, embed.Color = "Updated: "+embed.Title, discordUpdatedColor
+		} else {
+			embed.Title, embed.Color = "New position: "+embed.Title, discordNewColor
+		}
+		embeds = append(embeds, embed)
+	}
+	b.mu.Unlock()
+
+	if b.cfg.ChannelID == "" {
+		return
+	}
+	for _, embed := range embeds {
+		if _, err := b.session.ChannelMessageSendEmbed(b.cfg.ChannelID, embed); err != nil {
+			log.Error("could not post position to discord", "position", embed.Title, "error", err)
+		}
+	}
+}
+
+// interaction answers /positions with the open positions, or those matching
+// its search, only to whoever asked.
+func (b *discordBot) interaction(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionApplicationCommand || i.ApplicationCommandData().Name != positionsCommand.Name {
+		return
+	}
+	query := ""
+	for _, option := range i.ApplicationCommandData().Options {
+		if option.Name == "search" {
+			query = strings.TrimSpace(option.StringValue())
+		}
+	}
+
+	cfg := b.live.load()
+	response := &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral}
+	positionMeta, searchIndex, err := b.content.positions(cfg.Directory)
+	if err != nil {
+		log.Error("could not list positions for discord", "error", err)
+		response.Content = "Could not read the positions, try again later."
+	} else {
+		response.Embeds = []*discordgo.MessageEmbed{positionsEmbed(cfg, publishedPositions(cfg, positionMeta), searchIndex, query)}
+	}
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: response,
+	})
+	if err != nil {
+		log.Error("could not answer discord command", "error", err)
+	}
+}
+
+// positionsEmbed lists the open positions, in the board's order or, with a
+// query, best match first.
+func positionsEmbed(cfg *config.Config, positions []positionJSON, searchIndex *search.Index, query string) *discordgo.MessageEmbed {
+	open := make(map[string]positionJSON, len(positions))
+	var listed []positionJSON
+	for _, position := range positions {
+		if position.Status == "open" {
+			open[position.File] = position
+			listed = append(listed, position)
+		}
+	}
+	title := "Open positions"
+	if query != "" {
+		title = "Open positions matching “" + query + "”"
+		listed = nil
+		for _, result := range searchIndex.Search(query, discordSearchLimit) {
+			if position, ok := open[result.FileName]; ok {
+				listed = append(listed, position)
+				delete(open, result.FileName)
+			}
+		}
+	}
+
+	embed := &discordgo.MessageEmbed{Title: title, Color: discordNewColor, Footer: &discordgo.MessageEmbedFooter{Text: reconnectCommand(cfg)}}
+	if len(listed) == 0 {
+		embed.Description = "Nothing right now."
+		return embed
+	}
+	for _, position := range listed {
+		if len(embed.Fields) == discordFieldLimit {
+			break
+		}
+		value := position.Description
+		if link := positionLink(cfg, position); link != "" {
+			value += "\n" + link
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  embedText(position.Title, 256),
+			Value: embedText(value, 1024),
+		})
+	}
+	return embed
+}
+
+// positionEmbed announces one position with what a visitor would want to
+// know before opening it.
+func positionEmbed(cfg *config.Config, position positionJSON) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:       utils.Truncate(position.Title, 200),
+		Description: utils.Truncate(position.Description, 4096),
+		Timestamp:   position.Updated.Format(time.RFC3339),
+	}
+	// Without a web mirror the link is an ssh command, which can't be a URL.
+	if cfg.PublicURL != "" {
+		embed.URL = shareLink(cfg, position.File)
+	} else {
+		embed.Footer = &discordgo.MessageEmbedFooter{Text: shareLink(cfg, position.File)}
+	}
+	if len(position.Tags) > 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Tags", Value: embedText(strings.Join(position.Tags, ", "), 1024), Inline: true})
+	}
+	if position.Deadline != nil {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Deadline", Value: position.Deadline.Format("2 January 2006"), Inline: true})
+	}
+	if position.ApplyURL != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Apply", Value: position.ApplyURL, Inline: true})
+	}
+	return embed
+}
+
+// embedText fits s in an embed field of at most limit characters. Discord
+// refuses the whole message over a field that is left empty, so blank text
+// becomes a dash.
+func embedText(s string, limit int) string {
+	if s = strings.TrimSpace(s); s == "" {
+		return "—"
+	}
+	return utils.Truncate(s, limit)
+}
+
+// positionLink is where to read a position from Discord: the web mirror, or
+// the apply link when there is no mirror.
+func positionLink(cfg *config.Config, position positionJSON) string {
+	if cfg.PublicURL != "" {
+		return shareLink(cfg, position.File)
+	}
+	return position.ApplyURL
+}
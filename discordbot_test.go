@@ -0,0 +1,35 @@
+package main
+
+import (
+	"testing"
+
+	"organize/config"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// checkFields fails for every field Discord would refuse the embed over.
+func checkFields(t *testing.T, embed *discordgo.MessageEmbed) {
+	t.Helper()
+	for i, field := range embed.Fields {
+		if field.Name == "" || field.Value == "" {
+			t.Errorf("field %d is empty: name %q, value %q", i+1, field.Name, field.Value)
+		}
+	}
+}
+
+func TestEmbedsHaveNoEmptyFields(t *testing.T) {
+	cfg := config.Default()
+	cfg.PublicURL = ""
+	bare := positionJSON{File: "Mentor.md", Title: "Mentor", Status: "open", Tags: []string{""}}
+
+	embed := positionsEmbed(&cfg, []positionJSON{bare}, nil, "")
+	if len(embed.Fields) != 1 {
+		t.Fatalf("got %d fields, want 1", len(embed.Fields))
+	}
+	checkFields(t, embed)
+	if got := embed.Fields[0].Value; got != "—" {
+		t.Errorf("a position with nothing to say shows %q, want a dash", got)
+	}
+	checkFields(t, positionEmbed(&cfg, bare))
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+
+	"organize/utils"
+
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/ssh"
+	"github.com/pkg/sftp"
+)
+
+// contentFS is the content directory as visitors see it over scp and SFTP.
+// Paths may start with positions/, so "scp host:positions/Apply.md ." reads
+// the way the board talks about them, and hidden files such as .git stay out
+// of reach, as do drafts. fs.FS already refuses paths that climb out with
+// "..", but os.DirFS follows symlinks, so links that lead out of the
+// directory are refused too.
+type contentFS struct {
+	dir  string
+	fsys fs.FS
+}
+
+func newContentFS(dir string) contentFS {
+	return contentFS{dir: dir, fsys: os.DirFS(dir)}
+}
+
+func (c contentFS) resolve(name string) (string, error) {
+	name = path.Clean(strings.TrimPrefix(name, "/"))
+	if name == "positions" {
+		name = "."
+	} else if strings.HasPrefix(name, "positions/") {
+		name = strings.TrimPrefix(name, "positions/")
+	}
+	if !fs.ValidPath(name) {
+		return "", fs.ErrInvalid
+	}
+	for _, part := range strings.Split(name, "/") {
+		if strings.HasPrefix(part, ".") && part != "." {
+			return "", fs.ErrNotExist
+		}
+	}
+	return name, nil
+}
+
+func (c contentFS) Open(name string) (fs.File, error) {
+	resolved, err := c.resolve(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	if c.draft(resolved) || !utils.InsideRoot(c.dir, resolved) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return c.fsys.Open(resolved)
+}
+
+// draft reports whether name is a markdown position whose frontmatter marks
+// it as a draft.
+func (c contentFS) draft(name string) bool {
+	if !strings.HasSuffix(name, ".md") {
+		return false
+	}
+	content, err := fs.ReadFile(c.fsys, name)
+	if err != nil {
+		return false
+	}
+	frontmatter, _, err := utils.ParsePosition(string(content))
+	return err == nil && frontmatter.Draft()
+}
+
+func (c contentFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	resolved, err := c.resolve(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: err}
+	}
+	if !utils.InsideRoot(c.dir, resolved) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	entries, err := fs.ReadDir(c.fsys, resolved)
+	if err != nil {
+		return nil, err
+	}
+	visible := entries[:0]
+	for _, entry := range entries {
+		entryName := path.Join(resolved, entry.Name())
+		if entry.Type()&fs.ModeSymlink != 0 && !utils.InsideRoot(c.dir, entryName) {
+			continue
+		}
+		if !strings.HasPrefix(entry.Name(), ".") && !c.draft(entryName) {
+			visible = append(visible, entry)
+		}
+	}
+	return visible, nil
+}
+
+// sftpSubsystem serves contentFS read-only; every write, rename or remove
+// is refused.
+func sftpSubsystem(content contentFS) ssh.Handler {
+	return func(s ssh.Session) {
+		handler := sftpHandler{content: content}
+		server := sftp.NewRequestServer(s, sftp.Handlers{
+			FileGet:  handler,
+			FilePut:  handler,
+			FileCmd:  handler,
+			FileList: handler,
+		})
+		if err := server.Serve(); err != nil && !errors.Is(err, io.EOF) {
+			log.Warn("sftp session ended", "error", err)
+		}
+		server.Close()
+	}
+}
+
+type sftpHandler struct {
+	content contentFS
+}
+
+func (h sftpHandler) Fileread(r *sftp.Request) (io.ReaderAt, error) {
+	file, err := h.content.Open(r.Filepath)
+	if err != nil {
+		return nil, os.ErrNotExist
+	}
+	readerAt, ok := file.(io.ReaderAt)
+	if !ok {
+		file.Close()
+		return nil, sftp.ErrSSHFxOpUnsupported
+	}
+	return readerAt, nil
+}
+
+func (h sftpHandler) Filewrite(*sftp.Request) (io.WriterAt, error) {
+	return nil, sftp.ErrSSHFxPermissionDenied
+}
+
+func (h sftpHandler) Filecmd(*sftp.Request) error {
+	return sftp.ErrSSHFxPermissionDenied
+}
+
+func (h sftpHandler) Filelist(r *sftp.Request) (sftp.ListerAt, error) {
+	switch r.Method {
+	case "List":
+		entries, err := h.content.ReadDir(r.Filepath)
+		if err != nil {
+			return nil, os.ErrNotExist
+		}
+		infos := make(listerAt, 0, len(entries))
+		for _, entry := range entries {
+			info, err := entry.Info()
+			if err != nil {
+				continue
+			}
+			infos = append(infos, info)
+		}
+		return infos, nil
+	case "Stat", "Lstat":
+		info, err := fs.Stat(h.content, r.Filepath)
+		if err != nil {
+			return nil, os.ErrNotExist
+		}
+		return listerAt{info}, nil
+	}
+	return nil, sftp.ErrSSHFxOpUnsupported
+}
+
+type listerAt []os.FileInfo
+
+func (l listerAt) ListAt(infos []os.FileInfo, offset int64) (int, error) {
+	if offset >= int64(len(l)) {
+		return 0, io.EOF
+	}
+	n := copy(infos, l[offset:])
+	if n < len(infos) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func withSFTP(handler ssh.Handler) ssh.Option {
+	return func(s *ssh.Server) error {
+		if s.SubsystemHandlers == nil {
+			s.SubsystemHandlers = map[string]ssh.SubsystemHandler{}
+		}
+		s.SubsystemHandlers["sftp"] = ssh.SubsystemHandler(handler)
+		return nil
+	}
+}
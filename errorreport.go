@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"organize/config"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/ssh"
+	"github.com/charmbracelet/wish"
+	"github.com/getsentry/sentry-go"
+)
+
+// sentryFlushTimeout is how long a crashing session or a shutdown waits for
+// reports still being sent.
+const sentryFlushTimeout = 2 * time.Second
+
+// hubKey is where errorReporting leaves the session's hub in its context.
+type hubKey struct{}
+
+// crashKey is where errorReporting leaves a flag the session's program sets
+// when it panicked, to say goodbye once the program has shut down.
+type crashKey struct{}
+
+// setupErrorReporting sends reports to cfg.DSN, when one is set. Without one
+// every hub drops what it is given, so reporting costs nothing.
+func setupErrorReporting(cfg config.Sentry) error {
+	if cfg.DSN == "" {
+		return nil
+	}
+	return sentry.Init(sentry.ClientOptions{
+		Dsn:              cfg.DSN,
+		Environment:      cfg.Environment,
+		AttachStacktrace: true,
+	})
+}
+
+// errorReporting gives each session a hub that tags its reports with who was
+// connected, when tracking is on, and turns a panic on the session's
+// goroutine into a report and a polite goodbye instead of taking the whole
+// server down. Panics in the session's program are caught by
+// recoveringModel, which leaves the goodbye to this.
+func errorReporting(tracking bool) wish.Middleware {
+	return func(next ssh.Handler) ssh.Handler {
+		return func(s ssh.Session) {
+			hub := sentry.CurrentHub().Clone()
+			hub.ConfigureScope(func(scope *sentry.Scope) {
+				if tracking {
+					scope.SetUser(sentry.User{
+						ID:        keyFingerprint(sessionKey(s)),
+						Username:  s.User(),
+						IPAddress: remoteIP(s.RemoteAddr()),
+					})
+				}
+				if record := sessionRecordFrom(s); record != nil {
+					scope.SetTag("session", record.id)
+				}
+				if pty, _, active := s.Pty(); active {
+					scope.SetTag("term", pty.Term)
+				}
+				if command := s.Command(); len(command) > 0 {
+					scope.SetTag("command", command[0])
+				}
+			})
+			s.Context().SetValue(hubKey{}, hub)
+			crashed := new(atomic.Bool)
+			s.Context().SetValue(crashKey{}, crashed)
+
+			defer func() {
+				recovered := recover()
+				if recovered == nil {
+					if crashed.Load() {
+						fmt.Fprint(s, "\r\nSomething went wrong on our side, sorry. Please reconnect.\r\n")
+						s.Exit(1)
+					}
+					return
+				}
+				reportPanic(hub, s.User(), recovered)
+				// Leave the alternate screen and show the cursor again, which
+				// bubbletea would have done on a clean exit.
+				fmt.Fprint(s, "\x1b[?1049l\x1b[?25h\r\nSomething went wrong on our side, sorry. Please reconnect.\r\n")
+				s.Exit(1)
+			}()
+			next(s)
+		}
+	}
+}
+
+func reportPanic(hub *sentry.Hub, user string, recovered interface{}) {
+	log.Error("session panicked", "user", user, "panic", recovered)
+	hub.Recover(recovered)
+	hub.Flush(sentryFlushTimeout)
+}
+
+// panicMsg tells a session's program that one of its commands panicked.
+type panicMsg struct{}
+
+// recoveringModel runs a session's program, reporting a panic in its Init,
+// Update or View, or in any command it returns, and quitting the program
+// cleanly. Bubble Tea runs each command on a goroutine of its own, where a
+// panic would take the whole server down, and a panic in Update would skip
+// the program's shutdown and leak its renderer.
+type recoveringModel struct {
+	model   tea.Model
+	hub     *sentry.Hub
+	user    string
+	crashed *atomic.Bool
+}
+
+func newRecoveringModel(s ssh.Session, model tea.Model) recoveringModel {
+	crashed, ok := s.Context().Value(crashKey{}).(*atomic.Bool)
+	if !ok {
+		crashed = new(atomic.Bool)
+	}
+	return recoveringModel{model: model, hub: sessionHub(s), user: s.User(), crashed: crashed}
+}
+
+func (r recoveringModel) Init() (cmd tea.Cmd) {
+	defer r.recover(&cmd)
+	return r.guard(r.model.Init())
+}
+
+func (r recoveringModel) Update(msg tea.Msg) (model tea.Model, cmd tea.Cmd) {
+	if _, ok := msg.(panicMsg); ok {
+		r.crashed.Store(true)
+		return r, tea.Quit
+	}
+	model = r
+	defer r.recover(&cmd)
+	next, cmd := r.model.Update(msg)
+	r.model = next
+	return r, r.guard(cmd)
+}
+
+func (r recoveringModel) View() (view string) {
+	if r.crashed.Load() {
+		return ""
+	}
+	defer r.recover(nil)
+	return r.model.View()
+}
+
+// recover reports a panic and has the program quit, through cmd when there
+// is one.
+func (r recoveringModel) recover(cmd *tea.Cmd) {
+	recovered := recover()
+	if recovered == nil {
+		return
+	}
+	if !r.crashed.Swap(true) {
+		reportPanic(r.hub, r.user, recovered)
+	}
+	if cmd != nil {
+		*cmd = tea.Quit
+	}
+}
+
+// guard wraps cmd, and the commands of a batch it returns, so a panic in
+// one is reported and ends the session.
+func (r recoveringModel) guard(cmd tea.Cmd) tea.Cmd {
+	if cmd == nil {
+		return nil
+	}
+	return func() (msg tea.Msg) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				if !r.crashed.Swap(true) {
+					reportPanic(r.hub, r.user, recovered)
+				}
+				msg = panicMsg{}
+			}
+		}()
+		msg = cmd()
+		if batch, ok := msg.(tea.BatchMsg); ok {
+			guarded := make(tea.BatchMsg, len(batch))
+			for i, cmd := range batch {
+				guarded[i] = r.guard(cmd)
+			}
+			msg = guarded
+		}
+		return msg
+	}
+}
+
+// sessionHub is the hub errorReporting set up for s.
+func sessionHub(s ssh.Session) *sentry.Hub {
+	if hub, ok := s.Context().Value(hubKey{}).(*sentry.Hub); ok {
+		return hub
+	}
+	return sentry.CurrentHub()
+}
+
+// reportError logs err and sends it on through hub, with keyvals as extra
+// detail. Pass sentry.CurrentHub() for errors outside a session.
+func reportError(hub *sentry.Hub, msg string, err error, keyvals ...interface{}) {
+	log.Error(msg, append(keyvals, "error", err)...)
+	hub.WithScope(func(scope *sentry.Scope) {
+		for i := 0; i+1 < len(keyvals); i += 2 {
+			scope.SetExtra(fmt.Sprint(keyvals[i]), keyvals[i+1])
+		}
+		hub.CaptureException(fmt.Errorf("%s: %w", msg, err))
+	})
+}
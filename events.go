@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"organize/calendar"
+	"organize/components"
+	"organize/utils"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+)
+
+// eventsSection lists what the club has coming up, soonest first, and shows
+// the details of the one picked.
+type eventsSection struct {
+	viewport viewport.Model
+	events   []calendar.Event
+	cursor   int
+	open     bool
+	// listOffset is where the list was scrolled to before an event was
+	// opened.
+	listOffset int
+}
+
+func (s *eventsSection) title() string { return "Events" }
+
+func (s *eventsSection) resize(m *Model, width int, height int) {
+	if s.viewport.Width == 0 {
+		s.viewport = newSectionViewport(m.keys)
+	}
+	s.viewport.Width, s.viewport.Height = width, height
+
+	var err error
+	s.events, err = calendar.Load(m.config.EventsDir, time.Now())
+	if err != nil {
+		log.Warn("could not load events", "error", err)
+	}
+	s.cursor = utils.Min(s.cursor, utils.Max(0, len(s.events)-1))
+	s.open = s.open && len(s.events) > 0
+	s.refresh(m)
+}
+
+// refresh draws the list or the open event into the viewport.
+func (s *eventsSection) refresh(m *Model) {
+	if s.open {
+		rendered, err := m.renderers.render(m.theme, utils.Max(1, s.viewport.Width-1), eventMarkdown(s.events[s.cursor], time.Now()))
+		if err != nil {
+			reportError(m.hub, "could not render event", err, "event", s.events[s.cursor].Title)
+			rendered = s.events[s.cursor].Body
+		}
+		s.viewport.SetContent(rendered)
+		return
+	}
+
+	now := time.Now()
+	cards := make([]string, len(s.events))
+	top := 0
+	for i, event := range s.events {
+		cards[i] = components.EventCardView(m.theme, s.viewport.Width, event, now, i == s.cursor)
+		if i < s.cursor {
+			top += lipgloss.Height(cards[i])
+		}
+	}
+	s.viewport.SetContent(strings.Join(cards, "\n"))
+	if len(cards) == 0 {
+		return
+	}
+	// Keep the selected event on screen.
+	bottom := top + lipgloss.Height(cards[s.cursor])
+	if top < s.viewport.YOffset {
+		s.viewport.SetYOffset(top)
+	} else if bottom > s.viewport.YOffset+s.viewport.Height {
+		s.viewport.SetYOffset(bottom - s.viewport.Height)
+	}
+}
+
+func (s *eventsSection) update(m *Model, msg tea.KeyMsg) tea.Cmd {
+	if len(s.events) == 0 {
+		return nil
+	}
+	if s.open {
+		if key.Matches(msg, m.keys.Back) {
+			s.open = false
+			s.refresh(m)
+			s.viewport.SetYOffset(s.listOffset)
+			return nil
+		}
+		var cmd tea.Cmd
+		s.viewport, cmd = s.viewport.Update(msg)
+		return cmd
+	}
+
+	switch {
+	case key.Matches(msg, m.keys.Up):
+		s.cursor = utils.Max(0, s.cursor-1)
+	case key.Matches(msg, m.keys.Down):
+		s.cursor = utils.Min(len(s.events)-1, s.cursor+1)
+	case key.Matches(msg, m.keys.Top):
+		s.cursor = 0
+	case key.Matches(msg, m.keys.Bottom):
+		s.cursor = len(s.events) - 1
+	case key.Matches(msg, m.keys.Enter):
+		s.open = true
+		s.listOffset = s.viewport.YOffset
+		s.refresh(m)
+		s.viewport.GotoTop()
+		return nil
+	default:
+		var cmd tea.Cmd
+		s.viewport, cmd = s.viewport.Update(msg)
+		return cmd
+	}
+	s.refresh(m)
+	return nil
+}
+
+func (s *eventsSection) view(m Model) string {
+	if len(s.events) == 0 {
+		empty := lipgloss.NewStyle().Faint(true).Render("No events planned yet, watch Discord for the next one.")
+		return lipgloss.Place(s.viewport.Width, s.viewport.Height, lipgloss.Center, lipgloss.Center, empty)
+	}
+	return s.viewport.View()
+}
+
+// eventMarkdown is an event's details as markdown for glamour.
+func eventMarkdown(event calendar.Event, now time.Time) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n**%s**, %s", event.Title, calendar.Countdown(event.Start, now), components.EventDate(event))
+	if event.Location != "" {
+		fmt.Fprintf(&b, " at %s", event.Location)
+	}
+	b.WriteString("\n\n")
+	b.WriteString(event.Body)
+	return b.String()
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/xml"
+	"sort"
+	"time"
+
+	"organize/config"
+)
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Description string   `xml:"description"`
+	GUID        rssGUID  `xml:"guid"`
+	PubDate     string   `xml:"pubDate"`
+	Categories  []string `xml:"category"`
+}
+
+type rssGUID struct {
+	Value       string `xml:",chardata"`
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+}
+
+// buildFeed lists the open positions as an RSS 2.0 feed, most recently
+// updated first. Drafts and closed positions are left out.
+func buildFeed(cfg *config.Config) ([]byte, error) {
+	positions, err := listPositions(cfg)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(positions, func(i, j int) bool {
+		return positions[i].Updated.After(positions[j].Updated)
+	})
+
+	channel := rssChannel{
+		Title:       "JODC open positions",
+		Link:        cfg.ApplyURL,
+		Description: "Open positions at the JIIT Open Source Developers Club",
+	}
+	for _, position := range positions {
+		if position.Status != "open" {
+			continue
+		}
+		if channel.LastBuildDate == "" {
+			channel.LastBuildDate = position.Updated.Format(time.RFC1123Z)
+		}
+		channel.Items = append(channel.Items, rssItem{
+			Title:       position.Title,
+			Link:        position.ApplyURL,
+			Description: position.Description,
+			GUID:        rssGUID{Value: position.File},
+			PubDate:     position.Updated.Format(time.RFC1123Z),
+			Categories:  position.Tags,
+		})
+	}
+
+	content, err := xml.MarshalIndent(rssFeed{Version: "2.0", Channel: channel}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), content...), nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"organize/applications"
+	"organize/feedback"
+	"organize/utils"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+)
+
+// feedbackSection takes suggestions, questions and bug reports for the
+// organisers through a short form.
+type feedbackSection struct {
+	width  int
+	height int
+	form   *huh.Form
+	draft  feedback.Feedback
+}
+
+func (s *feedbackSection) title() string { return "Feedback" }
+
+func (s *feedbackSection) resize(m *Model, width int, height int) {
+	s.width, s.height = width, height
+	if s.form != nil {
+		s.form = s.form.WithWidth(utils.Min(width-4, 80))
+	}
+}
+
+func (s *feedbackSection) typing() bool { return s.form != nil }
+
+func (s *feedbackSection) update(m *Model, msg tea.KeyMsg) tea.Cmd {
+	if key.Matches(msg, m.keys.Enter) {
+		return s.openForm(m)
+	}
+	return nil
+}
+
+func (s *feedbackSection) openForm(m *Model) tea.Cmd {
+	s.draft = feedback.Feedback{Topic: feedback.Topics[0]}
+
+	keyMap := huh.NewDefaultKeyMap()
+	keyMap.Quit = key.NewBinding(key.WithKeys("esc", "ctrl+c"))
+	// The editor would be started on the server, not on the visitor's machine.
+	keyMap.Text.Editor.SetEnabled(false)
+
+	s.form = huh.NewForm(
+		huh.NewGroup(
+			huh.NewSelect[string]().
+				Title("What is it about?").
+				Options(huh.NewOptions(feedback.Topics...)...).
+				Value(&s.draft.Topic),
+			huh.NewText().
+				Title("Your message").
+				CharLimit(feedback.MaxLength).
+				Value(&s.draft.Message).
+				Validate(applications.ValidateRequired),
+		).Title("Feedback").
+			Description("The organisers read every message. Press esc at any time to cancel."),
+	).WithKeyMap(keyMap).
+		WithTheme(m.theme.Form()).
+		WithWidth(utils.Min(s.width-4, 80))
+	return s.form.Init()
+}
+
+func (s *feedbackSection) updateInput(m *Model, msg tea.Msg) tea.Cmd {
+	form, cmd := s.form.Update(msg)
+	s.form = form.(*huh.Form)
+
+	switch s.form.State {
+	case huh.StateCompleted:
+		s.submit(m)
+	case huh.StateAborted:
+		m.statusMessage = "Feedback cancelled"
+	default:
+		return cmd
+	}
+	s.form = nil
+	return nil
+}
+
+func (s *feedbackSection) submit(m *Model) {
+	sent := s.draft
+	sent.Message = strings.TrimSpace(sent.Message)
+	sent.User = m.user
+	sent.Fingerprint = m.fingerprint
+	sent.SubmittedAt = time.Now()
+
+	if err := m.feedback.SaveFeedback(sent); err != nil {
+		reportError(m.hub, "could not save feedback", err)
+		m.statusMessage = "Could not send your feedback, please try again later"
+		return
+	}
+	log.Info("feedback received", "topic", sent.Topic, "user", sent.User)
+	if m.feedbackHook != nil {
+		m.feedbackHook.NotifyFeedback(m.ctx, sent)
+	}
+	m.statusMessage = "Thanks! The organisers will read your feedback"
+}
+
+func (s *feedbackSection) view(m Model) string {
+	if s.form != nil {
+		return lipgloss.NewStyle().Padding(1, 2).Height(s.height).MaxHeight(s.height).Render(s.form.View())
+	}
+	intro := lipgloss.JoinVertical(lipgloss.Left,
+		lipgloss.NewStyle().Bold(true).Render("Tell the organisers what you think"),
+		"",
+		"Got an idea for an event, found a bug here or have a question?",
+		lipgloss.NewStyle().Faint(true).Render("Press "+m.keys.Enter.Help().Key+" to write to us."),
+	)
+	return lipgloss.NewStyle().Padding(1, 2).Height(s.height).MaxHeight(s.height).Render(intro)
+}
@@ -0,0 +1,89 @@
+package feedback
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MaxLength is how many characters a message may be.
+const MaxLength = 2000
+
+// Topics are what feedback can be about, the first picked to begin with.
+var Topics = []string{"Suggestion", "Event idea", "Bug report", "Question", "Something else"}
+
+// Feedback is a suggestion or question sent through the feedback form.
+type Feedback struct {
+	Topic       string    `json:"topic"`
+	Message     string    `json:"message"`
+	User        string    `json:"user"`
+	Fingerprint string    `json:"fingerprint"`
+	SubmittedAt time.Time `json:"submitted_at"`
+}
+
+type Store interface {
+	SaveFeedback(feedback Feedback) error
+	// Feedback is everything sent so far, oldest first.
+	Feedback() ([]Feedback, error)
+}
+
+// FileStore appends all feedback to a file as one line of JSON each.
+type FileStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+func (s *FileStore) SaveFeedback(feedback Feedback) error {
+	line, err := json.Marshal(feedback)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Feedback returns everything sent in the order it came in. A missing file
+// just means nothing has been sent yet.
+func (s *FileStore) Feedback() ([]Feedback, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	content, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var sent []Feedback
+	for _, line := range strings.Split(string(content), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		var feedback Feedback
+		if err := json.Unmarshal([]byte(line), &feedback); err != nil {
+			return nil, err
+		}
+		sent = append(sent, feedback)
+	}
+	return sent, nil
+}
@@ -0,0 +1,202 @@
+// Package gitsync keeps a local checkout of a git repository, such as the
+// GitHub repository the positions are managed in, in step with one branch,
+// answers GitHub's and GitLab's push webhooks to update it straight away, and
+// reads back the history of the files in it.
+package gitsync
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// maxWebhookBody is the largest webhook payload read; push events are well
+// under it.
+const maxWebhookBody = 5 << 20
+
+// Options say which branch of which repository to keep a checkout of.
+type Options struct {
+	// Repository is owner/name on GitHub, or the URL of any git remote.
+	Repository string
+	Branch     string
+	// Dir is where the checkout lives. The server owns it: local changes are
+	// thrown away on every sync.
+	Dir string
+	// Token is sent as the password for HTTPS remotes, for private
+	// repositories. It is kept out of the checkout's git config.
+	Token string
+}
+
+// Repo is a checkout of one branch of a repository.
+type Repo struct {
+	opts Options
+	mu   sync.Mutex
+}
+
+func New(opts Options) *Repo {
+	return &Repo{opts: opts}
+}
+
+// URL is where the repository is fetched from.
+func (r *Repo) URL() string {
+	if strings.Contains(r.opts.Repository, "://") || strings.Contains(r.opts.Repository, "@") {
+		return r.opts.Repository
+	}
+	return "https://github.com/" + strings.TrimSuffix(r.opts.Repository, ".git") + ".git"
+}
+
+func (r *Repo) String() string {
+	return r.URL() + "@" + r.opts.Branch
+}
+
+// Sync clones the repository if there is no checkout yet and otherwise
+// brings it up to the tip of the branch, and says whether the files changed.
+func (r *Repo) Sync(ctx context.Context) (changed bool, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, err := os.Stat(filepath.Join(r.opts.Dir, ".git")); errors.Is(err, os.ErrNotExist) {
+		if err := os.MkdirAll(filepath.Dir(filepath.Clean(r.opts.Dir)), 0o755); err != nil {
+			return false, err
+		}
+		if _, err := r.git(ctx, "", "clone", "--quiet", "--single-branch", "--branch", r.opts.Branch, r.URL(), r.opts.Dir); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+
+	before, err := r.git(ctx, r.opts.Dir, "rev-parse", "HEAD")
+	if err != nil {
+		return false, err
+	}
+	// The whole history is kept for the reader to show. Checkouts cloned
+	// shallow before that get the rest of it on their next fetch.
+	fetch := []string{"fetch", "--quiet"}
+	if _, err := os.Stat(filepath.Join(r.opts.Dir, ".git", "shallow")); err == nil {
+		fetch = append(fetch, "--unshallow")
+	}
+	if _, err := r.git(ctx, r.opts.Dir, append(fetch, r.URL(), r.opts.Branch)...); err != nil {
+		return false, err
+	}
+	if _, err := r.git(ctx, r.opts.Dir, "reset", "--quiet", "--hard", "FETCH_HEAD"); err != nil {
+		return false, err
+	}
+	if _, err := r.git(ctx, r.opts.Dir, "clean", "--quiet", "-fd"); err != nil {
+		return false, err
+	}
+	after, err := r.git(ctx, r.opts.Dir, "rev-parse", "HEAD")
+	if err != nil {
+		return false, err
+	}
+	return before != after, nil
+}
+
+// Head is the commit the checkout is at.
+func (r *Repo) Head() (string, error) {
+	return r.git(context.Background(), r.opts.Dir, "rev-parse", "--short", "HEAD")
+}
+
+func (r *Repo) git(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	// Never stop to ask for a password the server can't type.
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if r.opts.Token != "" {
+		// Passed through the environment so it shows up neither in the
+		// process list nor in .git/config.
+		auth := base64.StdEncoding.EncodeToString([]byte("x-access-token:" + r.opts.Token))
+		cmd.Env = append(cmd.Env,
+			"GIT_CONFIG_COUNT=1",
+			"GIT_CONFIG_KEY_0=http.extraHeader",
+			"GIT_CONFIG_VALUE_0=Authorization: Basic "+auth,
+		)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// PushHandler answers the push webhooks of GitHub and GitLab, calling onPush
+// for every push to branch, or to any branch when branch is empty. GitHub
+// deliveries must be signed with secret and GitLab's must carry it as their
+// token, so nobody else can make the server refresh.
+func PushHandler(secret string, branch string, onPush func()) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBody))
+		if err != nil {
+			http.Error(w, "could not read body", http.StatusBadRequest)
+			return
+		}
+
+		var push bool
+		switch {
+		case r.Header.Get("X-GitHub-Event") != "":
+			if !validSignature(secret, body, r.Header.Get("X-Hub-Signature-256")) {
+				http.Error(w, "bad signature", http.StatusUnauthorized)
+				return
+			}
+			push = r.Header.Get("X-GitHub-Event") == "push"
+		case r.Header.Get("X-Gitlab-Event") != "":
+			if subtle.ConstantTimeCompare([]byte(r.Header.Get("X-Gitlab-Token")), []byte(secret)) != 1 {
+				http.Error(w, "bad token", http.StatusUnauthorized)
+				return
+			}
+			push = r.Header.Get("X-Gitlab-Event") == "Push Hook"
+		default:
+			http.Error(w, "not a github or gitlab webhook", http.StatusBadRequest)
+			return
+		}
+		// Pings and other events only need to hear that the hook works.
+		if !push {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		// Both send the pushed ref as ref.
+		var event struct {
+			Ref string `json:"ref"`
+		}
+		if err := json.Unmarshal(body, &event); err != nil {
+			http.Error(w, "malformed push event", http.StatusBadRequest)
+			return
+		}
+		if branch != "" && event.Ref != "refs/heads/"+branch {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		go onPush()
+		w.WriteHeader(http.StatusAccepted)
+	})
+}
+
+// validSignature checks the sha256=<hex> HMAC GitHub signs each delivery with.
+func validSignature(secret string, body []byte, header string) bool {
+	signature, err := hex.DecodeString(strings.TrimPrefix(header, "sha256="))
+	if err != nil || !strings.HasPrefix(header, "sha256=") {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(signature, mac.Sum(nil))
+}
@@ -0,0 +1,76 @@
+package gitsync
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrNotRepository is returned for files outside any git work tree.
+var ErrNotRepository = errors.New("not in a git repository")
+
+// Commit is one change in a file's history.
+type Commit struct {
+	Hash    string
+	Author  string
+	Date    time.Time
+	Subject string
+}
+
+// History lists up to limit commits that changed the file at path, newest
+// first, following it across renames. A file that was never committed has
+// none.
+func History(path string, limit int) ([]Commit, error) {
+	dir, name := filepath.Split(path)
+	if !isWorkTree(dir) {
+		return nil, ErrNotRepository
+	}
+	// Fields are split on the unit separator, which no name or subject has.
+	out, err := run(dir, "log", "--follow", fmt.Sprintf("--max-count=%d", limit), "--format=%h%x1f%an%x1f%aI%x1f%s", "--", name)
+	if err != nil {
+		return nil, err
+	}
+	var commits []Commit
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Split(line, "\x1f")
+		if len(fields) != 4 {
+			continue
+		}
+		date, _ := time.Parse(time.RFC3339, fields[2])
+		commits = append(commits, Commit{Hash: fields[0], Author: fields[1], Date: date, Subject: fields[3]})
+	}
+	return commits, nil
+}
+
+// LastChange is the patch the newest commit to touch the file at path made
+// to it.
+func LastChange(path string) (string, error) {
+	dir, name := filepath.Split(path)
+	if !isWorkTree(dir) {
+		return "", ErrNotRepository
+	}
+	return run(dir, "log", "--follow", "--max-count=1", "--patch", "--format=", "--no-color", "--no-ext-diff", "--no-textconv", "--", name)
+}
+
+func isWorkTree(dir string) bool {
+	out, err := run(dir, "rev-parse", "--is-inside-work-tree")
+	return err == nil && out == "true"
+}
+
+// run is git in dir, for reading a work tree nobody needs to log in to.
+func run(dir string, args ...string) (string, error) {
+	if dir == "" {
+		dir = "."
+	}
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimRight(stdout.String(), "\n"), nil
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+
+	"organize/termimage"
+	"organize/utils"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// graphicsMarker is an escape sequence that does nothing, left where the
+// logo's bitmap goes for View to find once the screen is laid out.
+const graphicsMarker = "\x1b[8m\x1b[28m"
+
+// logoImageID is the number kitty terminals keep the logo under.
+const logoImageID = 1
+
+// frameEnd is how the renderer ends every frame it draws on the alt screen:
+// by moving the cursor to the start of the last line.
+var frameEnd = regexp.MustCompile(`\x1b\[\d+;\d+H$`)
+
+// logoGraphics draws the logo as a bitmap on terminals that speak sixel or
+// the kitty graphics protocol. The program's renderer only knows text, so the
+// list view leaves blank cells where the logo goes and graphicsOutput draws
+// the bitmap over them once a frame is on screen.
+type logoGraphics struct {
+	mu          sync.Mutex
+	protocol    termimage.Protocol
+	image       string
+	columns     int
+	rows        int
+	transmitted bool
+	// row and column are where the logo is on screen, counting from 1; row
+	// is 0 while it is not.
+	row    int
+	column int
+	// lines are the screen lines the logo was last placed on. The renderer
+	// redraws lines that change, wiping a sixel bitmap with them.
+	lines string
+	shown bool
+	dirty bool
+}
+
+func newLogoGraphics(path string, term terminal, columns int, rows int) (*logoGraphics, error) {
+	img, err := termimage.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	scaled := termimage.Scale(img, columns*term.cellWidth, rows*term.cellHeight)
+
+	graphics := &logoGraphics{protocol: term.graphics, columns: columns, rows: rows}
+	switch term.graphics {
+	case termimage.Sixel:
+		graphics.image = termimage.EncodeSixel(scaled)
+	case termimage.Kitty:
+		graphics.image, err = termimage.KittyTransmit(scaled, logoImageID)
+	}
+	return graphics, err
+}
+
+// placeholder is blank cells as big as the logo after padding columns, with
+// the marker where the bitmap goes.
+func (g *logoGraphics) placeholder(padding int) string {
+	lines := make([]string, g.rows)
+	for i := range lines {
+		lines[i] = strings.Repeat(" ", padding+g.columns)
+	}
+	lines[0] = strings.Repeat(" ", padding) + graphicsMarker + strings.Repeat(" ", g.columns)
+	return strings.Join(lines, "\n")
+}
+
+// place takes the marker out of a finished view and notes where the logo
+// goes on a screen height lines tall. The bitmap is only drawn when all of it
+// fits on the screen.
+func (g *logoGraphics) place(view string, height int) string {
+	row, column, lines := 0, 0, ""
+	if i := strings.Index(view, graphicsMarker); i >= 0 {
+		before := view[:i]
+		view = before + view[i+len(graphicsMarker):]
+
+		viewLines := strings.Split(view, "\n")
+		line := strings.Count(before, "\n")
+		// The renderer drops the top of views taller than the screen.
+		top := line - utils.Max(0, len(viewLines)-height)
+		if top >= 0 && top+g.rows <= height && line+g.rows <= len(viewLines) {
+			row = top + 1
+			column = runewidth.StringWidth(utils.StripANSI(before[strings.LastIndex(before, "\n")+1:])) + 1
+			lines = strings.Join(viewLines[line:line+g.rows], "\n")
+		}
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if row != g.row || column != g.column || lines != g.lines {
+		g.row, g.column, g.lines = row, column, lines
+		g.dirty = true
+	}
+	return view
+}
+
+// redraw has the bitmap drawn again after the next frame, for when the whole
+// screen is cleared.
+func (g *logoGraphics) redraw() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.dirty = true
+}
+
+// pending is what has to follow the frame just written to bring the bitmap up
+// to date. The cursor is left where the renderer expects it.
+func (g *logoGraphics) pending() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.dirty {
+		return ""
+	}
+	g.dirty = false
+
+	if g.row == 0 {
+		// Sixels went with the text drawn over them; kitty images stay put.
+		wasShown := g.shown
+		g.shown = false
+		if wasShown && g.protocol == termimage.Kitty {
+			return termimage.KittyDelete(logoImageID)
+		}
+		return ""
+	}
+
+	g.shown = true
+	var b strings.Builder
+	fmt.Fprintf(&b, "\x1b7\x1b[%d;%dH", g.row, g.column)
+	switch g.protocol {
+	case termimage.Sixel:
+		b.WriteString(g.image)
+	case termimage.Kitty:
+		if !g.transmitted {
+			b.WriteString(g.image)
+			g.transmitted = true
+		}
+		b.WriteString(termimage.KittyPlace(logoImageID, g.columns, g.rows))
+	}
+	b.WriteString("\x1b8")
+	return b.String()
+}
+
+// graphicsOutput passes the program's output on to the session and draws the
+// logo's bitmap after each frame.
+type graphicsOutput struct {
+	io.Writer
+	graphics *logoGraphics
+}
+
+func (o graphicsOutput) Write(p []byte) (int, error) {
+	n, err := o.Writer.Write(p)
+	if err != nil {
+		return n, err
+	}
+	switch {
+	case bytes.Contains(p, []byte("\x1b[?1049h")) || bytes.Contains(p, []byte("\x1b[2J")):
+		// The screen was wiped, and the frame to draw on comes next.
+		o.graphics.redraw()
+	case frameEnd.Match(p):
+		if extra := o.graphics.pending(); extra != "" {
+			_, err = io.WriteString(o.Writer, extra)
+		}
+	}
+	return n, err
+}
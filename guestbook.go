@@ -0,0 +1,161 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"organize/components"
+	"organize/guestbook"
+	"organize/utils"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/log"
+	"golang.org/x/time/rate"
+)
+
+// guestbookInterval is how long a visitor waits between messages.
+const guestbookInterval = 10 * time.Minute
+
+// newGuestbookLimiter lets each key sign once every guestbookInterval. The
+// server's limiter forgets a key once it would have been allowed again.
+func newGuestbookLimiter() *connectionLimiter {
+	return &connectionLimiter{
+		limit:    rate.Every(guestbookInterval),
+		burst:    1,
+		limiters: make(map[string]*ipLimiter),
+		swept:    time.Now(),
+	}
+}
+
+// guestbookSection lists the guestbook's messages, newest first, with a line
+// to write one below them while signing.
+type guestbookSection struct {
+	viewport viewport.Model
+	input    textinput.Model
+	entries  []guestbook.Entry
+	signing  bool
+	height   int
+}
+
+func (s *guestbookSection) title() string { return "Guestbook" }
+
+func (s *guestbookSection) resize(m *Model, width int, height int) {
+	if s.viewport.Width == 0 {
+		s.viewport = newSectionViewport(m.keys)
+		s.input = textinput.New()
+		s.input.Placeholder = "leave the club a message"
+		s.input.Prompt = "✎ "
+		s.input.CharLimit = guestbook.MaxLength
+	}
+	s.viewport.Width, s.height = width, height
+	s.input.Width = width - 4
+	s.refresh(m)
+}
+
+// open reads the guestbook again each time the tab is opened.
+func (s *guestbookSection) open(m *Model) {
+	entries, err := m.guestbook.Entries()
+	if err != nil {
+		log.Warn("could not read guestbook", "error", err)
+	}
+	s.entries = entries
+}
+
+// refresh lists the entries, leaving the bottom line for the message while
+// signing.
+func (s *guestbookSection) refresh(m *Model) {
+	s.viewport.Height = s.height
+	if s.signing {
+		s.viewport.Height--
+	}
+	s.viewport.SetContent(components.GuestbookView(m.theme, s.viewport.Width, s.entries, time.Now(), m.keys.Sign.Help().Key))
+}
+
+func (s *guestbookSection) typing() bool { return s.signing }
+
+// startSigning opens the line to write a message on, for visitors who
+// connected with a key.
+func (s *guestbookSection) startSigning(m *Model) tea.Cmd {
+	if m.fingerprint == "" {
+		m.statusMessage = "Connect with an SSH key to sign the guestbook"
+		return nil
+	}
+	s.signing = true
+	s.refresh(m)
+	return s.input.Focus()
+}
+
+func (s *guestbookSection) stopSigning(m *Model) {
+	s.signing = false
+	s.input.Blur()
+	s.input.Reset()
+	s.refresh(m)
+}
+
+func (s *guestbookSection) update(m *Model, msg tea.KeyMsg) tea.Cmd {
+	if key.Matches(msg, m.keys.Sign) {
+		return s.startSigning(m)
+	}
+	var cmd tea.Cmd
+	s.viewport, cmd = s.viewport.Update(msg)
+	return cmd
+}
+
+func (s *guestbookSection) updateInput(m *Model, msg tea.Msg) tea.Cmd {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.Type {
+		case tea.KeyEnter:
+			message := strings.TrimSpace(utils.Printable(s.input.Value()))
+			if err := guestbook.Validate(message); err != nil {
+				m.statusMessage = "Could not sign: " + err.Error()
+				return nil
+			}
+			if !m.guestbookLimit.allow(m.fingerprint) {
+				m.statusMessage = "You signed a moment ago, come back in a few minutes"
+				return nil
+			}
+			entry := guestbook.Entry{User: utils.Printable(m.user), Fingerprint: m.fingerprint, Message: guestbook.Censor(message), SignedAt: time.Now()}
+			if err := m.guestbook.Sign(entry); err != nil {
+				reportError(m.hub, "could not sign guestbook", err)
+				m.statusMessage = "Could not sign the guestbook, try again later"
+				return nil
+			}
+			log.Info("guestbook signed", "user", m.user)
+			s.entries = append([]guestbook.Entry{entry}, s.entries...)
+			if len(s.entries) > guestbook.MaxEntries {
+				s.entries = s.entries[:guestbook.MaxEntries]
+			}
+			m.statusMessage = "Thanks for signing!"
+			s.stopSigning(m)
+			s.viewport.GotoTop()
+			return nil
+		case tea.KeyEsc:
+			s.stopSigning(m)
+			return nil
+		}
+	}
+	var cmd tea.Cmd
+	s.input, cmd = s.input.Update(msg)
+	return cmd
+}
+
+func (s *guestbookSection) view(m Model) string {
+	if s.signing {
+		return s.viewport.View() + "\n " + s.input.View()
+	}
+	return s.viewport.View()
+}
+
+// openGuestbook switches to the guestbook tab and starts a message there.
+func (m *Model) openGuestbook() tea.Cmd {
+	for i, s := range m.sections {
+		if g, ok := s.(*guestbookSection); ok {
+			m.switchTab(i + 1)
+			return g.startSigning(m)
+		}
+	}
+	return nil
+}
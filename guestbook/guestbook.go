@@ -0,0 +1,147 @@
+package guestbook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/charmbracelet/log"
+)
+
+// MaxLength is how many characters a message may be.
+const MaxLength = 140
+
+// MaxEntries is how many of the newest messages are read and shown. Older
+// ones stay in the store.
+const MaxEntries = 200
+
+// Entry is a message a visitor left, signed with the key they connected with.
+type Entry struct {
+	User        string    `json:"user"`
+	Fingerprint string    `json:"fingerprint"`
+	Message     string    `json:"message"`
+	SignedAt    time.Time `json:"signed_at"`
+}
+
+type Store interface {
+	Sign(entry Entry) error
+	// Entries are the newest MaxEntries messages, newest first.
+	Entries() ([]Entry, error)
+}
+
+// FileStore appends every entry to a file as one line of JSON.
+type FileStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+func (s *FileStore) Sign(entry Entry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Entries returns the newest MaxEntries entries, newest first. A missing file
+// just means nobody has signed yet, and a line that isn't an entry, say one
+// cut short by a crash, is skipped so the rest still show.
+func (s *FileStore) Entries() ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	content, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	for i, line := range strings.Split(string(content), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			log.Warn("skipping malformed guestbook entry", "path", s.path, "line", i+1, "error", err)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) > MaxEntries {
+		entries = entries[len(entries)-MaxEntries:]
+	}
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	return entries, nil
+}
+
+// profanity are the starts and ends of words Censor blanks out. Matching the
+// ends of words as well catches compounds without touching place names that
+// merely contain one.
+var profanity = []string{
+	"fuck", "shit", "cunt", "bitch", "asshole", "bastard", "wanker",
+	"twat", "slut", "whore", "nigger", "faggot", "retard",
+}
+
+// Censor blanks out all but the first letter of every word in message that
+// starts or ends with a profanity.
+func Censor(message string) string {
+	runes := []rune(message)
+	for start := 0; start < len(runes); {
+		if !unicode.IsLetter(runes[start]) {
+			start++
+			continue
+		}
+		end := start
+		for end < len(runes) && unicode.IsLetter(runes[end]) {
+			end++
+		}
+		word := strings.ToLower(string(runes[start:end]))
+		for _, bad := range profanity {
+			if strings.HasPrefix(word, bad) || strings.HasSuffix(word, bad) {
+				for i := start + 1; i < end; i++ {
+					runes[i] = '*'
+				}
+				break
+			}
+		}
+		start = end
+	}
+	return string(runes)
+}
+
+// Validate checks a message is worth keeping: not blank and not too long.
+func Validate(message string) error {
+	switch {
+	case strings.TrimSpace(message) == "":
+		return errors.New("write a message first")
+	case len([]rune(message)) > MaxLength:
+		return fmt.Errorf("keep it to %d characters", MaxLength)
+	}
+	return nil
+}
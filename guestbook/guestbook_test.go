@@ -0,0 +1,46 @@
+package guestbook
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestEntriesSkipBadLinesAndKeepTheNewest(t *testing.T) {
+	store := NewFileStore(filepath.Join(t.TempDir(), "guestbook.jsonl"))
+	signed := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < MaxEntries+5; i++ {
+		if err := store.Sign(Entry{User: fmt.Sprint(i), Message: "hi", SignedAt: signed.Add(time.Duration(i) * time.Minute)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A write cut short by a crash.
+	file, err := os.OpenFile(store.path, os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString(`{"user":"cut","mess` + "\n")
+	file.Close()
+	if err := store.Sign(Entry{User: "last", Message: "still here"}); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := store.Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != MaxEntries {
+		t.Fatalf("got %d entries, want %d", len(entries), MaxEntries)
+	}
+	if entries[0].User != "last" {
+		t.Errorf("newest entry is %q, want last", entries[0].User)
+	}
+	if want := fmt.Sprint(MaxEntries + 4); entries[1].User != want {
+		t.Errorf("second entry is %q, want %s", entries[1].User, want)
+	}
+	if oldest, want := entries[len(entries)-1].User, fmt.Sprint(6); oldest != want {
+		t.Errorf("oldest entry is %q, want %s", oldest, want)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"sync/atomic"
+
+	"organize/config"
+)
+
+type healthCheck struct {
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// newHealthServer answers /healthz while the SSH listener is up, so the
+// orchestrator restarts a server that stopped accepting connections, and
+// /readyz once the content directory and host key are usable as well.
+func newHealthServer(addr string, cfg *config.Config, listening *atomic.Bool) *http.Server {
+	listener := func() healthCheck {
+		if listening.Load() {
+			return healthCheck{OK: true}
+		}
+		return healthCheck{Detail: "ssh listener is down"}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeHealth(w, map[string]healthCheck{"listener": listener()})
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		checks := map[string]healthCheck{"listener": listener(), "content": {OK: true}, "host_key": {OK: true}}
+		if _, err := os.ReadDir(cfg.Directory); err != nil {
+			checks["content"] = healthCheck{Detail: err.Error()}
+		}
+		if _, err := os.Stat(cfg.HostKeyPath); err != nil {
+			checks["host_key"] = healthCheck{Detail: err.Error()}
+		}
+		writeHealth(w, checks)
+	})
+
+	return &http.Server{Addr: addr, Handler: mux}
+}
+
+func writeHealth(w http.ResponseWriter, checks map[string]healthCheck) {
+	status := http.StatusOK
+	for _, check := range checks {
+		if !check.OK {
+			status = http.StatusServiceUnavailable
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(checks)
+}
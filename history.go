@@ -0,0 +1,114 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"time"
+
+	"organize/components"
+	"organize/gitsync"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// historyLimit is how many commits the history of a position lists.
+const historyLimit = 50
+
+// historyLoadedMsg is the git history of a position, read off the Update
+// loop.
+type historyLoadedMsg struct {
+	fileName   string
+	commits    []gitsync.Commit
+	lastChange string
+	err        error
+}
+
+// loadHistory reads who changed the open position when, and what the latest
+// change did, from the git repository the content directory is in.
+func (m Model) loadHistory() tea.Cmd {
+	fileName := m.selectedFileName
+	path := filepath.Join(m.config.Directory, filepath.FromSlash(fileName))
+	return func() tea.Msg {
+		msg := historyLoadedMsg{fileName: fileName}
+		msg.commits, msg.err = gitsync.History(path, historyLimit)
+		if msg.err == nil && len(msg.commits) > 0 {
+			msg.lastChange, msg.err = gitsync.LastChange(path)
+		}
+		return msg
+	}
+}
+
+// showHistory swaps the reader for the position's history, unless the
+// visitor has moved on since asking for it.
+func (m *Model) showHistory(msg historyLoadedMsg) {
+	if m.currentView != fileContentView || msg.fileName != m.selectedFileName {
+		return
+	}
+	switch {
+	case errors.Is(msg.err, gitsync.ErrNotRepository):
+		m.statusMessage = "Positions here are not kept in git, so there is no history"
+		return
+	case msg.err != nil:
+		reportError(m.hub, "could not read position history", msg.err, "file", msg.fileName)
+		m.statusMessage = "Could not read the history of this position"
+		return
+	case len(msg.commits) == 0:
+		m.statusMessage = "This position has not been committed yet"
+		return
+	}
+	m.history, m.lastChange, m.showingDiff = msg.commits, msg.lastChange, false
+	m.historyOffset = m.viewport.YOffset
+	m.currentView = historyView
+	m.viewport.SetContent(m.historyContent())
+	m.viewport.GotoTop()
+}
+
+func (m Model) historyContent() string {
+	if m.showingDiff {
+		return components.DiffView(m.theme, m.viewport.Width, m.lastChange)
+	}
+	return components.HistoryView(m.theme, m.viewport.Width, m.history, time.Now(), m.keys.Diff.Help().Key)
+}
+
+func (m Model) updateHistory(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.statusMessage = ""
+	switch {
+	case key.Matches(msg, m.keys.Quit):
+		return m, tea.Quit
+	case key.Matches(msg, m.keys.Diff):
+		m.showingDiff = !m.showingDiff
+		m.viewport.SetContent(m.historyContent())
+		m.viewport.GotoTop()
+		return m, nil
+	case key.Matches(msg, m.keys.Back), key.Matches(msg, m.keys.History):
+		if m.showingDiff && key.Matches(msg, m.keys.Back) {
+			m.showingDiff = false
+			m.viewport.SetContent(m.historyContent())
+			m.viewport.GotoTop()
+			return m, nil
+		}
+		m.closeHistory()
+		return m, nil
+	case key.Matches(msg, m.keys.Top):
+		m.viewport.GotoTop()
+		return m, nil
+	case key.Matches(msg, m.keys.Bottom):
+		m.viewport.GotoBottom()
+		return m, nil
+	case key.Matches(msg, m.keys.Help):
+		m.openHelp()
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.viewport, cmd = m.viewport.Update(msg)
+	return m, cmd
+}
+
+// closeHistory goes back to reading the position where it was left.
+func (m *Model) closeHistory() {
+	m.currentView = fileContentView
+	m.history, m.lastChange, m.showingDiff = nil, "", false
+	m.viewport.SetContent(m.renderedContent)
+	m.viewport.SetYOffset(m.historyOffset)
+}
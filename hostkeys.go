@@ -0,0 +1,48 @@
+package main
+
+import (
+	"os"
+
+	"organize/config"
+
+	"github.com/charmbracelet/keygen"
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/ssh"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// hostKey is a host key the server offers, as it was found at startup.
+type hostKey struct {
+	path      string
+	pair      *keygen.KeyPair
+	generated bool
+}
+
+// loadHostKeys reads host_key_path and every key in host_keys, generating and
+// writing the ones that don't exist yet, and logs their fingerprints so
+// visitors can check what their client is shown.
+func loadHostKeys(cfg *config.Config) ([]hostKey, error) {
+	wanted := append([]config.HostKey{{Path: cfg.HostKeyPath, Type: string(keygen.Ed25519)}}, cfg.HostKeys...)
+	keys := make([]hostKey, 0, len(wanted))
+	for _, want := range wanted {
+		_, err := os.Stat(want.Path)
+		generated := os.IsNotExist(err)
+		pair, err := keygen.New(want.Path, keygen.WithKeyType(keygen.KeyType(want.Type)), keygen.WithWrite())
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, hostKey{path: want.Path, pair: pair, generated: generated})
+		log.Info("host key", "path", want.Path, "type", pair.PublicKey().Type(), "fingerprint", gossh.FingerprintSHA256(pair.PublicKey()), "generated", generated)
+	}
+	return keys, nil
+}
+
+// withHostKeys offers every host key, leaving clients to pick the algorithm.
+func withHostKeys(keys []hostKey) ssh.Option {
+	return func(s *ssh.Server) error {
+		for _, key := range keys {
+			s.AddHostKey(key.pair.Signer())
+		}
+		return nil
+	}
+}
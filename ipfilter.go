@@ -0,0 +1,211 @@
+package main
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"organize/config"
+	"organize/proxyproto"
+
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/ssh"
+)
+
+const (
+	// quickDrop is how soon after opening a connection has to close to count
+	// towards a ban.
+	quickDrop = 10 * time.Second
+	// dropWindow is how far back drops are counted.
+	dropWindow = time.Minute
+)
+
+// ipFilter turns connections away before the SSH handshake, by the allow and
+// deny lists and by temporary bans on addresses that keep opening and dropping
+// connections.
+type ipFilter struct {
+	allow    []*net.IPNet
+	deny     []*net.IPNet
+	banAfter int
+	banFor   time.Duration
+
+	mu    sync.Mutex
+	drops map[string][]time.Time
+	bans  map[string]time.Time
+	swept time.Time
+}
+
+func newIPFilter(cfg config.IPFilter) (*ipFilter, error) {
+	f := &ipFilter{
+		banAfter: cfg.BanAfterDrops,
+		banFor:   time.Duration(cfg.BanMinutes) * time.Minute,
+		drops:    make(map[string][]time.Time),
+		bans:     make(map[string]time.Time),
+		swept:    time.Now(),
+	}
+	var err error
+	if f.allow, err = parseNetworks(cfg.Allow); err != nil {
+		return nil, err
+	}
+	if f.deny, err = parseNetworks(cfg.Deny); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func parseNetworks(networks []string) ([]*net.IPNet, error) {
+	parsed := make([]*net.IPNet, 0, len(networks))
+	for _, network := range networks {
+		n, err := config.ParseNetwork(network)
+		if err != nil {
+			return nil, err
+		}
+		parsed = append(parsed, n)
+	}
+	return parsed, nil
+}
+
+func containsIP(networks []*net.IPNet, ip net.IP) bool {
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// refuse gives the reason the address at ip may not connect, or "" if it may.
+func (f *ipFilter) refuse(ip string) string {
+	parsed := net.ParseIP(ip)
+	switch {
+	case parsed == nil:
+		return ""
+	case containsIP(f.deny, parsed):
+		return "denied"
+	case len(f.allow) > 0 && !containsIP(f.allow, parsed):
+		return "not allowed"
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if until, ok := f.bans[ip]; ok {
+		if time.Now().Before(until) {
+			return "banned"
+		}
+		delete(f.bans, ip)
+	}
+	return ""
+}
+
+// dropped notes a connection from ip that closed after lasting for lasted
+// without ever logging in, banning the address once it has dropped too many
+// too quickly.
+func (f *ipFilter) dropped(ip string, lasted time.Duration) {
+	if f.banAfter == 0 || lasted >= quickDrop {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(f.swept) > dropWindow {
+		// Forget addresses that stopped dropping and bans that ran out.
+		for addr, drops := range f.drops {
+			if now.Sub(drops[len(drops)-1]) >= dropWindow {
+				delete(f.drops, addr)
+			}
+		}
+		for addr, until := range f.bans {
+			if now.After(until) {
+				delete(f.bans, addr)
+			}
+		}
+		f.swept = now
+	}
+
+	recent := f.drops[ip][:0]
+	for _, at := range f.drops[ip] {
+		if now.Sub(at) < dropWindow {
+			recent = append(recent, at)
+		}
+	}
+	recent = append(recent, now)
+	if len(recent) < f.banAfter {
+		f.drops[ip] = recent
+		return
+	}
+	delete(f.drops, ip)
+	f.bans[ip] = now.Add(f.banFor)
+	log.Warn("banned address", "ip", ip, "drops", len(recent), "until", f.bans[ip].Format(time.RFC3339))
+}
+
+// filteredConn reports back to the filter when it closes, unless the visitor
+// logged in: "ssh host list", a quick scp or a script polling the JSON are
+// over in moments too, but they are not someone hammering the port.
+type filteredConn struct {
+	net.Conn
+	ctx    ssh.Context
+	filter *ipFilter
+	ip     string
+	opened time.Time
+	once   sync.Once
+}
+
+func (c *filteredConn) Close() error {
+	c.once.Do(func() {
+		// The server only keeps the SSH connection in the context once the
+		// handshake, login included, has succeeded.
+		if c.ctx.Value(ssh.ContextKeyConn) == nil {
+			c.filter.dropped(c.ip, time.Since(c.opened))
+		}
+	})
+	return c.Conn.Close()
+}
+
+// withIPFilter closes refused connections as they arrive, before any session
+// or middleware is set up for them.
+func withIPFilter(f *ipFilter) ssh.Option {
+	return func(s *ssh.Server) error {
+		s.ConnCallback = func(ctx ssh.Context, conn net.Conn) net.Conn {
+			ip := remoteIP(conn.RemoteAddr())
+			if reason := f.refuse(ip); reason != "" {
+				log.Warn("refused connection", "ip", ip, "reason", reason)
+				return nil
+			}
+			return &filteredConn{Conn: conn, ctx: ctx, filter: f, ip: ip, opened: time.Now()}
+		}
+		return nil
+	}
+}
+
+// proxyHeaderTimeout is how long a balancer has to send the PROXY header.
+const proxyHeaderTimeout = 5 * time.Second
+
+// withProxyProtocol reads the PROXY header off every connection before the
+// rest of the server sees it, so the IP filter, the rate limits and the logs
+// all get the visitor's address. Only the balancers in trusted may say who
+// is connecting; anyone else reaching the port directly could claim any
+// address to get past bans and limits, so they are refused. Connections on
+// the unix socket come from the same machine and are trusted. It has to come
+// after withIPFilter.
+func withProxyProtocol(trusted []*net.IPNet) ssh.Option {
+	return func(s *ssh.Server) error {
+		next := s.ConnCallback
+		s.ConnCallback = func(ctx ssh.Context, conn net.Conn) net.Conn {
+			if addr, ok := conn.RemoteAddr().(*net.TCPAddr); ok && !containsIP(trusted, addr.IP) {
+				log.Warn("refused connection from an untrusted proxy", "address", addr.String())
+				return nil
+			}
+			proxied, err := proxyproto.Read(conn, proxyHeaderTimeout)
+			if err != nil {
+				log.Warn("refused connection without a usable PROXY header", "address", conn.RemoteAddr().String(), "error", err)
+				return nil
+			}
+			if next == nil {
+				return proxied
+			}
+			return next(ctx, proxied)
+		}
+		return nil
+	}
+}
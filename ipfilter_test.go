@@ -0,0 +1,121 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"organize/config"
+
+	"github.com/charmbracelet/ssh"
+	"github.com/charmbracelet/wish"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// newFilteredServer serves a command that answers and exits, the way
+// "ssh host list" does, behind f.
+func newFilteredServer(t *testing.T, f *ipFilter) string {
+	t.Helper()
+	_, hostKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := gossh.NewSignerFromKey(hostKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, err := wish.NewServer(
+		func(s *ssh.Server) error {
+			s.AddHostKey(signer)
+			return nil
+		},
+		wish.WithPublicKeyAuth(acceptPublicKey),
+		wish.WithKeyboardInteractiveAuth(acceptKeyboardInteractive),
+		withIPFilter(f),
+		wish.WithMiddleware(func(next ssh.Handler) ssh.Handler {
+			return func(s ssh.Session) {
+				io.WriteString(s, "Apply.md\n")
+			}
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go server.Serve(listener)
+	t.Cleanup(func() { server.Close() })
+	return listener.Addr().String()
+}
+
+// runListCommand logs in to addr, runs one command and disconnects.
+func runListCommand(addr string) error {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+	signer, err := gossh.NewSignerFromKey(key)
+	if err != nil {
+		return err
+	}
+	client, err := gossh.Dial("tcp", addr, &gossh.ClientConfig{
+		User:            "visitor",
+		Auth:            []gossh.AuthMethod{gossh.PublicKeys(signer)},
+		HostKeyCallback: gossh.InsecureIgnoreHostKey(),
+	})
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	session, err := client.NewSession()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+	_, err = session.Output("list")
+	return err
+}
+
+func TestShortSessionsAreNotDrops(t *testing.T) {
+	f, err := newIPFilter(config.IPFilter{BanAfterDrops: 2, BanMinutes: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := newFilteredServer(t, f)
+
+	for i := 0; i < 5; i++ {
+		if err := runListCommand(addr); err != nil {
+			t.Fatalf("command %d: %v", i+1, err)
+		}
+	}
+	// Give the server a moment to close its side of the last one.
+	time.Sleep(100 * time.Millisecond)
+	if reason := f.refuse("127.0.0.1"); reason != "" {
+		t.Errorf("after five finished commands the address is %s", reason)
+	}
+
+	// Connections that hang up before logging in still count.
+	for i := 0; i < 2; i++ {
+		conn, err := net.Dial("tcp", addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		conn.Close()
+	}
+	waitFor(t, func() bool { return f.refuse("127.0.0.1") == "banned" })
+}
+
+// waitFor fails the test if done isn't true within a couple of seconds.
+func waitFor(t *testing.T, done func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(2 * time.Second); !done(); time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting")
+		}
+	}
+}
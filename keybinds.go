@@ -1,18 +1,61 @@
 package main
 
 import (
+	"fmt"
+	"sort"
+	"strings"
+
 	"github.com/charmbracelet/bubbles/key"
 )
 
 type keyMap struct {
-	Up    key.Binding
-	Down  key.Binding
-	Left  key.Binding
-	Right key.Binding
-	Quit  key.Binding
-	Back  key.Binding
-	Top   key.Binding
-	Enter key.Binding
+	Up           key.Binding
+	Down         key.Binding
+	Left         key.Binding
+	Right        key.Binding
+	Quit         key.Binding
+	Back         key.Binding
+	Top          key.Binding
+	Bottom       key.Binding
+	PageUp       key.Binding
+	PageDown     key.Binding
+	HalfPageUp   key.Binding
+	HalfPageDown key.Binding
+	Enter        key.Binding
+	Search       key.Binding
+	ClearSearch  key.Binding
+	GlobalSearch key.Binding
+	NextMatch    key.Binding
+	PrevMatch    key.Binding
+	CopyLinks    key.Binding
+	CopyLink     key.Binding
+	Help         key.Binding
+	ShareView    key.Binding
+	NextTag      key.Binding
+	Apply        key.Binding
+	PrevTag      key.Binding
+	Admin        key.Binding
+	Reload       key.Binding
+	Broadcast    key.Binding
+	Favorite     key.Binding
+	Theme        key.Binding
+	Background   key.Binding
+	ASCII        key.Binding
+	Contents     key.Binding
+	Raw          key.Binding
+	ApplyQR      key.Binding
+	Links        key.Binding
+	Section      key.Binding
+	Sign         key.Binding
+	Sort         key.Binding
+	History      key.Binding
+	Diff         key.Binding
+}
+
+type helpGroup struct {
+	title       string
+	description string
+	bindings    []key.Binding
 }
 
 var keys = keyMap{
@@ -25,26 +68,297 @@ var keys = keyMap{
 		key.WithHelp("↓/j", "move down"),
 	),
 	Left: key.NewBinding(
-		key.WithKeys("left", "h"),
-		key.WithHelp("←/h", "move left"),
+		key.WithKeys("["),
+		key.WithHelp("[", "previous position"),
 	),
 	Right: key.NewBinding(
-		key.WithKeys("right", "l"),
-		key.WithHelp("→/l", "move right"),
+		key.WithKeys("]", "right"),
+		key.WithHelp("]", "next position"),
 	),
 	Quit: key.NewBinding(
 		key.WithKeys("q", "ctrl+c"),
 		key.WithHelp("q", "quit"),
 	),
 	Back: key.NewBinding(
-		key.WithKeys("esc", "left"),
+		key.WithKeys("esc", "left", "backspace"),
 		key.WithHelp("←/esc", "go back"),
 	),
 	Top: key.NewBinding(
-		key.WithKeys("t"),
-		key.WithHelp("t", "go to top"),
+		key.WithKeys("g", "home"),
+		key.WithHelp("g", "go to top"),
+	),
+	Bottom: key.NewBinding(
+		key.WithKeys("G", "end"),
+		key.WithHelp("G", "go to bottom"),
+	),
+	PageUp: key.NewBinding(
+		key.WithKeys("pgup"),
+		key.WithHelp("pgup", "page up"),
+	),
+	PageDown: key.NewBinding(
+		key.WithKeys("pgdown", " "),
+		key.WithHelp("pgdn/space", "page down"),
+	),
+	HalfPageUp: key.NewBinding(
+		key.WithKeys("ctrl+u"),
+		key.WithHelp("ctrl+u", "half page up"),
+	),
+	HalfPageDown: key.NewBinding(
+		key.WithKeys("ctrl+d"),
+		key.WithHelp("ctrl+d", "half page down"),
 	),
 	Enter: key.NewBinding(
 		key.WithKeys("enter"),
+		key.WithHelp("enter", "open"),
+	),
+	Search: key.NewBinding(
+		key.WithKeys("/"),
+		key.WithHelp("/", "filter"),
+	),
+	ClearSearch: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "clear filter"),
+	),
+	NextMatch: key.NewBinding(
+		key.WithKeys("n"),
+		key.WithHelp("n", "next match"),
+	),
+	PrevMatch: key.NewBinding(
+		key.WithKeys("N"),
+		key.WithHelp("N", "previous match"),
+	),
+	GlobalSearch: key.NewBinding(
+		key.WithKeys("ctrl+f"),
+		key.WithHelp("ctrl+f", "search"),
+	),
+	CopyLinks: key.NewBinding(
+		key.WithKeys("c"),
+		key.WithHelp("c", "copy apply links"),
+	),
+	CopyLink: key.NewBinding(
+		key.WithKeys("y"),
+		key.WithHelp("y", "copy link"),
+	),
+	ShareView: key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "copy view as text"),
+	),
+	NextTag: key.NewBinding(
+		key.WithKeys("tab"),
+		key.WithHelp("tab", "next category"),
+	),
+	PrevTag: key.NewBinding(
+		key.WithKeys("shift+tab"),
+		key.WithHelp("shift+tab", "previous category"),
+	),
+	Apply: key.NewBinding(
+		key.WithKeys("a"),
+		key.WithHelp("a", "apply"),
+	),
+	Favorite: key.NewBinding(
+		key.WithKeys("b"),
+		key.WithHelp("b", "star"),
+	),
+	Admin: key.NewBinding(
+		key.WithKeys("A"),
+		key.WithHelp("A", "admin"),
+	),
+	Reload: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "reload content"),
 	),
+	Broadcast: key.NewBinding(
+		key.WithKeys("m"),
+		key.WithHelp("m", "announce"),
+	),
+	Theme: key.NewBinding(
+		key.WithKeys("t"),
+		key.WithHelp("t", "next theme"),
+	),
+	Background: key.NewBinding(
+		key.WithKeys("T"),
+		key.WithHelp("T", "light/dark colours"),
+	),
+	ASCII: key.NewBinding(
+		key.WithKeys("u"),
+		key.WithHelp("u", "plain ASCII"),
+	),
+	Contents: key.NewBinding(
+		key.WithKeys("o"),
+		key.WithHelp("o", "contents"),
+	),
+	Raw: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "markdown source"),
+	),
+	ApplyQR: key.NewBinding(
+		key.WithKeys("Q"),
+		key.WithHelp("Q", "QR code to apply"),
+	),
+	Links: key.NewBinding(
+		key.WithKeys("L"),
+		key.WithHelp("L", "links"),
+	),
+	Section: key.NewBinding(
+		key.WithKeys("1", "2", "3", "4", "5", "6", "7", "8", "9"),
+		key.WithHelp("1-9", "switch tab"),
+	),
+	Sign: key.NewBinding(
+		key.WithKeys("m"),
+		key.WithHelp("m", "sign the guestbook"),
+	),
+	Sort: key.NewBinding(
+		key.WithKeys("s"),
+		key.WithHelp("s", "sort"),
+	),
+	History: key.NewBinding(
+		key.WithKeys("h"),
+		key.WithHelp("h", "history"),
+	),
+	Diff: key.NewBinding(
+		key.WithKeys("d"),
+		key.WithHelp("d", "latest change"),
+	),
+	Help: key.NewBinding(
+		key.WithKeys("?"),
+		key.WithHelp("?", "all keybindings"),
+	),
+}
+
+// bindings are the keybindings of k by the names the config file remaps them
+// under.
+func (k *keyMap) bindings() map[string]*key.Binding {
+	return map[string]*key.Binding{
+		"up":             &k.Up,
+		"down":           &k.Down,
+		"left":           &k.Left,
+		"right":          &k.Right,
+		"quit":           &k.Quit,
+		"back":           &k.Back,
+		"top":            &k.Top,
+		"bottom":         &k.Bottom,
+		"page_up":        &k.PageUp,
+		"page_down":      &k.PageDown,
+		"half_page_up":   &k.HalfPageUp,
+		"half_page_down": &k.HalfPageDown,
+		"enter":          &k.Enter,
+		"search":         &k.Search,
+		"clear_search":   &k.ClearSearch,
+		"global_search":  &k.GlobalSearch,
+		"next_match":     &k.NextMatch,
+		"prev_match":     &k.PrevMatch,
+		"copy_links":     &k.CopyLinks,
+		"copy_link":      &k.CopyLink,
+		"help":           &k.Help,
+		"share_view":     &k.ShareView,
+		"next_tag":       &k.NextTag,
+		"apply":          &k.Apply,
+		"prev_tag":       &k.PrevTag,
+		"admin":          &k.Admin,
+		"reload":         &k.Reload,
+		"broadcast":      &k.Broadcast,
+		"favorite":       &k.Favorite,
+		"theme":          &k.Theme,
+		"background":     &k.Background,
+		"ascii":          &k.ASCII,
+		"contents":       &k.Contents,
+		"raw":            &k.Raw,
+		"apply_qr":       &k.ApplyQR,
+		"links":          &k.Links,
+		"section":        &k.Section,
+		"sign":           &k.Sign,
+		"sort":           &k.Sort,
+		"history":        &k.History,
+		"diff":           &k.Diff,
+	}
+}
+
+// remap is k with the keys of the actions in overrides replaced, the help
+// showing the new keys.
+func (k keyMap) remap(overrides map[string][]string) (keyMap, error) {
+	names := make([]string, 0, len(overrides))
+	for name := range overrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	bindings := k.bindings()
+	for _, name := range names {
+		binding, ok := bindings[name]
+		if !ok {
+			return k, fmt.Errorf("keys.%s: no such action", name)
+		}
+		keys := overrides[name]
+		if len(keys) == 0 {
+			return k, fmt.Errorf("keys.%s: no keys given", name)
+		}
+		binding.SetKeys(keys...)
+		binding.SetHelp(strings.Join(keys, "/"), binding.Help().Desc)
+	}
+	return k, nil
+}
+
+func (k keyMap) helpGroups() []helpGroup {
+	return []helpGroup{
+		{
+			title:       "Positions list",
+			description: "Browse the open positions and pick one to read, or open a folder to see what is inside. The mouse works too: scroll to move and click a card twice to open it. Tab through the categories to only see positions tagged with one, or the ones you starred, and s sorts them A-Z, newest first, by deadline or by how often they were viewed. Lists too long for the screen are split into pages, with dots under them showing which one you are on.",
+			bindings:    []key.Binding{k.Up, k.Down, k.PageUp, k.PageDown, k.Enter, k.Back, k.NextTag, k.PrevTag, k.Sort, k.Favorite, k.Search, k.GlobalSearch, k.CopyLinks, k.ShareView},
+		},
+		{
+			title:       "Filtering the list",
+			description: "Type after / to narrow the list to positions whose title or description fuzzy-matches, press enter to keep the filter and browse the matches.",
+			bindings:    []key.Binding{k.Enter, k.ClearSearch},
+		},
+		{
+			title:       "Reading a position",
+			description: "Scroll through the selected position, apply for it right here, then head back to the list. r shows the markdown it was rendered from, to copy from or when it renders badly, and y copies a link to the position, or that markdown while it is shown. Positions with their own application link show it as a QR code to scan with your phone. The application form moves on with enter and cancels with esc.",
+			bindings:    []key.Binding{k.Up, k.Down, k.HalfPageUp, k.HalfPageDown, k.PageUp, k.PageDown, k.Top, k.Bottom, k.Left, k.Right, k.Raw, k.CopyLink, k.Apply, k.ApplyQR, k.History, k.Favorite, k.ShareView, k.Back},
+		},
+		{
+			title:       "History",
+			description: "When the positions are kept in git, see who changed the open position, when and why, and what the latest change did to it.",
+			bindings:    []key.Binding{k.History, k.Diff, k.Up, k.Down, k.Back},
+		},
+		{
+			title:       "Finding text in a position",
+			description: "Type after / to highlight every line containing your text, then hop between the matches like in less.",
+			bindings:    []key.Binding{k.Search, k.NextMatch, k.PrevMatch, k.ClearSearch},
+		},
+		{
+			title:       "Table of contents",
+			description: "Open the headings of a long position next to it, or over it on narrow terminals, and jump to a section with enter. The section you are reading is marked.",
+			bindings:    []key.Binding{k.Contents, k.Up, k.Down, k.Enter, k.Back},
+		},
+		{
+			title:       "Search",
+			description: "Type to rank every position by how well it matches, pick a result with the arrow keys and open it with enter.",
+			bindings:    []key.Binding{k.Enter, k.Back},
+		},
+		{
+			title:       "Links",
+			description: "Every place to find the club, on the last tab or straight from a position. Pick one to see its QR code and scan it with your phone, or copy the link with enter.",
+			bindings:    []key.Binding{k.Links, k.Up, k.Down, k.NextTag, k.Enter, k.Back},
+		},
+		{
+			title:       "Guestbook",
+			description: "Leave the club and everyone after you a short message, signed with your SSH key. Enter signs and esc thinks better of it; one message every ten minutes is plenty.",
+			bindings:    []key.Binding{k.Sign, k.Enter, k.Back},
+		},
+		{
+			title:       "Feedback",
+			description: "Send the organisers a suggestion, an event idea, a bug report or a question from the Feedback tab. Enter starts writing and moves on through the form, esc cancels.",
+			bindings:    []key.Binding{k.Enter, k.Back},
+		},
+		{
+			title:       "Admin",
+			description: "Only for the keys listed in admin_keys. See who is around, how each position is doing and the latest applications, reload the content or put up an announcement above every screen.",
+			bindings:    []key.Binding{k.Admin, k.Reload, k.Broadcast, k.Back},
+		},
+		{
+			title:       "Everywhere",
+			description: "Available from any screen. The number keys switch between the tabs along the top: positions, archive, events, team, guestbook, feedback, about and links. Colours follow your terminal's light or dark background, and terminals that can't draw boxes or emoji get plain ASCII; the theme, background and characters you pick are remembered for your SSH key.",
+			bindings:    []key.Binding{k.Section, k.Theme, k.Background, k.ASCII, k.Help, k.Quit},
+		},
+	}
 }
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"organize/config"
+	"organize/preferences"
+	"organize/utils"
+	"organize/views"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// newTestModel is a session on the positions in directory/, set up the way
+// teaHandler sets one up, on a terminal of the given size.
+func newTestModel(t *testing.T, width int, height int) Model {
+	t.Helper()
+	cfg := config.Default()
+	cfg.Directory = "directory"
+	cfg.LogoPath = "jodc_logo.jpeg"
+	cfg.ReduceMotion = true
+
+	cache := newContentCache()
+	meta, index, err := cache.positions(cfg.Directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	counter, err := views.NewCounter(views.NewFileStore(filepath.Join(t.TempDir(), "views.json")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { counter.Close() })
+
+	m := Model{
+		fileNames:        meta.FileNames,
+		fileTitles:       meta.FileTitles,
+		fileDescriptions: meta.FileDescriptions,
+		fileMetadata:     meta.FileMetadata,
+		terminalHeight:   height,
+		help:             help.New(),
+		keys:             keys,
+		searchIndex:      index,
+		searchInput:      textinput.New(),
+		filterInput:      textinput.New(),
+		readerInput:      textinput.New(),
+		adminInput:       textinput.New(),
+		config:           &cfg,
+		views:            counter,
+		sessions:         newSessionRegistry(),
+		preferenceStore:  preferences.NewFileStore(filepath.Join(t.TempDir(), "preferences.json")),
+		renderers:        newRendererCache(nil),
+		viewed:           make(map[string]bool),
+		offsets:          make(map[string]int),
+		ctx:              context.Background(),
+		sections:         newSections(),
+		pager:            newPager(keys),
+		cache:            cache,
+	}
+	m.theme = pickTheme(&cfg, m.preferences, terminal{})
+	if err := m.renderBranding(); err != nil {
+		t.Fatal(err)
+	}
+	return send(t, m, tea.WindowSizeMsg{Width: width, Height: height})
+}
+
+// send updates m with msg and then with whatever its commands return, the
+// way the program would, leaving out timers so the test doesn't wait.
+func send(t *testing.T, m Model, msg tea.Msg) Model {
+	t.Helper()
+	pending := []tea.Msg{msg}
+	for len(pending) > 0 {
+		next, cmd := m.Update(pending[0])
+		m, pending = next.(Model), pending[1:]
+		pending = append(pending, run(cmd)...)
+	}
+	return m
+}
+
+func run(cmd tea.Cmd) []tea.Msg {
+	if cmd == nil {
+		return nil
+	}
+	done := make(chan tea.Msg, 1)
+	go func() { done <- cmd() }()
+	select {
+	case msg := <-done:
+		if batch, ok := msg.(tea.BatchMsg); ok {
+			var msgs []tea.Msg
+			for _, cmd := range batch {
+				msgs = append(msgs, run(cmd)...)
+			}
+			return msgs
+		}
+		if msg == nil {
+			return nil
+		}
+		return []tea.Msg{msg}
+	case <-time.After(100 * time.Millisecond):
+		return nil
+	}
+}
+
+// checkWidth fails for every line of view wider than the terminal.
+func checkWidth(t *testing.T, view string, width int) {
+	t.Helper()
+	for i, line := range strings.Split(view, "\n") {
+		if w := lipgloss.Width(line); w > width {
+			t.Errorf("line %d is %d wide, more than %d: %q", i+1, w, width, utils.StripANSI(line))
+		}
+	}
+}
+
+func TestNarrowTerminalsDoNotOverflow(t *testing.T) {
+	for _, width := range []int{20, 40, 60} {
+		m := newTestModel(t, width, 30)
+		t.Run(fmt.Sprintf("list at %d", width), func(t *testing.T) {
+			checkWidth(t, m.View(), width)
+		})
+		t.Run(fmt.Sprintf("reader at %d", width), func(t *testing.T) {
+			reader := send(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+			if reader.currentView != fileContentView {
+				t.Fatalf("enter did not open a position")
+			}
+			checkWidth(t, reader.View(), width)
+		})
+	}
+}
+
+func TestLongTitleFitsHeader(t *testing.T) {
+	title := strings.Repeat("A very long position title ", 10)
+	for _, width := range []int{20, 40, 80} {
+		m := newTestModel(t, width, 30)
+		m.fileTitles = append([]string(nil), m.fileTitles...)
+		m.fileTitles[m.cursor] = title
+		m = send(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+
+		header := m.HeaderView()
+		if lines := strings.Count(header, "\n") + 1; lines != 3 {
+			t.Errorf("at %d the header takes %d lines, want the 3 of its box", width, lines)
+		}
+		checkWidth(t, header, width)
+		if !strings.Contains(utils.StripANSI(header), "…") {
+			t.Errorf("at %d the title is not cut short with an ellipsis: %q", width, utils.StripANSI(header))
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"organize/components"
+	"organize/config"
+	"organize/utils"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/log"
+)
+
+// clubLinks are the links on the links page, the Discord invite first.
+func clubLinks(cfg *config.Config) []config.Link {
+	return append([]config.Link{{Name: "Discord", URL: cfg.DiscordURL}}, cfg.Links...)
+}
+
+// linksSection lists every place to find the club with a QR code for the
+// selected one.
+type linksSection struct {
+	viewport viewport.Model
+	cursor   int
+	codes    []string
+}
+
+func (s *linksSection) title() string { return "Links" }
+
+func (s *linksSection) resize(m *Model, width int, height int) {
+	if s.viewport.Width == 0 {
+		s.viewport = newSectionViewport(m.keys)
+	}
+	s.viewport.Width, s.viewport.Height = width, height
+
+	// The codes are drawn for the session's theme and characters.
+	links := clubLinks(m.config)
+	s.codes = make([]string, len(links))
+	for i, link := range links {
+		code, err := m.cache.qrCode(link.URL, m.qrOptions())
+		if err != nil {
+			log.Warn("could not render link qr code", "link", link.Name, "error", err)
+			continue
+		}
+		s.codes[i] = code
+	}
+	s.viewport.SetContent(s.content(*m))
+}
+
+func (s *linksSection) update(m *Model, msg tea.KeyMsg) tea.Cmd {
+	links := clubLinks(m.config)
+	switch {
+	case key.Matches(msg, m.keys.Up), key.Matches(msg, m.keys.PrevTag):
+		s.cursor = (s.cursor + len(links) - 1) % len(links)
+	case key.Matches(msg, m.keys.Down), key.Matches(msg, m.keys.NextTag):
+		s.cursor = (s.cursor + 1) % len(links)
+	case key.Matches(msg, m.keys.Enter):
+		link := links[s.cursor]
+		if err := utils.CopyToClipboard(m.clipboard, m.term, link.URL); err != nil {
+			m.statusMessage = "Could not copy the link"
+		} else {
+			m.statusMessage = "Copied the " + link.Name + " link to your clipboard"
+		}
+	default:
+		var cmd tea.Cmd
+		s.viewport, cmd = s.viewport.Update(msg)
+		return cmd
+	}
+	s.viewport.SetContent(s.content(*m))
+	return nil
+}
+
+func (s *linksSection) view(m Model) string {
+	return s.viewport.View()
+}
+
+func (s *linksSection) content(m Model) string {
+	links := clubLinks(m.config)
+	names := make([]string, len(links))
+	for i, link := range links {
+		names[i] = link.Name
+	}
+	return components.LinksView(m.theme, s.viewport.Width, names, links[s.cursor].URL, s.codes[s.cursor], s.cursor)
+}
+
+// openLinks switches to the links tab.
+func (m *Model) openLinks() {
+	for i, s := range m.sections {
+		if _, ok := s.(*linksSection); ok {
+			m.switchTab(i + 1)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"image"
+	"strings"
+	"time"
+
+	"organize/components"
+	"organize/utils"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// fileLoad is a file being opened in the reader, and where to put the reader
+// once it is.
+type fileLoad struct {
+	fileName string
+	// seq tells this load apart from ones that were overtaken.
+	seq int
+	// offset is the line to scroll to, or -1 to go back to where the visitor
+	// left the file.
+	offset int
+	// query and line jump to a search match instead, when query is set.
+	query string
+	line  int
+}
+
+// contentLoadedMsg is a file read and rendered off the Update loop.
+type contentLoadedMsg struct {
+	load     fileLoad
+	file     cachedFile
+	image    image.Image
+	rendered string
+	err      error
+	// renderErr is set when the file was read but could not be rendered.
+	renderErr error
+}
+
+// newLoadingSpinner spins in the theme's accent, with plain characters for
+// terminals that only do ASCII.
+func newLoadingSpinner(theme components.Theme) spinner.Model {
+	frames := spinner.Dot
+	if theme.ASCII {
+		frames = spinner.Line
+	}
+	return spinner.New(spinner.WithSpinner(frames), spinner.WithStyle(lipgloss.NewStyle().Foreground(theme.Accent)))
+}
+
+// loadFile switches to the reader and reads and renders the file in the
+// background, showing a spinner until it is done.
+func (m *Model) loadFile(load fileLoad) tea.Cmd {
+	if m.currentView == fileContentView {
+		m.rememberScroll()
+	}
+	// Views count sessions, so opening a position again is not a new view,
+	// and nothing is counted with tracking off.
+	if m.config.Tracking && !m.viewed[load.fileName] {
+		m.viewed[load.fileName] = true
+		m.views.Record(load.fileName)
+	}
+	m.record.viewedFile(load.fileName)
+
+	m.loads++
+	load.seq = m.loads
+	m.loading = true
+	m.spinner = newLoadingSpinner(m.theme)
+	m.contents = nil
+	m.contentsOpen = false
+	m.applyQROpen = false
+	m.readerMatches = nil
+	m.readerInput.SetValue("")
+	m.currentView = fileContentView
+	m.viewport.SetContent(m.loadingView())
+	m.viewport.GotoTop()
+
+	// The copy renders for the session as it is now; the Model itself only
+	// changes once the result is back.
+	render := *m
+	return tea.Batch(m.spinner.Tick, func() tea.Msg {
+		msg := contentLoadedMsg{load: load}
+		ctx, span := tracer.Start(render.ctx, "content.load", trace.WithAttributes(attribute.String("position", load.fileName)))
+		defer func() { endSpan(span, msg.err) }()
+		msg.file, msg.err = render.cache.read(render.config.Directory, load.fileName)
+		if msg.err == nil && utils.IsImage(load.fileName) {
+			msg.image, _, msg.err = image.Decode(strings.NewReader(msg.file.raw))
+		}
+		if msg.err != nil {
+			return msg
+		}
+		render.openImage, render.selectedFileName, render.fileModTime = msg.image, load.fileName, msg.file.modTime
+		_, rendering := tracer.Start(ctx, "content.render")
+		msg.rendered, msg.renderErr = render.renderFile(msg.file.body)
+		endSpan(rendering, msg.renderErr)
+		return msg
+	})
+}
+
+// showLoaded puts a loaded file in the reader, unless the visitor has moved on
+// since asking for it.
+func (m *Model) showLoaded(msg contentLoadedMsg) {
+	if !m.loading || msg.load.seq != m.loads {
+		return
+	}
+	m.loading = false
+	if m.currentView != fileContentView {
+		return
+	}
+
+	m.openImage = msg.image
+	if msg.err != nil {
+		m.fileContent = "Error reading file"
+		m.fileModTime = time.Time{}
+	} else {
+		m.fileContent = msg.file.body
+		m.fileModTime = msg.file.modTime
+		m.selectedFileName = msg.load.fileName
+		m.rememberRead(msg.load.fileName)
+	}
+	m.renderedContent = msg.rendered
+	if msg.err != nil {
+		m.renderedContent = m.fileContent
+	} else if msg.renderErr != nil {
+		reportError(m.hub, "could not render position", msg.renderErr, "file", msg.load.fileName)
+		m.renderedContent = "Error parsing markdown"
+	}
+	m.buildContents()
+	m.viewport.SetContent(m.renderedContent)
+
+	switch {
+	case msg.load.query != "":
+		m.viewport.GotoTop()
+		m.jumpToMatch(msg.load.query, msg.load.line)
+	case msg.load.offset >= 0:
+		m.viewport.SetYOffset(msg.load.offset)
+	default:
+		m.restoreScroll()
+	}
+}
+
+// loadingView is the spinner the reader shows while a file loads.
+func (m Model) loadingView() string {
+	return "\n  " + m.spinner.View() + " Loading..."
+}
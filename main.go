@@ -1,171 +1,1014 @@
 package main
 
 import (
-	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"image"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
 	"os"
-	"os/exec"
 	"os/signal"
+	"sort"
 	"strings"
+	"sync/atomic"
 	"syscall"
 	"time"
 
+	"organize/accesslog"
+	"organize/applications"
 	"organize/components"
+	"organize/config"
+	"organize/feedback"
+	"organize/gitsync"
+	"organize/guestbook"
+	"organize/notify"
+	"organize/preferences"
+	"organize/qr"
+	"organize/search"
+	"organize/storage"
+	"organize/termimage"
 	"organize/utils"
+	"organize/views"
+	"organize/watcher"
 
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/paginator"
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/glamour/ansi"
+	"github.com/charmbracelet/huh"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/log"
 	"github.com/charmbracelet/ssh"
 	"github.com/charmbracelet/wish"
 	bm "github.com/charmbracelet/wish/bubbletea"
-	lm "github.com/charmbracelet/wish/logging"
+	"github.com/charmbracelet/wish/scp"
+	"github.com/getsentry/sentry-go"
+	"github.com/muesli/reflow/wordwrap"
+	"github.com/muesli/reflow/wrap"
+	"github.com/muesli/termenv"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type viewState int
 
-const (
-	host = "0.0.0.0"
-	port = 23234
-)
-
 const (
 	fileListView viewState = iota
 	fileContentView
+	searchView
+	helpView
+	applyView
+	adminView
+	historyView
 )
 
+const maxSearchResults = 10
+
+// favoritesCategory is the last pill of the category bar for visitors who
+// starred something.
+const favoritesCategory = "★ favorites"
+
+// expiringWithin is how close a deadline has to be for its position to move
+// to the top of the list.
+const expiringWithin = 7 * 24 * time.Hour
+
+type filterMatch struct {
+	index              int
+	score              int
+	titleMatches       []int
+	descriptionMatches []int
+}
+
+type contentReloadedMsg struct {
+	positionMeta *utils.PositionMeta
+	searchIndex  *search.Index
+}
+
+// browsingMsg carries how many sessions are open right now.
+type browsingMsg int
+
+const browsingInterval = 5 * time.Second
+
+func countBrowsing(sessions *sessionRegistry) tea.Msg {
+	return browsingMsg(sessions.count())
+}
+
+// rewrapMsg carries which resize it was sent after; only the latest one
+// re-renders.
+type rewrapMsg int
+
+const rewrapDelay = 150 * time.Millisecond
+
+// noticeMsg is shown to a visitor until their session ends.
+type noticeMsg string
+
 type Model struct {
 	cursor           int
 	ready            bool
 	viewport         viewport.Model
 	fileNames        []string
+	fileTitles       []string
 	fileDescriptions []string
+	fileMetadata     []utils.Frontmatter
 	currentView      viewState
 	selectedFileName string
 	fileContent      string
+	openImage        image.Image
 	terminalHeight   int
 	help             help.Model
 	keys             keyMap
-	catimgOutput     string
+	logoOutput       string
+	graphics         *logoGraphics
+	qrOutput         string
+	searchIndex      *search.Index
+	searchInput      textinput.Model
+	searchResults    []search.Result
+	searchCursor     int
+	filterInput      textinput.Model
+	filtering        bool
+	filterMatches    []filterMatch
+	activeTag        string
+	currentDir       string
+	applications     applications.Store
+	repository       storage.Repository
+	notifiers        []notify.Notifier
+	views            *views.Counter
+	sessions         *sessionRegistry
+	browsing         int
+	admin            bool
+	adminInput       textinput.Model
+	broadcasting     bool
+	reload           func()
+	announcer        *announcer
+	announcement     announcementMsg
+	fingerprint      string
+	record           *sessionRecord
+	hub              *sentry.Hub
+	ctx              context.Context
+	preferences      preferences.Preferences
+	preferenceStore  preferences.Store
+	theme            components.Theme
+	renderers        *rendererCache
+	resizes          int
+	lastVisit        time.Time
+	viewed           map[string]bool
+	offsets          map[string]int
+	application      *applications.Application
+	applyForm        *huh.Form
+	user             string
+	readerInput      textinput.Model
+	readerSearching  bool
+	readerMatches    []int
+	readerMatch      int
+	config           *config.Config
+	clipboard        io.Writer
+	term             string
+	statusMessage    string
+	notice           string
+	renderedContent  string
+	contents         []heading
+	contentsOpen     bool
+	contentsCursor   int
+	applyQR          string
+	applyQROpen      bool
+	tab              int
+	previousTab      int
+	sections         []section
+	splashing        bool
+	splashFrame      int
+	raw              bool
+	previousView     viewState
+	previousOffset   int
+	guestbook        guestbook.Store
+	guestbookLimit   *connectionLimiter
+	feedback         feedback.Store
+	feedbackHook     *notify.Discord
+	sortOrder        sortOrder
+	pager            paginator.Model
+	cache            *contentCache
+	fileModTime      time.Time
+	loading          bool
+	loads            int
+	spinner          spinner.Model
+	history          []gitsync.Commit
+	lastChange       string
+	showingDiff      bool
+	historyOffset    int
 }
 
 func (k keyMap) ShortHelp() []key.Binding {
-	return []key.Binding{k.Up, k.Down, k.Quit, k.Back}
+	return []key.Binding{k.Up, k.Down, k.Search, k.GlobalSearch, k.Help, k.Admin, k.Quit, k.Back}
 }
 
 func (k keyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
-		{k.Up, k.Down, k.Left, k.Right, k.Quit, k.Back},
+		{k.Up, k.Down, k.HalfPageUp, k.HalfPageDown, k.PageUp, k.PageDown, k.Top, k.Bottom, k.Left, k.Right, k.NextTag, k.Search, k.ClearSearch, k.GlobalSearch, k.CopyLinks, k.ShareView, k.Help, k.Quit, k.Back},
+	}
+}
+
+// The logo is logoHeight lines tall, after logoPadding blank columns.
+const (
+	logoHeight  = 15
+	logoPadding = 2
+)
+
+func renderLogo(imagePath string, height, padding int, mode termimage.Mode) (string, error) {
+	logo, err := termimage.RenderFile(imagePath, height, mode)
+	if err != nil {
+		return "", err
+	}
+
+	lines := strings.Split(logo, "\n")
+	for i, line := range lines {
+		lines[i] = strings.Repeat(" ", padding) + line
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// renderBranding draws the logo and the Discord QR code for the session's
+// terminal, in plain characters when it only does ASCII.
+func (m *Model) renderBranding() error {
+	mode := termimage.ModeFor(m.theme.Profile)
+	if m.theme.ASCII {
+		mode = termimage.ASCII
+	}
+	logoOutput, err := m.cache.logo(m.config.LogoPath, mode)
+	if err != nil {
+		return fmt.Errorf("failed to render logo: %w", err)
+	}
+	qrOutput, err := m.cache.qrCode(m.config.DiscordURL, m.qrOptions())
+	if err != nil {
+		return fmt.Errorf("failed to render qr code: %w", err)
+	}
+	m.logoOutput, m.qrOutput = logoOutput, qrOutput
+	return nil
+}
+
+// qrOptions draws QR codes for the session's terminal.
+func (m Model) qrOptions() qr.Options {
+	return qr.Options{
+		ModuleSize: m.config.QR.ModuleSize,
+		QuietZone:  m.config.QR.QuietZone,
+		ASCII:      m.theme.ASCII,
+		// Reverse video, which the colours turn into without any, only
+		// keeps light modules light on a light background.
+		Plain: m.theme.Profile == termenv.Ascii && !m.theme.Light,
 	}
 }
 
-func runCatimg(imagePath string, height, padding int) (string, error) {
-    cmd := exec.Command("cat", imagePath)
-    var out bytes.Buffer
-    cmd.Stdout = &out
-    err := cmd.Run()
-    if err != nil {
-        return "", err
-    }
+func reconnectCommand(cfg *config.Config) string {
+	return fmt.Sprintf("ssh %s -p %d", cfg.PublicHost, cfg.PublicPort)
+}
+
+// shareLink is where someone else can read a position: the web mirror when
+// public_url is set, otherwise over SSH.
+func shareLink(cfg *config.Config, fileName string) string {
+	if cfg.PublicURL != "" {
+		return strings.TrimSuffix(cfg.PublicURL, "/") + "/positions/" + (&url.URL{Path: fileName}).EscapedPath()
+	}
+	return fmt.Sprintf("ssh -t %s -p %d cat %s", cfg.PublicHost, cfg.PublicPort, fileName)
+}
 
-    // Split the output into lines
-    lines := strings.Split(out.String(), "\n")
+// defaultTheme is the theme for visitors who have not picked one.
+func defaultTheme(cfg *config.Config) components.Theme {
+	if theme, ok := components.ThemeByName(cfg.Theme); ok {
+		return theme
+	}
+	return components.JODCTheme
+}
 
-    // Add padding to the left of each line
-    paddedLines := make([]string, len(lines))
-    for i, line := range lines {
-        paddedLines[i] = strings.Repeat(" ", padding) + line
-    }
+// pickTheme is the visitor's own theme, or the configured one, drawn for
+// their terminal as detected unless they chose otherwise.
+func pickTheme(cfg *config.Config, prefs preferences.Preferences, term terminal) components.Theme {
+	theme := defaultTheme(cfg)
+	if picked, ok := components.ThemeByName(prefs.Theme); ok {
+		theme = picked
+	}
+	switch prefs.Background {
+	case preferences.LightBackground:
+		term.light = true
+	case preferences.DarkBackground:
+		term.light = false
+	}
+	switch prefs.Charset {
+	case preferences.ASCIICharset:
+		term.ascii = true
+	case preferences.UnicodeCharset:
+		term.ascii = false
+	}
+	theme.ASCII = term.ascii
+	theme.Profile = term.profile
+	return theme.ForBackground(term.light)
+}
 
-    // Join the padded lines back into a single string
-    paddedOutput := strings.Join(paddedLines, "\n")
+// sessionTheme is the theme the visitor on s last saw.
+func sessionTheme(cfg *config.Config, store preferences.Store, s ssh.Session) components.Theme {
+	term, _ := s.Context().Value(terminalKey{}).(terminal)
+	var prefs preferences.Preferences
+	if fingerprint := keyFingerprint(sessionKey(s)); fingerprint != "" {
+		prefs, _ = store.Preferences(fingerprint)
+	}
+	return pickTheme(cfg, prefs, term)
+}
 
-    return paddedOutput, nil
+// sessionCleanup runs once the bubbletea program has exited and the alt
+// screen is gone, so anything written here stays on the visitor's terminal.
+// A session whose program panicked gets errorReporting's apology instead.
+func sessionCleanup(live *liveConfig, store preferences.Store) wish.Middleware {
+	return func(next ssh.Handler) ssh.Handler {
+		return func(s ssh.Session) {
+			cfg := live.load()
+			crashed, _ := s.Context().Value(crashKey{}).(*atomic.Bool)
+			if _, _, active := s.Pty(); active && (crashed == nil || !crashed.Load()) {
+				theme := sessionTheme(cfg, store, s)
+				farewell := components.FarewellView(theme, reconnectCommand(cfg), cfg.DiscordURL)
+				if theme.ASCII {
+					farewell = utils.ToASCII(farewell)
+				}
+				wish.Println(s, utils.DowngradeColors(farewell, theme.Profile))
+			}
+			next(s)
+		}
+	}
 }
 
+func selfCheck(cfg *config.Config, renderers *rendererCache, hostKeyExisted bool) []interface{} {
+	warnings := 0
+
+	positions := 0
+	positionMeta, err := utils.GetPositionMeta(cfg.Directory)
+	switch {
+	case err != nil:
+		log.Warn("content directory is not readable", "error", err)
+		warnings++
+	case positionMeta.PositionCount() == 0:
+		log.Warn("content directory is empty")
+		warnings++
+	default:
+		positions = positionMeta.PositionCount()
+	}
+
+	hostKey := "loaded"
+	if !hostKeyExisted {
+		hostKey = "generated"
+	}
+	if _, err := os.Stat(cfg.HostKeyPath); err != nil {
+		log.Warn("host key is missing", "path", cfg.HostKeyPath, "error", err)
+		hostKey = "missing"
+		warnings++
+	}
+
+	logo := true
+	if _, err := termimage.Load(cfg.LogoPath); err != nil {
+		log.Warn("logo cannot be decoded", "path", cfg.LogoPath, "error", err)
+		logo = false
+		warnings++
+	}
+
+	markdown := true
+	if _, err := renderers.render(defaultTheme(cfg), defaultWrapWidth, ""); err != nil {
+		log.Warn("markdown renderer failed to initialize", "error", err)
+		markdown = false
+		warnings++
+	}
+
+	if cfg.BannerFile != "" {
+		if _, err := os.Stat(cfg.BannerFile); err != nil {
+			log.Warn("banner file is not readable", "path", cfg.BannerFile, "error", err)
+			warnings++
+		}
+	}
 
+	return []interface{}{
+		"positions", positions,
+		"host_key", hostKey,
+		"logo", logo,
+		"markdown", markdown,
+		"warnings", warnings,
+	}
+}
 
 func main() {
-	sshFolderPath := os.Getenv("SSH_FOLDER_PATH")
-	if sshFolderPath == "" {
-		sshFolderPath = ".ssh"
+	if err := runCommand(os.Args[1:]); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func reloadContent(cfg *config.Config, sessions *sessionRegistry, cache *contentCache, bot *discordBot) {
+	cache.clear()
+	positionMeta, err := utils.GetPositionMeta(cfg.Directory)
+	if err != nil {
+		log.Error("could not reload content", "error", err)
+		return
+	}
+	searchIndex, err := search.Build(cfg.Directory, positionMeta)
+	if err != nil {
+		log.Error("could not reindex content", "error", err)
+		return
+	}
+	cache.setPositions(positionMeta, searchIndex)
+	log.Info("content reloaded", "positions", positionMeta.PositionCount())
+	sessions.broadcast(contentReloadedMsg{positionMeta: positionMeta, searchIndex: searchIndex})
+	bot.contentChanged(positionMeta)
+}
+
+// services are the long-lived backends shared by every session.
+type services struct {
+	applications applications.Store
+	repository   storage.Repository
+	notifiers    []notify.Notifier
+	views        *views.Counter
+	sessions     *sessionRegistry
+	admins       []ssh.PublicKey
+	reload       func()
+	announcer    *announcer
+	preferences  preferences.Store
+	renderers    *rendererCache
+	content      *contentCache
+	keys         keyMap
+	guestbook    guestbook.Store
+	guestLimiter *connectionLimiter
+	feedback     feedback.Store
+	// feedbackHook forwards feedback to Discord, when that is wanted.
+	feedbackHook *notify.Discord
+	config       *liveConfig
+}
+
+func programHandler(svc *services) bm.ProgramHandler {
+	handler := teaHandler(svc)
+	return func(s ssh.Session) *tea.Program {
+		cfg := svc.config.load()
+		connectedAt := time.Now()
+		m, opts := handler(s)
+		if m == nil {
+			return nil
+		}
+		// The server handles its own signals; left to bubbletea, every session
+		// would quit on SIGTERM before visitors could be warned. Panics are
+		// reported by recoveringModel, and errorReporting says goodbye,
+		// rather than printed to the server's terminal.
+		opts = append(opts, tea.WithoutSignalHandler(), tea.WithoutCatchPanics())
+		p := tea.NewProgram(newRecoveringModel(s, m), opts...)
+
+		info := sessionInfo{User: s.User(), Address: s.RemoteAddr().String(), ConnectedAt: connectedAt, record: sessionRecordFrom(s)}
+		if pty, _, active := s.Pty(); active {
+			info.Term = pty.Term
+		}
+		svc.sessions.add(p, info)
+		go func() {
+			<-s.Context().Done()
+			svc.sessions.remove(p)
+			if cfg.Tracking && svc.repository != nil {
+				visit := storage.Visit{
+					User:        s.User(),
+					Fingerprint: keyFingerprint(sessionKey(s)),
+					Address:     s.RemoteAddr().String(),
+					ConnectedAt: connectedAt,
+					Duration:    time.Since(connectedAt),
+				}
+				if err := svc.repository.RecordVisit(visit); err != nil {
+					reportError(sessionHub(s), "could not record visit", err)
+				}
+			}
+		}()
+		return p
+	}
+}
+
+func serve(cfg *config.Config, configPath string) {
+	setLogFormat(cfg.LogFormat)
+	if err := setupErrorReporting(cfg.Sentry); err != nil {
+		log.Fatal("could not set up error reporting", "error", err)
+	}
+	defer sentry.Flush(sentryFlushTimeout)
+	source, err := newContentSource(cfg)
+	if err != nil {
+		log.Fatal("could not set up the content source", "error", err)
+	}
+	source.open(cfg.Directory)
+	live := newLiveConfig(configPath, cfg)
+	sessions := newSessionRegistry()
+	cache := newContentCache()
+	var bot *discordBot
+	if cfg.DiscordBot.Token != "" {
+		var err error
+		if bot, err = newDiscordBot(cfg.DiscordBot, live, cache); err != nil {
+			log.Fatal("could not set up the discord bot", "error", err)
+		}
+	}
+
+	svc := &services{
+		config:       live,
+		applications: applications.NewFileStore(cfg.ApplicationsPath),
+		preferences:  preferences.NewFileStore(cfg.PreferencesPath),
+		guestbook:    guestbook.NewFileStore(cfg.GuestbookPath),
+		feedback:     feedback.NewFileStore(cfg.FeedbackPath),
+		guestLimiter: newGuestbookLimiter(),
+		sessions:     sessions,
+		content:      cache,
+		reload:       func() { reloadContent(cfg, sessions, cache, bot) },
+		announcer:    newAnnouncer(sessions, time.Duration(cfg.AnnouncementDuration)*time.Second),
+	}
+	admins, err := parseAdminKeys(cfg.AdminKeys)
+	if err != nil {
+		log.Fatal("could not parse admin keys", "error", err)
+	}
+	svc.admins = admins
+	if _, ok := components.ThemeByName(cfg.Theme); !ok {
+		log.Fatal("unknown theme", "theme", cfg.Theme, "themes", strings.Join(components.ThemeNames(), ", "))
+	}
+	if svc.keys, err = keys.remap(cfg.Keys); err != nil {
+		log.Fatal("could not remap keys", "error", err)
+	}
+	var markdownStyle *ansi.StyleConfig
+	if cfg.MarkdownStyle != "" {
+		if markdownStyle, err = loadMarkdownStyle(cfg.MarkdownStyle); err != nil {
+			log.Fatal("could not load markdown style", "error", err)
+		}
+	}
+	svc.renderers = newRendererCache(markdownStyle)
+	if cfg.DatabasePath != "" {
+		db, err := storage.Open(cfg.DatabasePath)
+		if err != nil {
+			log.Fatal("could not open database", "error", err)
+		}
+		defer db.Close()
+		svc.applications, svc.preferences, svc.guestbook, svc.feedback, svc.repository = db, db, db, db, db
+	}
+
+	preferenceWriter := preferences.NewWriter(svc.preferences)
+	defer func() {
+		if err := preferenceWriter.Close(); err != nil {
+			reportError(sentry.CurrentHub(), "could not save preferences", err)
+		}
+	}()
+	svc.preferences = preferenceWriter
+
+	var viewStore views.Store = views.NewFileStore(cfg.ViewsPath)
+	if svc.repository != nil {
+		viewStore = svc.repository
+	}
+	viewCounter, err := views.NewCounter(viewStore)
+	if err != nil {
+		log.Fatal("could not load view counts", "error", err)
+	}
+	defer func() {
+		if err := viewCounter.Close(); err != nil {
+			reportError(sentry.CurrentHub(), "could not save view counts", err)
+		}
+	}()
+	svc.views = viewCounter
+
+	deadLetters := notify.NewDeadLetters(cfg.DeadLetterPath)
+	if cfg.DiscordWebhookURL != "" {
+		discord := notify.NewDiscord(cfg.DiscordWebhookURL, deadLetters)
+		svc.notifiers = append(svc.notifiers, discord)
+		if cfg.FeedbackToDiscord {
+			svc.feedbackHook = discord
+		}
+	}
+	if cfg.SMTP.Host != "" {
+		svc.notifiers = append(svc.notifiers, notify.NewMailer(notify.MailOptions{
+			Host:        cfg.SMTP.Host,
+			Port:        cfg.SMTP.Port,
+			Username:    cfg.SMTP.Username,
+			Password:    cfg.SMTP.Password,
+			From:        cfg.SMTP.From,
+			Maintainers: cfg.SMTP.Maintainers,
+		}, deadLetters))
+	}
+
+	middleware := []wish.Middleware{
+		sessionCleanup(live, svc.preferences),
+		// Views are drawn in full colour and downgraded per session to what
+		// the visitor's terminal can show.
+		bm.MiddlewareWithProgramHandler(programHandler(svc), termenv.TrueColor),
+		remoteCommands(live, svc.renderers),
+	}
+	content := newContentFS(cfg.Directory)
+	if cfg.Downloads {
+		middleware = append(middleware, scp.Middleware(scp.NewFSReadHandler(content), nil))
+	}
+	guards := []wish.Middleware{sessionCap(newSessionCounter(cfg.SessionLimits.Max, cfg.SessionLimits.PerIP))}
+	if cfg.RateLimit.PerMinute > 0 {
+		guards = append(guards, rateLimit(newConnectionLimiter(cfg.RateLimit.PerMinute, cfg.RateLimit.Burst)))
+	}
+	middleware = append(middleware, guards...)
+	var access *accesslog.Writer
+	if cfg.AccessLog.Path != "" && !cfg.Tracking {
+		// The access log records who visited and what they read, which is
+		// tracking like any other; visitors are only told about it with
+		// tracking on.
+		log.Warn("Not writing the access log while tracking is off", "path", cfg.AccessLog.Path)
+	} else if cfg.AccessLog.Path != "" {
+		access, err = accesslog.Open(accesslog.Options{
+			Path:    cfg.AccessLog.Path,
+			Format:  cfg.AccessLog.Format,
+			MaxSize: int64(cfg.AccessLog.MaxSizeMB) << 20,
+			MaxAge:  time.Duration(cfg.AccessLog.MaxAgeHours) * time.Hour,
+		})
+		if err != nil {
+			log.Fatal("could not open access log", "error", err)
+		}
+		defer access.Close()
+	}
+	shutdownTracing, err := setupTracing(cfg.Tracing)
+	if err != nil {
+		log.Fatal("could not set up tracing", "error", err)
+	}
+	if cfg.RecordingsDir != "" {
+		if err := os.MkdirAll(cfg.RecordingsDir, 0o700); err != nil {
+			log.Fatal("could not create recordings directory", "path", cfg.RecordingsDir, "error", err)
+		}
+		middleware = append(middleware, recordSessions(cfg.RecordingsDir, svc.admins))
+	}
+	middleware = append(middleware, errorReporting(cfg.Tracking))
+	if cfg.Tracing.Endpoint != "" {
+		log.Info("Exporting traces", "endpoint", cfg.Tracing.Endpoint, "sample_ratio", cfg.Tracing.SampleRatio)
+		middleware = append(middleware, traceSession())
+	}
+	if cfg.Tracking {
+		middleware = append(middleware, sessionLog(access))
+	}
+
+	filter, err := newIPFilter(cfg.IPFilter)
+	if err != nil {
+		log.Fatal("could not parse ip filter", "error", err)
+	}
+
+	hostKeys, err := loadHostKeys(cfg)
+	if err != nil {
+		log.Fatal("could not load host keys", "error", err)
 	}
+	hostKeyExisted := !hostKeys[0].generated
 
 	s, err := wish.NewServer(
-		wish.WithAddress(fmt.Sprintf("%s:%d", host, port)),
-		wish.WithHostKeyPath(fmt.Sprintf("%s/term_info_ed25519", sshFolderPath)),
-		wish.WithMiddleware(
-			bm.Middleware(teaHandler),
-			lm.Middleware(),
-		),
+		wish.WithAddress(fmt.Sprintf("%s:%d", cfg.Host, cfg.Port)),
+		withHostKeys(hostKeys),
+		wish.WithMiddleware(middleware...),
+		// Every key is let in so the session learns who connected; anyone
+		// without a key still gets in through an empty keyboard-interactive.
+		wish.WithPublicKeyAuth(acceptPublicKey),
+		wish.WithKeyboardInteractiveAuth(acceptKeyboardInteractive),
+		withIPFilter(filter),
 	)
+	if err == nil {
+		err = withBanner(svc)(s)
+	}
+	if err == nil && cfg.ProxyProtocol {
+		var trusted []*net.IPNet
+		if trusted, err = parseNetworks(cfg.TrustedProxies); err == nil {
+			err = withProxyProtocol(trusted)(s)
+		}
+	}
+	if err == nil && cfg.Downloads {
+		// Subsystems skip the middleware, so SFTP gets the same limits by hand.
+		sftpHandler := sftpSubsystem(content)
+		for _, guard := range guards {
+			sftpHandler = guard(sftpHandler)
+		}
+		err = withSFTP(sftpHandler)(s)
+	}
 	if err != nil {
 		log.Error("could not start server", "error", err)
 	}
 
+	contentWatcher, err := watcher.Watch(cfg.Directory, func() { reloadContent(cfg, sessions, cache, bot) })
+	if err != nil {
+		log.Warn("content changes will not be picked up live", "error", err)
+	} else {
+		defer contentWatcher.Close()
+		cache.watching()
+	}
+	warmCache(cfg, svc)
+	stopPolling := make(chan struct{})
+	defer close(stopPolling)
+	go source.poll(svc.reload, stopPolling)
+	if bot != nil {
+		if err := bot.open(); err != nil {
+			log.Error("could not connect the discord bot", "error", err)
+		} else {
+			defer bot.close()
+		}
+	}
+
 	done := make(chan os.Signal, 1)
 	signal.Notify(done, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
-	log.Info("Starting SSH server", "host", host, "port", port)
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
 	go func() {
-		if err = s.ListenAndServe(); err != nil && !errors.Is(err, ssh.ErrServerClosed) {
+		for range hangup {
+			reloadConfig(live, svc)
+		}
+	}()
+	log.Info("ready", selfCheck(cfg, svc.renderers, hostKeyExisted)...)
+	log.Info("Starting SSH server", "host", cfg.Host, "port", cfg.Port, "tracking", cfg.Tracking)
+	var listening atomic.Bool
+	ls := newListeners()
+	listener, err := ls.listen("ssh", s.Addr)
+	if err != nil {
+		log.Error("could not start server", "error", err)
+		return
+	}
+	go func() {
+		listening.Store(true)
+		defer listening.Store(false)
+		if err := s.Serve(listener); err != nil && !errors.Is(err, ssh.ErrServerClosed) && !errors.Is(err, net.ErrClosed) {
 			log.Error("could not start server", "error", err)
 			done <- nil
 		}
 	}()
+	if cfg.UnixSocket != "" {
+		unixListener, err := ls.listen("unix", "unix:"+cfg.UnixSocket)
+		if err != nil {
+			log.Error("could not listen on unix socket", "path", cfg.UnixSocket, "error", err)
+		} else {
+			log.Info("Listening on unix socket", "path", cfg.UnixSocket)
+			go func() {
+				if err := s.Serve(unixListener); err != nil && !errors.Is(err, ssh.ErrServerClosed) && !errors.Is(err, net.ErrClosed) {
+					log.Error("could not serve unix socket", "error", err)
+				}
+			}()
+		}
+	}
+
+	var pprofServer *http.Server
+	if cfg.PprofAddr != "" {
+		pprofServer = newPprofServer(cfg.PprofAddr, sessions)
+		log.Info("Starting pprof server", "addr", cfg.PprofAddr)
+		serveHTTP(ls, "pprof", pprofServer)
+	}
+
+	var webServer *http.Server
+	if cfg.HTTPAddr != "" {
+		var webhook http.Handler
+		if cfg.WebhookSecret != "" {
+			webhook = pushWebhook(cfg.WebhookSecret, source, svc.reload)
+		}
+		webServer = newWebServer(cfg.HTTPAddr, live, webhook)
+		log.Info("Starting web server", "addr", cfg.HTTPAddr)
+		serveHTTP(ls, "web", webServer)
+	} else if cfg.WebhookSecret != "" {
+		log.Warn("webhook_secret is set without http_addr, so push webhooks have nowhere to go")
+	}
+
+	var healthServer *http.Server
+	if cfg.HealthAddr != "" {
+		healthServer = newHealthServer(cfg.HealthAddr, cfg, &listening)
+		log.Info("Starting health server", "addr", cfg.HealthAddr)
+		serveHTTP(ls, "health", healthServer)
+	}
+
+	var control net.Listener
+	if cfg.ControlSocket != "" {
+		control, err = listenControl(cfg.ControlSocket, svc.announcer, svc.reload)
+		if err != nil {
+			log.Error("could not open control socket", "error", err)
+		} else {
+			log.Info("Listening for control commands", "socket", cfg.ControlSocket)
+			defer control.Close()
+		}
+	}
+	ls.ready()
+
+	// SIGUSR2 starts a new copy of the server on the same sockets, for
+	// deploying a new binary. This process stops taking connections once the
+	// new one does and exits when its last session ends.
+	upgrade := make(chan os.Signal, 1)
+	signal.Notify(upgrade, syscall.SIGUSR2)
+	drained := make(chan struct{})
+	go func() {
+		for range upgrade {
+			if control != nil {
+				// Closing removes the socket file, so it goes before the new
+				// process makes its own.
+				control.Close()
+				control = nil
+			}
+			if err := ls.handOver(); err != nil {
+				log.Error("could not hand over to a new process", "error", err)
+				continue
+			}
+			ls.close()
+			signal.Stop(hangup)
+			log.Info("handed over to the new process, waiting for sessions to end", "sessions", sessions.count())
+			for sessions.count() > 0 {
+				time.Sleep(time.Second)
+			}
+			close(drained)
+			return
+		}
+	}()
 
-	<-done
+	select {
+	case <-done:
+	case <-drained:
+	}
+	if active := sessions.count(); active > 0 && live.load().ShutdownGrace > 0 {
+		grace := time.Duration(live.load().ShutdownGrace) * time.Second
+		log.Info("Warning visitors before stopping", "sessions", active, "grace", grace)
+		sessions.broadcast(noticeMsg("Server restarting, reconnect in a minute"))
+		select {
+		case <-time.After(grace):
+		case <-done:
+		}
+		sessions.broadcast(tea.Quit())
+	}
 	log.Info("Stopping SSH server")
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
 	defer cancel()
+	if pprofServer != nil {
+		if err := pprofServer.Shutdown(ctx); err != nil {
+			log.Error("could not stop pprof server", "error", err)
+		}
+	}
+	if webServer != nil {
+		if err := webServer.Shutdown(ctx); err != nil {
+			log.Error("could not stop web server", "error", err)
+		}
+	}
+	if healthServer != nil {
+		if err := healthServer.Shutdown(ctx); err != nil {
+			log.Error("could not stop health server", "error", err)
+		}
+	}
 	if err := s.Shutdown(ctx); err != nil && !errors.Is(err, ssh.ErrServerClosed) {
 		log.Error("could not stop server", "error", err)
 	}
+	for _, notifier := range svc.notifiers {
+		notifier.Wait()
+	}
+	if err := shutdownTracing(ctx); err != nil {
+		log.Error("could not flush traces", "error", err)
+	}
 }
 
-func teaHandler(s ssh.Session) (tea.Model, []tea.ProgramOption) {
-    pty, _, active := s.Pty()
-    if !active {
-        wish.Fatalln(s, "no active terminal, skipping")
-        return nil, nil
-    }
+func teaHandler(svc *services) bm.Handler {
+	return func(s ssh.Session) (tea.Model, []tea.ProgramOption) {
+		cfg := svc.config.load()
+		pty, _, active := s.Pty()
+		if !active {
+			wish.Fatalln(s, "no active terminal, skipping")
+			return nil, nil
+		}
+		// Ask before the program starts reading keys; the answers pick the
+		// light or dark variant of the theme and how the logo is drawn.
+		ctx, setup := tracer.Start(sessionTrace(s), "session.setup")
+		defer setup.End()
+		input := newSessionInput(s)
+		_, probe := tracer.Start(ctx, "terminal.probe")
+		term := input.probeTerminal(s, pty)
+		probe.SetAttributes(attribute.String("term", pty.Term), attribute.Bool("light", term.light), attribute.String("graphics", term.graphics.String()))
+		probe.End()
+		s.Context().SetValue(terminalKey{}, term)
+
+		_, index := tracer.Start(ctx, "content.index")
+		positionMeta, searchIndex, err := svc.content.positions(cfg.Directory)
+		endSpan(index, err)
+		if err != nil {
+			wish.Fatalln(s, err.Error())
+			return nil, nil
+		}
 
-    positionMeta, err := utils.GetPositionMeta("directory")
-    if err != nil {
-        wish.Fatalln(s, "can't read directory: "+err.Error())
-        return nil, nil
-    }
+		searchInput := textinput.New()
+		searchInput.Placeholder = "search positions"
+		searchInput.Prompt = "/ "
 
-    // Capture catimg output
-    catimgOutput, err := runCatimg("jodc_logo.txt", 15, 2)
-    if err != nil {
-        wish.Fatalln(s, "failed to run catimg: "+err.Error())
-        return nil, nil
-    }
+		readerInput := textinput.New()
+		readerInput.Placeholder = "find in position"
+		readerInput.Prompt = "/ "
 
-    // Continue with your model initialization
-    m := Model{
-        fileNames:        positionMeta.FileNames,
-        fileDescriptions: positionMeta.FileDescriptions,
-        terminalHeight:   pty.Window.Height,
-        help:             help.New(),
-        keys:             keys,
-        catimgOutput:     catimgOutput,
-    }
+		adminInput := textinput.New()
+		adminInput.Placeholder = "message for every session"
+		adminInput.Prompt = "📣 "
 
-    // Additional initialization code...
+		admin := isAdmin(svc.admins, sessionKey(s))
+		if admin {
+			log.Info("admin connected", "user", s.User(), "addr", s.RemoteAddr().String())
+		}
+		keyMap := svc.keys
+		keyMap.Admin.SetEnabled(admin)
+		keyMap.Reload.SetEnabled(admin)
+		keyMap.Broadcast.SetEnabled(admin)
 
-    return m, []tea.ProgramOption{tea.WithAltScreen(), tea.WithMouseCellMotion()}
-}
+		filterInput := textinput.New()
+		filterInput.Placeholder = "filter positions"
+		filterInput.Prompt = "/ "
+
+		m := Model{
+			fileNames:        positionMeta.FileNames,
+			fileTitles:       positionMeta.FileTitles,
+			fileDescriptions: positionMeta.FileDescriptions,
+			fileMetadata:     positionMeta.FileMetadata,
+			terminalHeight:   pty.Window.Height,
+			help:             help.New(),
+			keys:             keyMap,
+			searchIndex:      searchIndex,
+			searchInput:      searchInput,
+			filterInput:      filterInput,
+			readerInput:      readerInput,
+			config:           cfg,
+			applications:     svc.applications,
+			repository:       svc.repository,
+			notifiers:        svc.notifiers,
+			views:            svc.views,
+			sessions:         svc.sessions,
+			admin:            admin,
+			adminInput:       adminInput,
+			reload:           svc.reload,
+			announcer:        svc.announcer,
+			fingerprint:      keyFingerprint(sessionKey(s)),
+			record:           sessionRecordFrom(s),
+			hub:              sessionHub(s),
+			ctx:              sessionTrace(s),
+			preferenceStore:  svc.preferences,
+			renderers:        svc.renderers,
+			viewed:           make(map[string]bool),
+			offsets:          make(map[string]int),
+			user:             s.User(),
+			clipboard:        s,
+			term:             pty.Term,
+			splashing:        !cfg.ReduceMotion,
+			sections:         newSections(),
+			guestbook:        svc.guestbook,
+			guestbookLimit:   svc.guestLimiter,
+			feedback:         svc.feedback,
+			feedbackHook:     svc.feedbackHook,
+			pager:            newPager(keyMap),
+			cache:            svc.content,
+		}
+
+		if m.fingerprint != "" {
+			if m.preferences, err = svc.preferences.Preferences(m.fingerprint); err != nil {
+				reportError(m.hub, "could not load preferences", err)
+			}
+			if !cfg.Tracking {
+				// Anything read or starred while tracking was on stays unused.
+				m.preferences = m.preferences.Settings()
+			}
+			m.lastVisit = m.preferences.LastVisit
+			m.preferences.LastVisit = time.Now()
+			m.savePreferences()
+
+			var welcome []string
+			if last := m.lastRead(); last >= 0 {
+				m.revealInList(m.fileNames[last])
+				welcome = append(welcome, fmt.Sprintf("Last time you read %s.", m.fileTitles[last]))
+			}
+			if fresh := m.newCount(); fresh == 1 {
+				welcome = append(welcome, "1 position is new since your last visit.")
+			} else if fresh > 1 {
+				welcome = append(welcome, fmt.Sprintf("%d positions are new since your last visit.", fresh))
+			}
+			if len(welcome) > 0 {
+				m.statusMessage = "Welcome back! " + strings.Join(welcome, " ")
+			}
+		}
+
+		m.theme = pickTheme(cfg, m.preferences, term)
+		_, branding := tracer.Start(ctx, "branding.render")
+		err = m.renderBranding()
+		endSpan(branding, err)
+		if err != nil {
+			reportError(m.hub, "could not render branding", err)
+			wish.Fatalln(s, err.Error())
+			return nil, nil
+		}
 
+		var output io.Writer = s
+		if term.graphics != termimage.Cells {
+			columns := lipgloss.Width(m.logoOutput) - logoPadding
+			_, graphics := tracer.Start(ctx, "logo.graphics")
+			m.graphics, err = newLogoGraphics(cfg.LogoPath, term, columns, logoHeight)
+			endSpan(graphics, err)
+			if err != nil {
+				log.Warn("could not prepare logo graphics", "error", err)
+				m.graphics = nil
+			} else {
+				output = graphicsOutput{Writer: s, graphics: m.graphics}
+			}
+		}
+
+		return m, []tea.ProgramOption{tea.WithInput(input), tea.WithOutput(output), tea.WithAltScreen(), tea.WithMouseCellMotion()}
+	}
+}
 
 func (m Model) Init() tea.Cmd {
-	return nil
+	cmds := []tea.Cmd{func() tea.Msg { return countBrowsing(m.sessions) }}
+	if m.splashing {
+		cmds = append(cmds, splashTick())
+	}
+	if announcement, ok := m.announcer.active(); ok {
+		cmds = append(cmds, func() tea.Msg { return announcement })
+	}
+	return tea.Batch(cmds...)
 }
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -173,63 +1016,336 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		cmd  tea.Cmd
 		cmds []tea.Cmd
 	)
+	if m.currentView == applyView {
+		switch msg.(type) {
+		case tea.WindowSizeMsg, contentReloadedMsg, contentLoadedMsg, configReloadedMsg, noticeMsg, browsingMsg, announcementMsg, announcementExpiredMsg, rewrapMsg:
+		default:
+			return m.updateApply(msg)
+		}
+	}
+	if t, ok := m.typingSection(); ok {
+		switch msg.(type) {
+		case tea.WindowSizeMsg, contentReloadedMsg, contentLoadedMsg, configReloadedMsg, noticeMsg, browsingMsg, announcementMsg, announcementExpiredMsg, rewrapMsg:
+		case tea.KeyMsg:
+			m.statusMessage = ""
+			return m, t.updateInput(&m, msg)
+		default:
+			return m, t.updateInput(&m, msg)
+		}
+	}
+
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if m.splashing {
+			// Any key skips the splash.
+			m.splashing = false
+			return m, nil
+		}
+		if m.tab != positionsTab {
+			return m.updateSection(msg)
+		}
+		if m.currentView == adminView {
+			return m.updateAdmin(msg)
+		}
+		if m.currentView == searchView {
+			return m.updateSearch(msg)
+		}
+		if m.currentView == historyView {
+			return m.updateHistory(msg)
+		}
+		if m.filtering {
+			return m.updateFilter(msg)
+		}
+		if m.readerSearching {
+			return m.updateReaderSearch(msg)
+		}
+		if m.contentsOpen {
+			return m.updateContents(msg)
+		}
+		if m.applyQROpen {
+			return m.updateApplyQR(msg)
+		}
+		m.statusMessage = ""
 		switch {
 		case key.Matches(msg, m.keys.Quit):
+			if m.currentView == fileContentView {
+				m.rememberScroll()
+			}
 			return m, tea.Quit
 		case key.Matches(msg, m.keys.Up):
-			if m.cursor > 0 && m.currentView == fileListView {
-				m.cursor--
+			if m.currentView == fileListView {
+				m.moveCursor(-1)
 			}
 		case key.Matches(msg, m.keys.Down):
-			if m.cursor < len(m.fileNames)-1 && m.currentView == fileListView {
-				m.cursor++
+			if m.currentView == fileListView {
+				m.moveCursor(1)
 			}
 
+		case key.Matches(msg, m.keys.PageUp), key.Matches(msg, m.keys.PageDown):
+			if m.currentView == fileListView && !m.filterActive() {
+				m.turnPage(msg)
+			}
 		case key.Matches(msg, m.keys.Top):
 			m.viewport.GotoTop()
-		case key.Matches(msg, m.keys.Enter):
+		case key.Matches(msg, m.keys.Bottom):
+			m.viewport.GotoBottom()
+		case key.Matches(msg, m.keys.Left):
+			if m.currentView == fileContentView {
+				cmds = append(cmds, m.stepPosition(-1))
+			}
+		case key.Matches(msg, m.keys.Right):
+			if m.currentView == fileContentView {
+				cmds = append(cmds, m.stepPosition(1))
+			}
+		case key.Matches(msg, m.keys.NextTag):
+			if m.currentView == fileListView {
+				m.cycleTag(1)
+			}
+		case key.Matches(msg, m.keys.PrevTag):
+			if m.currentView == fileListView {
+				m.cycleTag(-1)
+			}
+		case key.Matches(msg, m.keys.Sort):
+			if m.currentView == fileListView {
+				m.cycleSort()
+			}
+		case key.Matches(msg, m.keys.Apply):
+			if m.currentView == fileContentView {
+				return m, m.openApplyForm()
+			}
+		case key.Matches(msg, m.keys.Favorite):
+			switch m.currentView {
+			case fileListView:
+				if len(m.fileNames) > 0 && !utils.IsDirEntry(m.fileNames[m.cursor]) {
+					m.toggleFavorite(m.fileNames[m.cursor])
+				}
+			case fileContentView:
+				m.toggleFavorite(m.selectedFileName)
+			}
+		case key.Matches(msg, m.keys.CopyLinks):
 			if m.currentView == fileListView {
-				selectedFile := m.fileNames[m.cursor]
-				content, err := os.ReadFile("directory/" + selectedFile)
-				if err != nil {
-					m.fileContent = "Error reading file"
+				positions := m.takingApplications()
+				if err := utils.CopyToClipboard(m.clipboard, m.term, m.applyLinks(positions)); err != nil {
+					m.statusMessage = "Could not copy apply links"
 				} else {
-					fileContent := string(content)
-					m.fileContent = strings.Join(strings.Split(fileContent, "\n")[2:], "\n")
-					m.selectedFileName = selectedFile
+					m.statusMessage = fmt.Sprintf("Copied %d apply links to your clipboard", len(positions))
 				}
-				parsedFileContent, err := glamour.Render(m.fileContent, "dark")
-				if err != nil {
-					m.viewport.SetContent("Error parsing markdown")
+			}
+		case key.Matches(msg, m.keys.CopyLink):
+			if m.currentView == fileContentView {
+				m.copyLink()
+			}
+		case key.Matches(msg, m.keys.ShareView):
+			if m.currentView == fileListView || m.currentView == fileContentView {
+				if err := utils.CopyToClipboard(m.clipboard, m.term, m.shareableView()); err != nil {
+					m.statusMessage = "Could not copy view"
+				} else {
+					m.statusMessage = "Copied view to your clipboard"
 				}
-				m.viewport.SetContent(parsedFileContent)
-				m.currentView = fileContentView
-				m.viewport.GotoTop()
 			}
-		case key.Matches(msg, m.keys.Back):
+		case key.Matches(msg, m.keys.Search):
+			switch m.currentView {
+			case fileListView:
+				m.filtering = true
+				m.applyFilter()
+				return m, m.filterInput.Focus()
+			case fileContentView:
+				m.readerSearching = true
+				m.readerInput.SetValue("")
+				return m, m.readerInput.Focus()
+			}
+		case m.currentView == fileListView && m.filterActive() && key.Matches(msg, m.keys.ClearSearch):
+			m.clearFilter()
+		case m.currentView == fileContentView && len(m.readerMatches) > 0 && key.Matches(msg, m.keys.ClearSearch):
+			m.clearReaderSearch()
+		case key.Matches(msg, m.keys.NextMatch):
 			if m.currentView == fileContentView {
-				m.currentView = fileListView
-				m.viewport.GotoTop()
+				m.stepReaderMatch(1)
 			}
-		}
-	case tea.WindowSizeMsg:
-		m.help.Width = msg.Width
-
-		headerHeight := lipgloss.Height(m.HeaderView())
+		case key.Matches(msg, m.keys.PrevMatch):
+			if m.currentView == fileContentView {
+				m.stepReaderMatch(-1)
+			}
+		case key.Matches(msg, m.keys.ApplyQR):
+			if m.currentView == fileContentView {
+				m.openApplyQR()
+			}
+		case key.Matches(msg, m.keys.Raw):
+			if m.currentView == fileContentView {
+				m.toggleRaw()
+			}
+		case key.Matches(msg, m.keys.Contents):
+			if m.currentView == fileContentView {
+				m.openContents()
+			}
+		case key.Matches(msg, m.keys.History):
+			if m.currentView == fileContentView && !m.loading {
+				cmds = append(cmds, m.loadHistory())
+			}
+		case key.Matches(msg, m.keys.GlobalSearch):
+			if m.currentView == fileListView {
+				m.currentView = searchView
+				m.searchInput.SetValue("")
+				m.searchResults = nil
+				m.searchCursor = 0
+				return m, m.searchInput.Focus()
+			}
+		case key.Matches(msg, m.keys.Enter):
+			if m.currentView == fileListView {
+				cmds = append(cmds, m.openCursor())
+			}
+		case key.Matches(msg, m.keys.Admin):
+			if m.currentView == fileListView || m.currentView == fileContentView {
+				m.openAdmin()
+			}
+		case key.Matches(msg, m.keys.Theme):
+			m.cycleTheme()
+		case key.Matches(msg, m.keys.Background):
+			m.toggleBackground()
+		case key.Matches(msg, m.keys.ASCII):
+			m.toggleASCII()
+		case key.Matches(msg, m.keys.Links):
+			if m.currentView == fileListView || m.currentView == fileContentView {
+				m.openLinks()
+				cmds = append(cmds, tea.ClearScreen)
+			}
+		case key.Matches(msg, m.keys.Sign):
+			if m.currentView == fileListView || m.currentView == fileContentView {
+				cmds = append(cmds, m.openGuestbook(), tea.ClearScreen)
+			}
+		case key.Matches(msg, m.keys.Section):
+			if m.currentView == fileListView || m.currentView == fileContentView {
+				m.switchTab(m.tabFor(msg))
+				// The renderer only redraws the lines that changed, and
+				// lines up a list taller than the screen by its hidden top.
+				cmds = append(cmds, tea.ClearScreen)
+			}
+		case key.Matches(msg, m.keys.Help):
+			if m.currentView == helpView {
+				m.closeHelp()
+			} else {
+				m.openHelp()
+			}
+		case key.Matches(msg, m.keys.Back):
+			switch m.currentView {
+			case fileListView:
+				if m.currentDir != "" {
+					m.leaveDir()
+				}
+			case fileContentView:
+				m.rememberScroll()
+				m.loading = false
+				m.currentView = fileListView
+				m.viewport.GotoTop()
+			case helpView:
+				m.closeHelp()
+			}
+		}
+	case tea.MouseMsg:
+		if m.currentView == fileListView && !m.splashing {
+			return m.updateListMouse(msg)
+		}
+	case contentLoadedMsg:
+		m.showLoaded(msg)
+	case historyLoadedMsg:
+		m.showHistory(msg)
+	case configReloadedMsg:
+		m.applyConfig(msg.config)
+	case spinner.TickMsg:
+		if m.loading {
+			m.spinner, cmd = m.spinner.Update(msg)
+			m.viewport.SetContent(m.loadingView())
+			cmds = append(cmds, cmd)
+		}
+	case splashMsg:
+		return m.updateSplash()
+	case noticeMsg:
+		m.notice = string(msg)
+	case announcementMsg:
+		m.announcement = msg
+		m.resizeViewport()
+		if msg.text != "" {
+			cmds = append(cmds, expireAnnouncement(msg))
+		}
+	case announcementExpiredMsg:
+		if m.announcement.expires.Equal(msg.expires) {
+			m.announcement = announcementMsg{}
+			m.resizeViewport()
+		}
+	case browsingMsg:
+		m.browsing = int(msg)
+		if m.currentView == adminView {
+			m.viewport.SetContent(m.adminContent())
+		}
+		cmds = append(cmds, tea.Tick(browsingInterval, func(time.Time) tea.Msg {
+			return countBrowsing(m.sessions)
+		}))
+	case contentReloadedMsg:
+		m.fileNames = msg.positionMeta.FileNames
+		m.fileTitles = msg.positionMeta.FileTitles
+		m.fileDescriptions = msg.positionMeta.FileDescriptions
+		m.fileMetadata = msg.positionMeta.FileMetadata
+		m.searchIndex = msg.searchIndex
+		m.cursor = utils.Min(m.cursor, utils.Max(0, len(m.fileNames)-1))
+		if !m.tagExists(m.activeTag) {
+			m.activeTag = ""
+		}
+		if !m.dirExists(m.currentDir) {
+			m.currentDir = ""
+		}
+		if m.filterActive() {
+			m.applyFilter()
+		}
+		if m.currentView == searchView {
+			m.searchResults = m.searchPositions(m.searchInput.Value())
+			m.searchCursor = utils.Min(m.searchCursor, utils.Max(0, len(m.searchResults)-1))
+		}
+		if m.currentView == fileContentView {
+			cmds = append(cmds, m.reloadFile())
+		}
+	case tea.WindowSizeMsg:
+		m.help.Width = msg.Width
+		m.terminalHeight = msg.Height
+		// The renderer redraws every line after a resize, wiping sixels.
+		if m.graphics != nil {
+			m.graphics.redraw()
+		}
+
+		headerHeight := lipgloss.Height(m.HeaderView())
 		footerHeight := lipgloss.Height(m.FooterView())
-		verticalMarginHeight := headerHeight + footerHeight
+		verticalMarginHeight := headerHeight + footerHeight + strings.Count(m.announcementView(), "\n")
 
 		if (!m.ready) {
 			m.viewport = viewport.New(msg.Width, msg.Height-verticalMarginHeight)
 			m.viewport.YPosition = headerHeight
 			m.viewport.HighPerformanceRendering = false
+			// The viewport's own paging keys clash with b (star) and u
+			// (ASCII), so it scrolls with ours, remapped or not.
+			m.viewport.KeyMap.Up = m.keys.Up
+			m.viewport.KeyMap.Down = m.keys.Down
+			m.viewport.KeyMap.PageUp = m.keys.PageUp
+			m.viewport.KeyMap.PageDown = m.keys.PageDown
+			m.viewport.KeyMap.HalfPageUp = m.keys.HalfPageUp
+			m.viewport.KeyMap.HalfPageDown = m.keys.HalfPageDown
 			m.ready = true
 		} else {
+			// Images are scaled to the height as well as the width.
+			resized := msg.Width != m.viewport.Width || (m.openImage != nil && msg.Height-verticalMarginHeight != m.viewport.Height)
+			if resized {
+				m.resizes++
+				cmds = append(cmds, rewrapAfter(m.resizes))
+			}
 			m.viewport.Width = msg.Width
 			m.viewport.Height = msg.Height - verticalMarginHeight
 		}
+		m.resizeSection()
+	case rewrapMsg:
+		if int(msg) == m.resizes {
+			m.rerender()
+			if m.currentView == historyView {
+				m.viewport.SetContent(m.historyContent())
+			}
+		}
 	}
 	m.viewport, cmd = m.viewport.Update(msg)
 
@@ -238,37 +1354,1097 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
+// copyLink copies a link to the open position to the visitor's clipboard,
+// or its markdown while the reader shows that.
+func (m *Model) copyLink() {
+	text, what := shareLink(m.config, m.selectedFileName), "a link to "+m.selectedTitle()
+	if m.raw && m.markdownOpen() {
+		text, what = m.fileContent, "the markdown of "+m.selectedTitle()
+	}
+	if err := utils.CopyToClipboard(m.clipboard, m.term, text); err != nil {
+		m.statusMessage = "Could not copy " + what
+		return
+	}
+	m.statusMessage = "Copied " + what + " to your clipboard"
+}
+
+func (m Model) applyLinks(positions []int) string {
+	var b strings.Builder
+	b.WriteString("JODC open positions\n\n")
+	for _, i := range positions {
+		fmt.Fprintf(&b, "- %s (%s): %s\n", m.fileTitles[i], m.fileDescriptions[i], positionApplyURL(m.config, m.fileMetadata[i]))
+	}
+	return b.String()
+}
+
+func (m Model) shareableView() string {
+	if m.currentView == fileContentView {
+		return utils.PlainText(m.selectedTitle() + "\n\n" + m.renderedContent)
+	}
+
+	s := components.BannerView(m.theme, m.viewport.Width)
+	s += components.IntroDescriptionView(m.viewport.Width)
+	s += components.OpenPositionsGrid(m.theme, m.viewport.Width, m.config.GridColumns, m.gridTitles(), m.fileDescriptions, m.cardBadges(), m.visiblePositions(), m.gridPinned(), -1)
+	return utils.PlainText(s)
+}
+
+// cardBadges are shown next to each title in the grid: DRAFT for drafts
+// only admins see, NEW for positions changed since the visitor was last
+// here, how often it was viewed and how long is left until its deadline.
+func (m Model) cardBadges() []string {
+	now := time.Now()
+	badges := make([]string, len(m.fileNames))
+	for i, fileName := range m.fileNames {
+		var parts []string
+		if i < len(m.fileMetadata) && m.fileMetadata[i].Draft() {
+			parts = append(parts, components.DraftBadge(m.theme))
+		}
+		if m.isNew(i) {
+			parts = append(parts, components.NewBadge(m.theme))
+		}
+		if views := m.views.Count(fileName); views > 0 {
+			parts = append(parts, components.ViewBadge(m.theme, views))
+		}
+		if i < len(m.fileMetadata) && !m.fileMetadata[i].Deadline.IsZero() {
+			meta := m.fileMetadata[i]
+			parts = append(parts, components.DeadlineBadge(m.theme, meta.Deadline, meta.Expired(now), m.expiringSoon(i, now), now))
+		}
+		badges[i] = strings.Join(parts, " ")
+	}
+	return badges
+}
+
+func (m Model) newCount() int {
+	count := 0
+	for i := range m.fileNames {
+		if m.isNew(i) && m.readable(i) {
+			count++
+		}
+	}
+	return count
+}
+
+// isNew reports whether a position was added or updated since the visitor's
+// previous visit. First-time visitors have nothing to compare with.
+func (m Model) isNew(index int) bool {
+	if m.lastVisit.IsZero() || index >= len(m.fileMetadata) || utils.IsDirEntry(m.fileNames[index]) {
+		return false
+	}
+	return m.fileMetadata[index].Updated.After(m.lastVisit)
+}
+
+func (m Model) selectedMetadata() utils.Frontmatter {
+	for i, fileName := range m.fileNames {
+		if fileName == m.selectedFileName && i < len(m.fileMetadata) {
+			return m.fileMetadata[i]
+		}
+	}
+	return utils.Frontmatter{}
+}
+
+func (m Model) selectedTitle() string {
+	for i, fileName := range m.fileNames {
+		if fileName == m.selectedFileName {
+			return m.fileTitles[i]
+		}
+	}
+	return m.selectedFileName
+}
+
+func (m *Model) openFile(selectedFile string) tea.Cmd {
+	return m.loadFile(fileLoad{fileName: selectedFile, offset: -1})
+}
+
+// rememberScroll keeps where the reader left the open position for when it
+// is opened again, in this session or, for visitors with a key, the next.
+func (m *Model) rememberScroll() {
+	if m.selectedFileName == "" || m.loading {
+		return
+	}
+	m.offsets[m.selectedFileName] = m.viewport.YOffset
+	if m.preferences.Scroll == nil {
+		m.preferences.Scroll = make(map[string]float64)
+	}
+	if m.viewport.YOffset > 0 {
+		m.preferences.Scroll[m.selectedFileName] = m.viewport.ScrollPercent()
+	} else {
+		delete(m.preferences.Scroll, m.selectedFileName)
+	}
+	m.savePreferences()
+}
+
+// scrollToFraction scrolls the reader the given fraction of the way down,
+// the inverse of the viewport's ScrollPercent.
+func (m *Model) scrollToFraction(scrolled float64) {
+	m.viewport.SetYOffset(int(math.Round(scrolled * float64(utils.Max(0, m.viewport.TotalLineCount()-m.viewport.Height)))))
+}
+
+// restoreScroll scrolls the open position back to where it was left, or to
+// the top the first time.
+func (m *Model) restoreScroll() {
+	m.viewport.GotoTop()
+	if offset, ok := m.offsets[m.selectedFileName]; ok {
+		m.viewport.SetYOffset(offset)
+	} else if scrolled, ok := m.preferences.Scroll[m.selectedFileName]; ok {
+		m.scrollToFraction(scrolled)
+	}
+}
+
+// reloadFile re-reads the open position after a content change, keeping the
+// reader's scroll position, or returns to the list if the file is gone.
+func (m *Model) reloadFile() tea.Cmd {
+	for _, fileName := range m.fileNames {
+		if fileName == m.selectedFileName {
+			return m.loadFile(fileLoad{fileName: fileName, offset: m.viewport.YOffset})
+		}
+	}
+	m.currentView = fileListView
+	m.viewport.GotoTop()
+	return nil
+}
+
+func (m Model) filterActive() bool {
+	return m.filtering || m.filterInput.Value() != ""
+}
+
+func (m *Model) applyFilter() {
+	m.filterMatches = nil
+	query := m.filterInput.Value()
+	if query == "" {
+		return
+	}
+
+	for i := range m.fileNames {
+		if !m.listed(i) {
+			continue
+		}
+		titleScore, titleMatches, titleOk := search.Fuzzy(query, m.fileTitles[i])
+		descriptionScore, descriptionMatches, descriptionOk := search.Fuzzy(query, m.fileDescriptions[i])
+		if !titleOk && !descriptionOk {
+			continue
+		}
+		m.filterMatches = append(m.filterMatches, filterMatch{
+			index:              i,
+			score:              2*titleScore + descriptionScore,
+			titleMatches:       titleMatches,
+			descriptionMatches: descriptionMatches,
+		})
+	}
+	sort.SliceStable(m.filterMatches, func(i, j int) bool {
+		return m.filterMatches[i].score > m.filterMatches[j].score
+	})
+	if len(m.filterMatches) > 0 {
+		m.cursor = m.filterMatches[0].index
+	}
+}
+
+func (m *Model) clearFilter() {
+	m.filtering = false
+	m.filterInput.Blur()
+	m.filterInput.SetValue("")
+	m.filterMatches = nil
+	m.resetCursor()
+}
+
+// tags lists every tag used by at least one position the visitor may see,
+// sorted.
+func (m Model) tags() []string {
+	seen := make(map[string]bool)
+	var tags []string
+	for i, meta := range m.fileMetadata {
+		if !m.readable(i) {
+			continue
+		}
+		for _, tag := range meta.Tags {
+			if !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// categories are the tags followed by the favorites, once there are any.
+func (m Model) categories() []string {
+	categories := m.tags()
+	if len(m.preferences.Favorites) > 0 {
+		categories = append(categories, favoritesCategory)
+	}
+	return categories
+}
+
+func (m Model) tagExists(tag string) bool {
+	if tag == "" {
+		return true
+	}
+	for _, t := range m.categories() {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func (m Model) hasActiveTag(index int) bool {
+	if m.activeTag == "" {
+		return true
+	}
+	if m.activeTag == favoritesCategory {
+		return m.preferences.IsFavorite(m.fileNames[index])
+	}
+	if index >= len(m.fileMetadata) {
+		return false
+	}
+	for _, tag := range m.fileMetadata[index].Tags {
+		if tag == m.activeTag {
+			return true
+		}
+	}
+	return false
+}
+
+// cycleTag moves the category bar by delta, where the empty tag stands for
+// every position, and puts the cursor on the first position left on screen.
+func (m *Model) cycleTag(delta int) {
+	categories := append([]string{""}, m.categories()...)
+	current := 0
+	for i, tag := range categories {
+		if tag == m.activeTag {
+			current = i
+		}
+	}
+	m.activeTag = categories[(current+delta+len(categories))%len(categories)]
+
+	m.applyFilter()
+	m.resetCursor()
+}
+
+// visiblePositions lists the positions currently on screen in display order:
+// the filter matches while a filter is active, otherwise every position in
+// the active category.
+func (m Model) visiblePositions() []int {
+	visible := make([]int, 0, len(m.fileNames))
+	if m.filterInput.Value() != "" {
+		for _, match := range m.filterMatches {
+			visible = append(visible, match.index)
+		}
+		return visible
+	}
+	for i := range m.fileNames {
+		if m.listed(i) {
+			visible = append(visible, i)
+		}
+	}
+
+	// The one pinned above the banner stays put.
+	first := 0
+	if m.gridPinned() {
+		first = 1
+	}
+	if len(visible) > first {
+		m.sortPositions(visible[first:])
+	}
+	return visible
+}
+
+// expiringSoon reports whether a position's deadline is less than
+// expiringWithin away.
+func (m Model) expiringSoon(index int, now time.Time) bool {
+	if index >= len(m.fileMetadata) || m.fileMetadata[index].Deadline.IsZero() {
+		return false
+	}
+	meta := m.fileMetadata[index]
+	return !meta.Expired(now) && meta.Closes().Sub(now) < expiringWithin
+}
+
+// readable reports whether the visitor may see a position: drafts are only
+// shown to admins.
+func (m Model) readable(index int) bool {
+	return m.admin || index >= len(m.fileMetadata) || !m.fileMetadata[index].Draft()
+}
+
+// searchPositions ranks the positions the visitor may see against query.
+// Archived positions are read from the Archive tab, so they stay out.
+func (m Model) searchPositions(query string) []search.Result {
+	var results []search.Result
+	for _, result := range m.searchIndex.Search(query, 0) {
+		for i, fileName := range m.fileNames {
+			if fileName == result.FileName && m.readable(i) && !m.archived(i) {
+				results = append(results, result)
+			}
+		}
+		if len(results) == maxSearchResults {
+			break
+		}
+	}
+	return results
+}
+
+// expired reports whether a position's deadline has passed.
+func (m Model) expired(index int) bool {
+	return index < len(m.fileMetadata) && m.fileMetadata[index].Expired(time.Now())
+}
+
+// archived reports whether a position belongs on the Archive tab instead of
+// the grid: closed, moved into the archive directory or, with hide_expired,
+// past its deadline.
+func (m Model) archived(index int) bool {
+	var meta utils.Frontmatter
+	if index < len(m.fileMetadata) {
+		meta = m.fileMetadata[index]
+	}
+	return utils.Archived(m.fileNames[index], meta) || m.config.HideExpired && m.expired(index)
+}
+
+// archivedPositions lists the positions on the Archive tab in the order they
+// are in the tree. Images have nothing to read, so they stay out.
+func (m Model) archivedPositions() []int {
+	var archived []int
+	for i, fileName := range m.fileNames {
+		if !utils.IsDirEntry(fileName) && !utils.IsImage(fileName) && m.archived(i) && m.readable(i) {
+			archived = append(archived, i)
+		}
+	}
+	return archived
+}
+
+// listed reports whether an entry belongs on screen when nothing is being
+// filtered: it has to sit in the current directory and, unless it is a
+// directory itself, carry the active tag.
+func (m Model) listed(index int) bool {
+	fileName := m.fileNames[index]
+	if m.activeTag == favoritesCategory {
+		// Favorites are gathered from every directory.
+		return !utils.IsDirEntry(fileName) && m.hasActiveTag(index) && !m.archived(index) && m.readable(index)
+	}
+	if utils.ParentDir(fileName) != m.currentDir {
+		return false
+	}
+	if m.archived(index) || !m.readable(index) {
+		return false
+	}
+	return utils.IsDirEntry(fileName) || m.hasActiveTag(index)
+}
+
+// takingApplications lists the positions the visitor could apply for: ones
+// they may read that are neither archived nor past their deadline. Images
+// are not positions at all.
+func (m Model) takingApplications() []int {
+	var positions []int
+	for _, i := range m.positions() {
+		if m.readable(i) && !m.archived(i) && !m.expired(i) && !utils.IsImage(m.fileNames[i]) {
+			positions = append(positions, i)
+		}
+	}
+	return positions
+}
+
+// positions lists every position in the tree, leaving out directory entries.
+func (m Model) positions() []int {
+	var positions []int
+	for i, fileName := range m.fileNames {
+		if !utils.IsDirEntry(fileName) {
+			positions = append(positions, i)
+		}
+	}
+	return positions
+}
+
+func (m *Model) resetCursor() {
+	if visible := m.visiblePositions(); len(visible) > 0 {
+		m.cursor = visible[0]
+	}
+}
+
+// gridPinned keeps the first position above the banner, but only on the
+// unfiltered top level where it is the place to start.
+func (m Model) gridPinned() bool {
+	return m.currentDir == "" && m.activeTag == ""
+}
+
+func (m Model) dirExists(dir string) bool {
+	if dir == "" {
+		return true
+	}
+	for _, fileName := range m.fileNames {
+		if fileName == dir+"/" {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Model) enterDir(dir string) {
+	m.currentDir = dir
+	m.clearFilter()
+}
+
+// leaveDir goes up one directory and puts the cursor back on the one it left.
+func (m *Model) leaveDir() {
+	left := m.currentDir + "/"
+	m.currentDir = utils.ParentDir(left)
+	m.clearFilter()
+	for i, fileName := range m.fileNames {
+		if fileName == left {
+			m.cursor = i
+		}
+	}
+}
+
+// lastRead is the most recently read position that still exists, or -1.
+func (m Model) lastRead() int {
+	for _, name := range m.preferences.LastRead {
+		for i, fileName := range m.fileNames {
+			if fileName == name && m.readable(i) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func (m *Model) rememberRead(fileName string) {
+	m.preferences.Read(fileName)
+	m.savePreferences()
+}
+
+// toggleFavorite stars or unstars a position. Visitors without a key, or on
+// a server without tracking, keep their stars until they disconnect.
+func (m *Model) toggleFavorite(fileName string) {
+	starred := m.preferences.ToggleFavorite(fileName)
+	m.savePreferences()
+
+	title := m.selectedTitle()
+	for i, name := range m.fileNames {
+		if name == fileName {
+			title = m.fileTitles[i]
+		}
+	}
+	switch {
+	case !starred:
+		m.statusMessage = "Unstarred " + title
+	case m.fingerprint == "":
+		m.statusMessage = "Starred " + title + " for this visit, connect with an SSH key to keep it"
+	case !m.config.Tracking:
+		m.statusMessage = "Starred " + title + " for this visit"
+	default:
+		m.statusMessage = "Starred " + title
+	}
+
+	if !m.tagExists(m.activeTag) {
+		m.activeTag = ""
+	}
+	if m.currentView == fileListView && !m.positionVisible(m.cursor) {
+		m.resetCursor()
+	}
+}
+
+// gridTitles are the titles shown on the cards, with favorites starred.
+func (m Model) gridTitles() []string {
+	titles := make([]string, len(m.fileTitles))
+	for i, title := range m.fileTitles {
+		if m.preferences.IsFavorite(m.fileNames[i]) {
+			title = "★ " + title
+		}
+		titles[i] = title
+	}
+	return titles
+}
+
+// savePreferences keeps the visitor's preferences for their next visit.
+// Without tracking only how the board looks is kept, not what they read.
+func (m *Model) savePreferences() {
+	if m.fingerprint == "" {
+		return
+	}
+	preferences := m.preferences
+	if !m.config.Tracking {
+		preferences = preferences.Settings()
+	}
+	if err := m.preferenceStore.SavePreferences(m.fingerprint, preferences); err != nil {
+		reportError(m.hub, "could not save preferences", err)
+	}
+}
+
+// renderFile renders the open file, highlighting source files by their
+// extension and rendering positions as markdown. Text renders are shared
+// through the content cache.
+func (m Model) renderFile(content string) (string, error) {
+	if m.openImage != nil {
+		mode := termimage.ModeFor(m.theme.Profile)
+		if m.theme.ASCII {
+			mode = termimage.ASCII
+		}
+		return termimage.RenderFit(m.openImage, m.wrapWidth(), m.imageHeight(), mode), nil
+	}
+	render := func() (string, error) {
+		return renderText(m.renderers, m.theme, m.selectedFileName, m.wrapWidth(), m.raw, content)
+	}
+	if m.fileModTime.IsZero() {
+		return render()
+	}
+	return m.cache.render(newRenderedKey(m.selectedFileName, m.fileModTime, m.theme, m.wrapWidth(), m.raw), render)
+}
+
+// renderText renders a text file in theme, wrapped to width: source files
+// highlighted, positions as markdown or, when raw, as written.
+func renderText(renderers *rendererCache, theme components.Theme, fileName string, width int, raw bool, content string) (string, error) {
+	if utils.CodeLanguage(fileName) != "" {
+		return highlightCode(theme, fileName, content)
+	}
+	if raw {
+		return rawMarkdown(content, width), nil
+	}
+	return renderers.render(theme, width, content)
+}
+
+// rawMarkdown is a position's markdown as written, wrapped to width.
+func rawMarkdown(content string, width int) string {
+	content = strings.ReplaceAll(content, "\t", "    ")
+	return wrap.String(wordwrap.String(content, width), width)
+}
+
+// markdownOpen reports whether the open file is a position rendered from
+// markdown rather than a source file or an image.
+func (m Model) markdownOpen() bool {
+	return m.openImage == nil && utils.CodeLanguage(m.selectedFileName) == ""
+}
+
+// toggleRaw switches the reader between the rendered position and the
+// markdown it was rendered from.
+func (m *Model) toggleRaw() {
+	if !m.markdownOpen() {
+		m.statusMessage = "Only positions have a markdown source"
+		return
+	}
+	m.raw = !m.raw
+	m.statusMessage = "Showing the rendered position"
+	if m.raw {
+		m.statusMessage = "Showing the markdown source"
+	}
+	m.rerender()
+}
+
+func (m Model) imageHeight() int {
+	if !m.ready || m.viewport.Height <= 0 {
+		return defaultImageHeight
+	}
+	return m.viewport.Height
+}
+
+func (m Model) wrapWidth() int {
+	if !m.ready || m.viewport.Width <= 0 {
+		return defaultWrapWidth
+	}
+	// The last column is left for the scrollbar.
+	return m.viewport.Width - 1
+}
+
+// rewrapAfter wraps the open position to the viewport once resizing has
+// settled, so dragging a window edge doesn't render it at every step.
+func rewrapAfter(resize int) tea.Cmd {
+	return tea.Tick(rewrapDelay, func(time.Time) tea.Msg {
+		return rewrapMsg(resize)
+	})
+}
+
+// rerender renders the open position again and keeps the reader around the
+// same place in it.
+func (m *Model) rerender() {
+	if m.selectedFileName == "" || m.loading {
+		return
+	}
+	rendered, err := m.renderFile(m.fileContent)
+	if err != nil {
+		return
+	}
+	m.renderedContent = rendered
+	m.buildContents()
+	if m.currentView != fileContentView {
+		return
+	}
+	if len(m.readerMatches) > 0 {
+		m.findReaderMatches()
+		return
+	}
+	scrolled := m.viewport.ScrollPercent()
+	m.viewport.SetContent(rendered)
+	m.scrollToFraction(scrolled)
+}
+
+// cycleTheme moves the session on to the next theme, remembers it for the
+// visitor's key and redraws the open position in it.
+func (m *Model) cycleTheme() {
+	m.theme = m.theme.Next()
+	m.preferences.Theme = m.theme.Name
+	m.savePreferences()
+	m.statusMessage = "Theme: " + m.theme.Name
+	m.redrawTheme()
+}
+
+// toggleBackground overrides the detected terminal background, for terminals
+// that don't say or say wrong.
+func (m *Model) toggleBackground() {
+	m.theme = m.theme.ForBackground(!m.theme.Light)
+	m.preferences.Background = preferences.DarkBackground
+	if m.theme.Light {
+		m.preferences.Background = preferences.LightBackground
+	}
+	m.savePreferences()
+	m.statusMessage = "Colours for a " + m.preferences.Background + " background"
+	m.redrawTheme()
+}
+
+// toggleASCII switches between plain ASCII and the full character set, for
+// terminals the guess got wrong.
+func (m *Model) toggleASCII() {
+	m.theme.ASCII = !m.theme.ASCII
+	m.preferences.Charset = preferences.UnicodeCharset
+	m.statusMessage = "Drawing with the full character set"
+	if m.theme.ASCII {
+		m.preferences.Charset = preferences.ASCIICharset
+		m.statusMessage = "Drawing with plain ASCII"
+	}
+	m.savePreferences()
+	if err := m.renderBranding(); err != nil {
+		reportError(m.hub, "could not redraw branding", err)
+	}
+	m.redrawTheme()
+}
+
+func (m *Model) redrawTheme() {
+	m.rerender()
+	switch m.currentView {
+	case helpView:
+		m.viewport.SetContent(m.helpContent())
+	case adminView:
+		m.viewport.SetContent(m.adminContent())
+	case historyView:
+		m.viewport.SetContent(m.historyContent())
+	}
+	m.resizeSection()
+}
+
+// revealInList points the list at fileName, switching to its directory when
+// it was opened from somewhere else, so going back lands next to it.
+func (m *Model) revealInList(fileName string) {
+	if dir := utils.ParentDir(fileName); dir != m.currentDir {
+		m.currentDir = dir
+		m.clearFilter()
+	}
+	for i, name := range m.fileNames {
+		if name == fileName {
+			m.cursor = i
+		}
+	}
+}
+
+// breadcrumbs splits dir into the trail shown above the list and in the
+// reader's header, starting from the content root.
+func (m Model) breadcrumbs(dir string) []string {
+	crumbs := []string{"positions"}
+	if dir != "" {
+		crumbs = append(crumbs, strings.Split(dir, "/")...)
+	}
+	return crumbs
+}
+
+func (m Model) positionVisible(index int) bool {
+	for _, i := range m.visiblePositions() {
+		if i == index {
+			return true
+		}
+	}
+	return false
+}
+
+// openCursor opens the position or folder under the cursor.
+func (m *Model) openCursor() tea.Cmd {
+	if !m.positionVisible(m.cursor) {
+		return nil
+	}
+	fileName := m.fileNames[m.cursor]
+	if utils.IsDirEntry(fileName) {
+		m.enterDir(strings.TrimSuffix(fileName, "/"))
+		return nil
+	}
+	return m.openFile(fileName)
+}
+
+// moveCursor steps through the positions currently on screen.
+func (m *Model) moveCursor(delta int) {
+	visible := m.visiblePositions()
+	for i, index := range visible {
+		if index == m.cursor {
+			next := utils.Max(0, utils.Min(len(visible)-1, i+delta))
+			m.cursor = visible[next]
+			return
+		}
+	}
+	if len(visible) > 0 {
+		m.cursor = visible[0]
+	}
+}
+
+// stepPosition opens the previous or next position straight from the reader,
+// wrapping around at either end of what the list currently shows.
+func (m *Model) stepPosition(delta int) tea.Cmd {
+	var order []int
+	for _, index := range m.visiblePositions() {
+		if !utils.IsDirEntry(m.fileNames[index]) {
+			order = append(order, index)
+		}
+	}
+	if len(order) == 0 {
+		return nil
+	}
+
+	current := 0
+	for i, index := range order {
+		if m.fileNames[index] == m.selectedFileName {
+			current = i
+			break
+		}
+	}
+	m.cursor = order[(current+delta+len(order))%len(order)]
+	return m.openFile(m.fileNames[m.cursor])
+}
+
+func (m Model) updateFilter(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		return m, tea.Quit
+	case tea.KeyEsc:
+		m.clearFilter()
+		return m, nil
+	case tea.KeyEnter:
+		m.filtering = false
+		m.filterInput.Blur()
+		if m.filterInput.Value() == "" {
+			m.clearFilter()
+		}
+		return m, nil
+	case tea.KeyUp:
+		m.moveCursor(-1)
+		return m, nil
+	case tea.KeyDown:
+		m.moveCursor(1)
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.filterInput, cmd = m.filterInput.Update(msg)
+	m.applyFilter()
+	return m, cmd
+}
+
+// jumpToMatch scrolls the reader to the rendered line that best corresponds to
+// line of the markdown source. Rendering wraps and restyles text, so the line
+// is found by looking for the query terms nearest to where it should be.
+func (m *Model) jumpToMatch(query string, line int) {
+	if line < 0 {
+		return
+	}
+	terms := search.Tokenize(query)
+	rendered := strings.Split(utils.StripANSI(m.renderedContent), "\n")
+	sourceLines := utils.Max(1, strings.Count(m.fileContent, "\n")+1)
+	estimate := line * len(rendered) / sourceLines
+
+	best := -1
+	for i, renderedLine := range rendered {
+		renderedLine = strings.ToLower(renderedLine)
+		for _, term := range terms {
+			if strings.Contains(renderedLine, term) {
+				if best < 0 || utils.Abs(i-estimate) < utils.Abs(best-estimate) {
+					best = i
+				}
+				break
+			}
+		}
+	}
+	if best >= 0 {
+		m.viewport.SetYOffset(best)
+	}
+}
+
+func (m Model) updateSearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		return m, tea.Quit
+	case tea.KeyEsc:
+		m.searchInput.Blur()
+		m.currentView = fileListView
+		return m, nil
+	case tea.KeyUp:
+		if m.searchCursor > 0 {
+			m.searchCursor--
+		}
+		return m, nil
+	case tea.KeyDown:
+		if m.searchCursor < len(m.searchResults)-1 {
+			m.searchCursor++
+		}
+		return m, nil
+	case tea.KeyEnter:
+		if len(m.searchResults) > 0 {
+			result := m.searchResults[m.searchCursor]
+			m.searchInput.Blur()
+			m.revealInList(result.FileName)
+			return m, m.loadFile(fileLoad{fileName: result.FileName, offset: -1, query: m.searchInput.Value(), line: result.Line})
+		}
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.searchInput, cmd = m.searchInput.Update(msg)
+	m.searchResults = m.searchPositions(m.searchInput.Value())
+	m.searchCursor = utils.Min(m.searchCursor, utils.Max(0, len(m.searchResults)-1))
+	return m, cmd
+}
+
+func (m *Model) openHelp() {
+	m.previousView = m.currentView
+	m.previousOffset = m.viewport.YOffset
+	m.currentView = helpView
+	m.viewport.SetContent(m.helpContent())
+	m.viewport.GotoTop()
+}
+
+// closeHelp goes back to the screen and tab help was opened from, scrolled
+// where it was left.
+func (m *Model) closeHelp() {
+	m.currentView = m.previousView
+	m.viewport.SetContent(m.renderedContent)
+	if m.currentView == historyView {
+		m.viewport.SetContent(m.historyContent())
+	}
+	m.viewport.SetYOffset(m.previousOffset)
+	m.switchTab(m.previousTab)
+	m.previousTab = positionsTab
+}
+
+func (m Model) helpContent() string {
+	var s string
+	for _, group := range m.keys.helpGroups() {
+		var bindings [][2]string
+		for _, binding := range group.bindings {
+			if binding.Enabled() {
+				bindings = append(bindings, [2]string{binding.Help().Key, binding.Help().Desc})
+			}
+		}
+		if len(bindings) == 0 {
+			continue
+		}
+		s += components.HelpSectionView(m.theme, utils.Max(0, m.viewport.Width-2), group.title, group.description, bindings)
+	}
+	return s
+}
+
+func (m Model) filteredListView() string {
+	s := " " + m.filterInput.View() + "\n\n"
+	if m.filterInput.Value() == "" {
+		return s
+	}
+
+	var titles, descriptions []string
+	var titleMatches, descriptionMatches [][]int
+	cursor := -1
+	for i, match := range m.filterMatches {
+		titles = append(titles, m.fileTitles[match.index])
+		descriptions = append(descriptions, m.fileDescriptions[match.index])
+		titleMatches = append(titleMatches, match.titleMatches)
+		descriptionMatches = append(descriptionMatches, match.descriptionMatches)
+		if match.index == m.cursor {
+			cursor = i
+		}
+	}
+	return s + components.FilteredPositionsView(m.theme, m.viewport.Width, titles, descriptions, titleMatches, descriptionMatches, cursor)
+}
+
 func (m Model) HeaderView() string {
-	title := components.HeaderStyle.Render(m.selectedFileName)
+	titleText := m.selectedTitle()
+	if dir := utils.ParentDir(m.selectedFileName); dir != "" {
+		titleText = strings.Join(append(m.breadcrumbs(dir), titleText), " › ")
+	}
+	if m.currentView == fileContentView && m.selectedMetadata().Draft() {
+		titleText += " (draft)"
+	}
+	if m.raw && m.currentView == fileContentView && m.markdownOpen() {
+		titleText += " (source)"
+	}
+	if m.currentView == historyView {
+		titleText += " (history)"
+		if m.showingDiff {
+			titleText += " (latest change)"
+		}
+	}
+	if m.currentView == helpView {
+		titleText = "Help"
+	}
+	if m.currentView == adminView {
+		titleText = "Admin"
+	}
+	// The frame is measured rendered: the border is only implied, so
+	// GetHorizontalFrameSize leaves it out.
+	titleWidth := m.viewport.Width - lipgloss.Width(components.HeaderStyle(m.theme).Render(""))
+	title := components.HeaderStyle(m.theme).Render(utils.Truncate(titleText, titleWidth))
 	line := strings.Repeat(lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#fcd34d")).
+		Foreground(m.theme.Accent).
 		Render("─"), utils.Max(0, m.viewport.Width-lipgloss.Width(title)))
 	return lipgloss.JoinHorizontal(lipgloss.Center, title, line)
 }
 
 func (m Model) FooterView() string {
 	helpView := lipgloss.PlaceHorizontal(m.viewport.Width, lipgloss.Right, m.help.View(m.keys))
+	if m.readerSearching {
+		helpView = lipgloss.PlaceHorizontal(m.viewport.Width, lipgloss.Left, " "+m.readerInput.View())
+	}
+	if m.broadcasting {
+		helpView = lipgloss.PlaceHorizontal(m.viewport.Width, lipgloss.Left, " "+m.adminInput.View())
+	}
 
-	info := components.FooterStyle.Render(fmt.Sprintf("%3.f%%", m.viewport.ScrollPercent()*100))
+	info := components.FooterStyle(m.theme).Render(fmt.Sprintf("%3.f%%", m.viewport.ScrollPercent()*100))
+	if m.browsing > 0 {
+		info = lipgloss.JoinHorizontal(lipgloss.Center, components.BrowsingView(m.browsing), info)
+	}
+	status := ""
+	if message := m.statusMessage; message != "" || m.readerSearchStatus() != "" || m.notice != "" {
+		if m.notice != "" {
+			message = m.notice
+		} else if message == "" {
+			message = m.readerSearchStatus()
+		}
+		status = components.StatusMessageView(m.theme, message)
+		status = strings.TrimSuffix(status, "\n")
+	}
 	line := strings.Repeat(lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#fcd34d")).
-		Render("─"), utils.Max(0, m.viewport.Width-lipgloss.Width(info)))
-	footerInfo := lipgloss.JoinHorizontal(lipgloss.Center, line, info)
+		Foreground(m.theme.Accent).
+		Render("─"), utils.Max(0, m.viewport.Width-lipgloss.Width(info)-lipgloss.Width(status)))
+	footerInfo := lipgloss.JoinHorizontal(lipgloss.Center, status, line, info)
 
 	return helpView + "\n" + footerInfo
 }
 
+// resizeViewport gives the viewport whatever height the header, footer and
+// announcement leave over.
+func (m *Model) resizeViewport() {
+	if !m.ready {
+		return
+	}
+	verticalMarginHeight := lipgloss.Height(m.HeaderView()) + lipgloss.Height(m.FooterView()) + strings.Count(m.announcementView(), "\n")
+	m.viewport.Height = utils.Max(0, m.terminalHeight-verticalMarginHeight)
+}
+
+func (m Model) announcementView() string {
+	if m.announcement.text == "" {
+		return ""
+	}
+	return components.AnnouncementView(m.theme, m.viewport.Width, m.announcement.text) + "\n"
+}
+
 func (m Model) View() string {
-	if m.currentView == fileListView {
-		s := components.TextWithBackgroundView("#fcd34d", " __THE_SUPREME_AND_POWERFUL_JODC_GANG__ ", true, false)
-		s += lipgloss.JoinHorizontal(lipgloss.Top, m.catimgOutput) + "\n"
+	view := m.announcementView() + m.screenView()
+	if m.theme.ASCII {
+		view = utils.ToASCII(view)
+	}
+	view = utils.DowngradeColors(view, m.theme.Profile)
+	if m.graphics != nil {
+		view = m.graphics.place(view, m.terminalHeight)
+	}
+	return view
+}
+
+// logoView leaves room for the logo's bitmap on terminals that can show one,
+// unless the visitor wants plain characters or no colours.
+func (m Model) logoView() string {
+	if m.graphics == nil || m.theme.ASCII || m.theme.Profile == termenv.Ascii {
+		return m.logoOutput
+	}
+	return m.graphics.placeholder(logoPadding)
+}
+
+// listHeaderView is everything the positions list shows above the positions
+// on a page of the grid. Pages after the first leave out the banner and the
+// introduction to make room for more positions.
+func (m Model) listHeaderView(page int) string {
+	s := ""
+	if page == 0 {
+		s += components.BannerView(m.theme, m.viewport.Width)
+	}
+	s += m.tabBarView()
+	s += components.NoticeView(m.theme, m.viewport.Width, m.notice)
+	if page == 0 {
+		s += components.BrandingView(m.viewport.Width, m.logoView(), m.qrOutput)
 		s += components.IntroDescriptionView(m.viewport.Width)
-		s += components.OpenPositionsGrid(m.viewport.Width, m.fileNames, m.fileDescriptions, m.cursor)
+		if m.config.Tracking && m.config.PrivacyNotice != "" {
+			s += components.PrivacyNoticeView(m.viewport.Width, m.config.PrivacyNotice)
+		}
+	}
+	if m.currentDir != "" {
+		s += components.BreadcrumbView(m.theme, m.breadcrumbs(m.currentDir))
+	}
+	if tags := m.categories(); len(tags) > 0 {
+		active := 0
+		for i, tag := range tags {
+			if tag == m.activeTag {
+				active = i + 1
+			}
+		}
+		s += components.CategoryBarView(m.theme, m.viewport.Width, append([]string{"all"}, tags...), active) + "\n"
+	}
+	if !m.filterActive() {
+		s += components.SortView(m.theme, m.viewport.Width, m.sortOrder.String(), m.keys.Sort.Help().Key)
+	}
+	return s
+}
+
+func (m Model) screenView() string {
+	if m.splashing {
+		return components.SplashView(m.theme, m.viewport.Width, m.terminalHeight-strings.Count(m.announcementView(), "\n"), m.splashFrame)
+	}
+	if m.tab != positionsTab {
+		return m.sectionView()
+	}
+	if m.currentView == applyView {
+		return m.HeaderView() + "\n" + components.NoticeView(m.theme, m.viewport.Width, m.notice) + m.applyFormView()
+	}
+	if m.currentView == searchView {
+		s := components.TextWithBackgroundView(m.theme, m.theme.Accent, " SEARCH ", true, false)
+		s += " " + m.searchInput.View() + "\n\n"
+		s += components.SearchResultsView(m.theme, m.viewport.Width, m.searchResults, m.searchCursor)
+		return s
+	}
+	if m.currentView == fileListView {
+		pages, page := m.gridPages()
+		if m.filterActive() {
+			page = 0
+		}
+		s := m.listHeaderView(page)
+		if m.filterActive() {
+			s += m.filteredListView()
+		} else {
+			s += components.OpenPositionsGrid(m.theme, m.viewport.Width, m.config.GridColumns, m.gridTitles(), m.fileDescriptions, m.cardBadges(), pages[page], m.gridPinned() && page == 0, m.cursor)
+			if pager := m.pagerView(len(pages), page); pager != "" {
+				s += "\n" + pager
+			}
+		}
 		s += "\n"
+		if m.browsing > 0 {
+			s += components.BrowsingView(m.browsing) + "\n"
+		}
+		if m.statusMessage != "" {
+			s += components.StatusMessageView(m.theme, m.statusMessage)
+		}
 
 		return fmt.Sprint(s)
 	} else {
-		return fmt.Sprintf("%s\n%s\n%s", m.HeaderView(), m.viewport.View(), m.FooterView())
+		body := m.viewport.View()
+		if m.currentView == fileContentView {
+			body = m.readerView()
+		}
+		return fmt.Sprintf("%s\n%s\n%s", m.HeaderView(), body, m.FooterView())
 	}
 }
 
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"organize/utils"
+
+	"github.com/charmbracelet/log"
+	"github.com/getsentry/sentry-go"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// markdown renders positions to HTML. Raw HTML in the markdown is escaped,
+// like the TUI shows it as text.
+var markdown = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+// mirrorLayout wraps every page of the mirror in the JODC colours; pages
+// fill in the "content" template.
+var mirrorLayout = template.Must(template.New("layout").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<link rel="alternate" type="application/rss+xml" title="JODC open positions" href="/feed.xml">
+<style>
+body { background: #1a1a1a; color: #d0d0d0; font-family: ui-monospace, SFMono-Regular, Menlo, monospace; line-height: 1.6; margin: 0 auto; max-width: 52rem; padding: 2rem 1rem; }
+a { color: #fcd34d; }
+header { border-bottom: 1px solid #fcd34d; margin-bottom: 2rem; padding-bottom: 1rem; }
+header .gang { background: #fcd34d; color: #000; font-weight: bold; padding: 0.2rem 0.6rem; text-decoration: none; }
+.ssh { color: #888; margin-left: 1rem; }
+.card { border: 1px solid #444; border-radius: 0.4rem; display: block; margin-bottom: 1rem; padding: 0.8rem 1rem; text-decoration: none; }
+.card:hover { border-color: #fcd34d; }
+.card .title { color: #ff5fd7; font-weight: bold; }
+.card .description { color: #d0d0d0; }
+.tag { border: 1px solid #666; border-radius: 1rem; color: #fcd34d; font-size: 0.8rem; margin-right: 0.4rem; padding: 0 0.5rem; }
+.status { color: #f87171; font-size: 0.8rem; margin-left: 0.5rem; text-transform: uppercase; }
+article h1, article h2, article h3 { color: #fcd34d; }
+article code, article pre { background: #262626; }
+article pre { overflow-x: auto; padding: 0.8rem; }
+.apply { background: #fcd34d; color: #000; display: inline-block; font-weight: bold; margin-top: 2rem; padding: 0.4rem 1rem; text-decoration: none; }
+</style>
+</head>
+<body>
+<header><a class="gang" href="/">__THE_SUPREME_AND_POWERFUL_JODC_GANG__</a><span class="ssh">{{.Reconnect}}</span></header>
+{{template "content" .}}
+</body>
+</html>`))
+
+var (
+	indexTemplate = mirrorPage(`{{range .Positions}}<a class="card" href="/positions/{{.File}}">
+<span class="title">{{.Title}}</span>{{if ne .Status "open"}}<span class="status">{{.Status}}</span>{{end}}
+<div class="description">{{.Description}}</div>
+<div>{{range .Tags}}<span class="tag">{{.}}</span>{{end}}</div>
+</a>
+{{else}}<p>Nothing to see here yet.</p>
+{{end}}`)
+	positionTemplate = mirrorPage(`<article>{{.Body}}</article>
+{{if eq .Position.Status "open"}}<a class="apply" href="{{.Position.ApplyURL}}">Apply</a>{{end}}
+`)
+)
+
+func mirrorPage(content string) *template.Template {
+	page := template.Must(mirrorLayout.Clone())
+	return template.Must(page.Parse(`{{define "content"}}` + content + `{{end}}`))
+}
+
+type mirrorData struct {
+	Title     string
+	Reconnect string
+	Positions []positionJSON
+	Position  positionJSON
+	Body      template.HTML
+}
+
+func renderMirror(w http.ResponseWriter, page *template.Template, data mirrorData) {
+	var b bytes.Buffer
+	if err := page.Execute(&b, data); err != nil {
+		reportError(sentry.CurrentHub(), "could not render page", err)
+		http.Error(w, "could not render page", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(b.Bytes())
+}
+
+// mirrorHandlers serve the board as HTML: the list of positions at / and
+// each position at /positions/<file>, from the same content as the TUI.
+func mirrorHandlers(mux *http.ServeMux, live *liveConfig) {
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		cfg := live.load()
+		positions, err := listPositions(cfg)
+		if err != nil {
+			log.Error("could not list positions", "error", err)
+			http.Error(w, "could not list positions", http.StatusInternalServerError)
+			return
+		}
+		renderMirror(w, indexTemplate, mirrorData{
+			Title:     "JODC open positions",
+			Reconnect: reconnectCommand(cfg),
+			Positions: positions,
+		})
+	})
+	mux.HandleFunc("/positions/", func(w http.ResponseWriter, r *http.Request) {
+		fileName := strings.TrimPrefix(r.URL.Path, "/positions/")
+		cfg := live.load()
+		positions, err := listPositions(cfg)
+		if err != nil {
+			log.Error("could not list positions", "error", err)
+			http.Error(w, "could not list positions", http.StatusInternalServerError)
+			return
+		}
+		for _, position := range positions {
+			if position.File != fileName {
+				continue
+			}
+			// Browsers show images fine on their own.
+			if utils.IsImage(fileName) {
+				path, _, err := positionPath(cfg, fileName)
+				if err != nil {
+					http.NotFound(w, r)
+					return
+				}
+				http.ServeFile(w, r, path)
+				return
+			}
+			body, err := readPosition(cfg, fileName)
+			if err != nil {
+				http.NotFound(w, r)
+				return
+			}
+			var rendered bytes.Buffer
+			if utils.CodeLanguage(fileName) != "" {
+				rendered.WriteString("<pre><code>" + template.HTMLEscapeString(body) + "</code></pre>")
+			} else if err := markdown.Convert([]byte(body), &rendered); err != nil {
+				reportError(sentry.CurrentHub(), "could not render position", err, "file", fileName)
+				http.Error(w, "could not render position", http.StatusInternalServerError)
+				return
+			}
+			renderMirror(w, positionTemplate, mirrorData{
+				Title:     position.Title + " · JODC",
+				Reconnect: reconnectCommand(cfg),
+				Position:  position,
+				Body:      template.HTML(rendered.String()),
+			})
+			return
+		}
+		http.NotFound(w, r)
+	})
+}
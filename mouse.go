@@ -0,0 +1,46 @@
+package main
+
+import (
+	"organize/components"
+	"organize/utils"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// updateListMouse lets the positions list be used with a mouse: the wheel
+// moves the cursor, clicking a card selects it and clicking the selected card
+// opens it.
+func (m Model) updateListMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case msg.Button == tea.MouseButtonWheelUp:
+		m.moveCursor(-1)
+	case msg.Button == tea.MouseButtonWheelDown:
+		m.moveCursor(1)
+	case msg.Button == tea.MouseButtonLeft && msg.Action == tea.MouseActionPress:
+		position := m.gridPositionAt(msg.X, msg.Y)
+		if position < 0 {
+			break
+		}
+		if position == m.cursor {
+			return m, m.openCursor()
+		} else {
+			m.cursor = position
+			m.statusMessage = ""
+		}
+	}
+	return m, nil
+}
+
+// gridPositionAt is the position whose card is at column x and row y of the
+// screen, or -1.
+func (m Model) gridPositionAt(x int, y int) int {
+	if m.filterActive() {
+		return -1
+	}
+	// The renderer drops the top of views taller than the screen.
+	hidden := utils.Max(0, lipgloss.Height(m.announcementView()+m.screenView())-m.terminalHeight)
+	pages, page := m.gridPages()
+	top := lipgloss.Height(m.announcementView()+m.listHeaderView(page)) - 1
+	return components.GridPositionAt(m.theme, m.viewport.Width, m.config.GridColumns, m.gridTitles(), m.fileDescriptions, m.cardBadges(), pages[page], m.gridPinned() && page == 0, m.cursor, x, y+hidden-top)
+}
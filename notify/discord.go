@@ -0,0 +1,161 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"organize/applications"
+	"organize/feedback"
+
+	"github.com/charmbracelet/log"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	discordAttempts = 5
+	discordBackoff  = time.Second
+)
+
+// Discord posts an embed to a Discord webhook for every application, and for
+// feedback when asked to, retrying with exponential backoff before giving up
+// to the dead letters.
+type Discord struct {
+	webhookURL  string
+	client      *http.Client
+	deadLetters *DeadLetters
+	wg          sync.WaitGroup
+}
+
+func NewDiscord(webhookURL string, deadLetters *DeadLetters) *Discord {
+	return &Discord{
+		webhookURL:  webhookURL,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		deadLetters: deadLetters,
+	}
+}
+
+func (d *Discord) Notify(ctx context.Context, application applications.Application) {
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		if err := d.deliver(ctx, discordEmbed(application)); err != nil {
+			log.Error("could not notify discord", "position", application.Position, "error", err)
+			if err := d.deadLetters.Record("discord", application, err); err != nil {
+				log.Error("could not record undelivered notification", "error", err)
+			}
+		}
+	}()
+}
+
+func (d *Discord) NotifyFeedback(ctx context.Context, f feedback.Feedback) {
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		if err := d.deliver(ctx, feedbackEmbed(f)); err != nil {
+			log.Error("could not forward feedback to discord", "topic", f.Topic, "error", err)
+			if err := d.deadLetters.RecordFeedback("discord", f, err); err != nil {
+				log.Error("could not record undelivered notification", "error", err)
+			}
+		}
+	}()
+}
+
+func (d *Discord) Wait() {
+	d.wg.Wait()
+}
+
+// permanentError marks a response that retrying will not fix.
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (d *Discord) deliver(ctx context.Context, embed map[string]interface{}) (err error) {
+	_, span := tracer.Start(ctx, "discord.deliver")
+	defer func() { endSpan(span, err) }()
+	payload, err := json.Marshal(embed)
+	if err != nil {
+		return err
+	}
+
+	backoff := discordBackoff
+	for attempt := 1; ; attempt++ {
+		span.SetAttributes(attribute.Int("attempts", attempt))
+		wait, err := d.post(payload)
+		if err == nil {
+			return nil
+		}
+		var permanent permanentError
+		if errors.As(err, &permanent) || attempt == discordAttempts {
+			return fmt.Errorf("attempt %d: %w", attempt, err)
+		}
+		if wait < backoff {
+			wait = backoff
+		}
+		time.Sleep(wait)
+		backoff *= 2
+	}
+}
+
+// post sends payload once. When Discord rate limits the webhook it also
+// returns how long Discord asked us to wait.
+func (d *Discord) post(payload []byte) (time.Duration, error) {
+	resp, err := d.client.Post(d.webhookURL, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return 0, nil
+	case resp.StatusCode == http.StatusTooManyRequests:
+		seconds, _ := strconv.ParseFloat(resp.Header.Get("Retry-After"), 64)
+		return time.Duration(seconds * float64(time.Second)), errors.New(resp.Status)
+	case resp.StatusCode >= 500:
+		return 0, errors.New(resp.Status)
+	}
+	return 0, permanentError{errors.New(resp.Status)}
+}
+
+func discordEmbed(application applications.Application) map[string]interface{} {
+	applicant := application.User
+	if application.GitHub != "" {
+		applicant = fmt.Sprintf("[%s](https://github.com/%s) (ssh: %s)", application.GitHub, application.GitHub, application.User)
+	}
+	return map[string]interface{}{
+		"embeds": []map[string]interface{}{{
+			"title": "New application: " + application.Title,
+			"color": 0xfcd34d,
+			"fields": []map[string]interface{}{
+				{"name": "Position", "value": application.Position, "inline": true},
+				{"name": "Applicant", "value": applicant, "inline": true},
+			},
+			"timestamp": application.SubmittedAt.Format(time.RFC3339),
+		}},
+	}
+}
+
+func feedbackEmbed(f feedback.Feedback) map[string]interface{} {
+	return map[string]interface{}{
+		"embeds": []map[string]interface{}{{
+			"title":       "Feedback: " + f.Topic,
+			"description": f.Message,
+			"color":       0x60a5fa,
+			"fields": []map[string]interface{}{
+				{"name": "From", "value": "ssh: " + f.User, "inline": true},
+			},
+			"timestamp": f.SubmittedAt.Format(time.RFC3339),
+		}},
+	}
+}
@@ -0,0 +1,159 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"organize/applications"
+
+	"github.com/charmbracelet/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	mailAttempts = 3
+	mailBackoff  = 2 * time.Second
+)
+
+type MailOptions struct {
+	Host        string
+	Port        int
+	Username    string
+	Password    string
+	From        string
+	Maintainers string
+}
+
+// Mailer sends every applicant a confirmation and tells the maintainers about
+// the application, in the background so the form returns immediately.
+type Mailer struct {
+	opts        MailOptions
+	deadLetters *DeadLetters
+	wg          sync.WaitGroup
+}
+
+func NewMailer(opts MailOptions, deadLetters *DeadLetters) *Mailer {
+	return &Mailer{opts: opts, deadLetters: deadLetters}
+}
+
+func (m *Mailer) Notify(ctx context.Context, application applications.Application) {
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		subject, body := confirmationMail(application)
+		m.send(ctx, application, "email confirmation", []string{application.Email}, subject, body)
+		if m.opts.Maintainers != "" {
+			subject, body := maintainersMail(application)
+			m.send(ctx, application, "email to maintainers", m.maintainers(), subject, body)
+		}
+	}()
+}
+
+// maintainers splits the comma-separated Maintainers option, so every
+// address is a recipient of its own rather than one made-up mailbox.
+func (m *Mailer) maintainers() []string {
+	addresses, err := mail.ParseAddressList(m.opts.Maintainers)
+	if err != nil {
+		return strings.Split(m.opts.Maintainers, ",")
+	}
+	to := make([]string, len(addresses))
+	for i, address := range addresses {
+		to[i] = address.String()
+	}
+	return to
+}
+
+func (m *Mailer) Wait() {
+	m.wg.Wait()
+}
+
+func (m *Mailer) send(ctx context.Context, application applications.Application, kind string, to []string, subject string, body string) {
+	message := m.message(to, subject, body)
+
+	var err error
+	_, span := tracer.Start(ctx, "smtp.send", trace.WithAttributes(attribute.String("kind", kind)))
+	defer func() { endSpan(span, err) }()
+	backoff := mailBackoff
+	for attempt := 1; attempt <= mailAttempts; attempt++ {
+		span.SetAttributes(attribute.Int("attempts", attempt))
+		if err = m.sendMail(to, message); err == nil {
+			return
+		}
+		if attempt < mailAttempts {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+
+	log.Error("could not send "+kind, "position", application.Position, "error", err)
+	if err := m.deadLetters.Record(kind, application, err); err != nil {
+		log.Error("could not record undelivered notification", "error", err)
+	}
+}
+
+func (m *Mailer) sendMail(to []string, message []byte) error {
+	var auth smtp.Auth
+	if m.opts.Username != "" {
+		auth = smtp.PlainAuth("", m.opts.Username, m.opts.Password, m.opts.Host)
+	}
+	// The envelope wants bare addresses, without display names.
+	recipients := make([]string, len(to))
+	for i, recipient := range to {
+		recipients[i] = bareAddress(recipient)
+	}
+	addr := net.JoinHostPort(m.opts.Host, strconv.Itoa(m.opts.Port))
+	return smtp.SendMail(addr, auth, bareAddress(m.opts.From), recipients, message)
+}
+
+func bareAddress(s string) string {
+	if address, err := mail.ParseAddress(s); err == nil {
+		return address.Address
+	}
+	return strings.TrimSpace(s)
+}
+
+// message puts the subject in RFC 2047 encoded words, since position titles
+// and applicant names need not be ASCII and headers must be.
+func (m *Mailer) message(to []string, subject string, body string) []byte {
+	recipients := make([]string, len(to))
+	for i, recipient := range to {
+		recipients[i] = strings.TrimSpace(recipient)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", m.opts.From)
+	fmt.Fprintf(&b, "To: %s\r\n", headerSafe(strings.Join(recipients, ", ")))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("UTF-8", headerSafe(subject)))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+// headerSafe keeps applicant input from starting new mail headers.
+func headerSafe(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
+
+func confirmationMail(application applications.Application) (string, string) {
+	return "We got your application for " + application.Title,
+		fmt.Sprintf("Hi %s,\n\nthanks for applying for %s at JODC! We will read your answer and get back to you soon.\n\nJIIT Open Source Developers Club\n",
+			application.Name, application.Title)
+}
+
+func maintainersMail(application applications.Application) (string, string) {
+	return fmt.Sprintf("New application for %s from %s", application.Title, application.Name),
+		fmt.Sprintf("Position: %s (%s)\nName: %s\nEmail: %s\nGitHub: https://github.com/%s\nSSH user: %s\nSubmitted: %s\n\n%s\n",
+			application.Title, application.Position, application.Name, application.Email, application.GitHub,
+			application.User, application.SubmittedAt.Format(time.RFC1123), application.Answer)
+}
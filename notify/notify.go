@@ -0,0 +1,94 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"organize/applications"
+	"organize/feedback"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer traces every delivery, under the session that asked for it when ctx
+// carries one.
+var tracer = otel.Tracer("organize/notify")
+
+// endSpan records how a delivery went and ends its span.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Notifier tells someone about a new application. Notify must not block the
+// session that submitted it, and Wait blocks until every notification that
+// is still in flight has been delivered or given up on.
+type Notifier interface {
+	Notify(ctx context.Context, application applications.Application)
+	Wait()
+}
+
+// DeadLetters records notifications that could not be delivered, one JSON
+// object per line, so they can be looked at and resent by hand.
+type DeadLetters struct {
+	mu   sync.Mutex
+	path string
+}
+
+type deadLetter struct {
+	Notifier    string                    `json:"notifier"`
+	Error       string                    `json:"error"`
+	FailedAt    time.Time                 `json:"failed_at"`
+	Application *applications.Application `json:"application,omitempty"`
+	Feedback    *feedback.Feedback        `json:"feedback,omitempty"`
+}
+
+func NewDeadLetters(path string) *DeadLetters {
+	return &DeadLetters{path: path}
+}
+
+func (d *DeadLetters) Record(notifier string, application applications.Application, cause error) error {
+	return d.write(deadLetter{
+		Notifier:    notifier,
+		Error:       cause.Error(),
+		FailedAt:    time.Now(),
+		Application: &application,
+	})
+}
+
+func (d *DeadLetters) RecordFeedback(notifier string, f feedback.Feedback, cause error) error {
+	return d.write(deadLetter{
+		Notifier: notifier,
+		Error:    cause.Error(),
+		FailedAt: time.Now(),
+		Feedback: &f,
+	})
+}
+
+func (d *DeadLetters) write(letter deadLetter) error {
+	line, err := json.Marshal(letter)
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	file, err := os.OpenFile(d.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
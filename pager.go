@@ -0,0 +1,79 @@
+package main
+
+import (
+	"strings"
+
+	"organize/components"
+	"organize/utils"
+
+	"github.com/charmbracelet/bubbles/paginator"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// listFooterHeight is how many lines the list keeps free under the grid for
+// the page dots, the browsing count and the status message.
+const listFooterHeight = 3
+
+// newPager turns the grid's pages with the session's page keys.
+func newPager(keys keyMap) paginator.Model {
+	pager := paginator.New()
+	pager.Type = paginator.Dots
+	pager.KeyMap.PrevPage = keys.PageUp
+	pager.KeyMap.NextPage = keys.PageDown
+	return pager
+}
+
+// gridPages splits the grid into pages that fit on the screen under the list
+// header and finds the one the cursor is on. The first page gets at least half
+// the screen, letting the top of a tall header scroll away on short
+// terminals. Before the terminal's height is known everything is on one page.
+func (m Model) gridPages() ([][]int, int) {
+	visible := m.visiblePositions()
+	if m.terminalHeight <= 0 {
+		return [][]int{visible}, 0
+	}
+	space := func(page int) int {
+		return m.terminalHeight - lipgloss.Height(m.announcementView()+m.listHeaderView(page)) - listFooterHeight
+	}
+	first := utils.Max(space(0), m.terminalHeight/2)
+	pages := components.GridPages(m.theme, m.viewport.Width, m.config.GridColumns, m.gridTitles(), m.fileDescriptions, m.cardBadges(), visible, m.gridPinned(), first, space(1))
+	for i, page := range pages {
+		for _, position := range page {
+			if position == m.cursor {
+				return pages, i
+			}
+		}
+	}
+	return pages, 0
+}
+
+// turnPage moves the cursor to the top of the page before or after the one
+// it is on.
+func (m *Model) turnPage(msg tea.KeyMsg) {
+	pages, page := m.gridPages()
+	m.pager.TotalPages, m.pager.Page = len(pages), page
+	m.pager, _ = m.pager.Update(msg)
+	if m.pager.Page != page && len(pages[m.pager.Page]) > 0 {
+		m.cursor = pages[m.pager.Page][0]
+	}
+}
+
+// pagerView is the page dots under the grid, left out while everything fits
+// on one page.
+func (m Model) pagerView(pages int, page int) string {
+	if pages < 2 {
+		return ""
+	}
+	pager := m.pager
+	pager.TotalPages, pager.Page = pages, page
+	pager.ActiveDot = lipgloss.NewStyle().Foreground(m.theme.Accent).Render("● ")
+	pager.InactiveDot = lipgloss.NewStyle().Foreground(m.theme.Muted).Render("○ ")
+	dots := pager.View()
+	if lipgloss.Width(dots) > m.viewport.Width/2 {
+		// Too many to count at a glance; the page numbers will do.
+		dots = ""
+	}
+	keys := strings.Join([]string{m.keys.PageUp.Help().Key, m.keys.PageDown.Help().Key}, "/")
+	return components.PagerView(m.theme, m.viewport.Width, dots, page+1, pages, keys+" to turn")
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+// debugSession is a running session as /debug/sessions lists it.
+type debugSession struct {
+	sessionInfo
+	ID       string   `json:"id,omitempty"`
+	Duration string   `json:"duration"`
+	Viewed   []string `json:"viewed,omitempty"`
+}
+
+// debugRuntime is the memory and goroutine counts /debug/runtime reports, to
+// set against the number of sessions when memory keeps growing.
+type debugRuntime struct {
+	Sessions     int    `json:"sessions"`
+	Goroutines   int    `json:"goroutines"`
+	HeapAlloc    uint64 `json:"heap_alloc_bytes"`
+	HeapObjects  uint64 `json:"heap_objects"`
+	Sys          uint64 `json:"sys_bytes"`
+	NumGC        uint32 `json:"num_gc"`
+	PauseTotalNs uint64 `json:"gc_pause_total_ns"`
+}
+
+// newPprofServer serves the pprof profiles, which include full goroutine
+// dumps at /debug/pprof/goroutine?debug=2, along with /debug/sessions and
+// /debug/runtime. Only loopback and unix socket clients get an answer.
+func newPprofServer(addr string, sessions *sessionRegistry) *http.Server {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+			log.Warn("pprof is listening on a non-loopback address, only local clients will be answered", "addr", addr)
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc("/debug/sessions", func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now()
+		list := []debugSession{}
+		for _, info := range sessions.list() {
+			session := debugSession{sessionInfo: info, Duration: now.Sub(info.ConnectedAt).Round(time.Second).String()}
+			if info.record != nil {
+				session.ID, session.Viewed = info.record.id, info.record.files()
+			}
+			list = append(list, session)
+		}
+		writeDebug(w, list)
+	})
+	mux.HandleFunc("/debug/runtime", func(w http.ResponseWriter, r *http.Request) {
+		var stats runtime.MemStats
+		runtime.ReadMemStats(&stats)
+		writeDebug(w, debugRuntime{
+			Sessions:     sessions.count(),
+			Goroutines:   runtime.NumGoroutine(),
+			HeapAlloc:    stats.HeapAlloc,
+			HeapObjects:  stats.HeapObjects,
+			Sys:          stats.Sys,
+			NumGC:        stats.NumGC,
+			PauseTotalNs: stats.PauseTotalNs,
+		})
+	})
+
+	return &http.Server{Addr: addr, Handler: localOnly(mux)}
+}
+
+// localOnly refuses anyone connecting from another machine, should the
+// listener be reachable from one.
+func localOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Unix socket clients have no address to check.
+		if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+			if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+				http.Error(w, "debug endpoints only answer local clients", http.StatusForbidden)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func writeDebug(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(v)
+}
@@ -0,0 +1,174 @@
+package preferences
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"organize/utils"
+)
+
+// MaxLastRead is how many recently read positions are remembered per visitor.
+const MaxLastRead = 10
+
+// Backgrounds a visitor can pick over the one their terminal reports.
+const (
+	LightBackground = "light"
+	DarkBackground  = "dark"
+)
+
+// Character sets a visitor can pick over the one guessed from their terminal.
+const (
+	ASCIICharset   = "ascii"
+	UnicodeCharset = "unicode"
+)
+
+// Preferences is what a returning visitor keeps between sessions. Visitors
+// are told apart by the fingerprint of the public key they connect with.
+type Preferences struct {
+	LastRead  []string  `json:"last_read,omitempty"`
+	Favorites []string  `json:"favorites,omitempty"`
+	LastVisit time.Time `json:"last_visit,omitempty"`
+	Theme     string    `json:"theme,omitempty"`
+	// Background is empty while the terminal's own answer is trusted.
+	Background string `json:"background,omitempty"`
+	// Charset is empty while the character set is guessed from the terminal.
+	Charset string `json:"charset,omitempty"`
+	// Scroll is how far down each position was left, as a fraction of its
+	// length so it carries over to terminals of another width.
+	Scroll map[string]float64 `json:"scroll,omitempty"`
+}
+
+// Settings are only the choices the visitor made about how the board looks,
+// without what they read, starred or when they were last here, for servers
+// that don't track visitors.
+func (p Preferences) Settings() Preferences {
+	return Preferences{Theme: p.Theme, Background: p.Background, Charset: p.Charset}
+}
+
+// clone copies p, so the copy can be kept while p changes.
+func (p Preferences) clone() Preferences {
+	p.LastRead = append([]string(nil), p.LastRead...)
+	p.Favorites = append([]string(nil), p.Favorites...)
+	if p.Scroll != nil {
+		scroll := make(map[string]float64, len(p.Scroll))
+		for position, scrolled := range p.Scroll {
+			scroll[position] = scrolled
+		}
+		p.Scroll = scroll
+	}
+	return p
+}
+
+// Read moves position to the front of the recently read list.
+func (p *Preferences) Read(position string) {
+	lastRead := []string{position}
+	for _, name := range p.LastRead {
+		if name != position && len(lastRead) < MaxLastRead {
+			lastRead = append(lastRead, name)
+		}
+	}
+	p.LastRead = lastRead
+}
+
+func (p Preferences) IsFavorite(position string) bool {
+	for _, name := range p.Favorites {
+		if name == position {
+			return true
+		}
+	}
+	return false
+}
+
+// ToggleFavorite stars position, or unstars it if it already was, and
+// reports whether it is starred now.
+func (p *Preferences) ToggleFavorite(position string) bool {
+	for i, name := range p.Favorites {
+		if name == position {
+			p.Favorites = append(p.Favorites[:i:i], p.Favorites[i+1:]...)
+			return false
+		}
+	}
+	p.Favorites = append(p.Favorites, position)
+	return true
+}
+
+type Store interface {
+	Preferences(fingerprint string) (Preferences, error)
+	SavePreferences(fingerprint string, preferences Preferences) error
+}
+
+// FileStore keeps every visitor's preferences in one JSON object keyed by
+// fingerprint.
+type FileStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+func (s *FileStore) Preferences(fingerprint string) (Preferences, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	all, err := s.read()
+	if err != nil {
+		return Preferences{}, err
+	}
+	return all[fingerprint], nil
+}
+
+func (s *FileStore) SavePreferences(fingerprint string, preferences Preferences) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := utils.LockFile(s.path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	all, err := s.read()
+	if err != nil {
+		return err
+	}
+	all[fingerprint] = preferences
+	content, err := json.MarshalIndent(all, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+func (s *FileStore) read() (map[string]Preferences, error) {
+	all := make(map[string]Preferences)
+	content, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return all, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(content, &all); err != nil {
+		return nil, err
+	}
+	return all, nil
+}
@@ -0,0 +1,99 @@
+package preferences
+
+import (
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+const flushInterval = 5 * time.Second
+
+// Writer keeps the preferences sessions save in memory and writes them to its
+// store every flushInterval and on Close, so saving never waits on the disk
+// and a visitor opening one position after another costs one write, not one
+// each. Reads see what is still waiting to be written.
+type Writer struct {
+	mu      sync.Mutex
+	pending map[string]Preferences
+	store   Store
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+func NewWriter(store Store) *Writer {
+	w := &Writer{
+		pending: make(map[string]Preferences),
+		store:   store,
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+func (w *Writer) Preferences(fingerprint string) (Preferences, error) {
+	w.mu.Lock()
+	preferences, ok := w.pending[fingerprint]
+	w.mu.Unlock()
+	if ok {
+		return preferences.clone(), nil
+	}
+	return w.store.Preferences(fingerprint)
+}
+
+// SavePreferences queues preferences to be written with the next flush. It
+// takes a copy, so the session can go on changing its own.
+func (w *Writer) SavePreferences(fingerprint string, preferences Preferences) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.pending[fingerprint] = preferences.clone()
+	return nil
+}
+
+func (w *Writer) Flush() error {
+	w.mu.Lock()
+	pending := w.pending
+	w.pending = make(map[string]Preferences)
+	w.mu.Unlock()
+
+	for fingerprint, preferences := range pending {
+		if err := w.store.SavePreferences(fingerprint, preferences); err != nil {
+			// Keep whatever wasn't written for the next flush, unless the
+			// session has saved newer preferences since.
+			w.mu.Lock()
+			for fingerprint, preferences := range pending {
+				if _, newer := w.pending[fingerprint]; !newer {
+					w.pending[fingerprint] = preferences
+				}
+			}
+			w.mu.Unlock()
+			return err
+		}
+		delete(pending, fingerprint)
+	}
+	return nil
+}
+
+func (w *Writer) run() {
+	defer close(w.stopped)
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := w.Flush(); err != nil {
+				log.Error("could not save preferences", "error", err)
+			}
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// Close stops the periodic flush and writes out whatever is left.
+func (w *Writer) Close() error {
+	close(w.done)
+	<-w.stopped
+	return w.Flush()
+}
@@ -0,0 +1,148 @@
+// Package proxyproto reads the PROXY protocol header load balancers such as
+// HAProxy and AWS NLB put in front of a connection, so the server sees the
+// client's address instead of the balancer's. Versions 1 and 2 are supported.
+package proxyproto
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// v1MaxLength is the longest a version 1 header may be, CRLF included.
+const v1MaxLength = 107
+
+var (
+	v1Prefix    = []byte("PROXY ")
+	v2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+
+	ErrNoHeader = errors.New("proxyproto: connection did not start with a PROXY header")
+)
+
+// Conn is a connection with its PROXY header read off. RemoteAddr is the
+// client the balancer forwarded, or the balancer itself for health checks
+// that carry no address.
+type Conn struct {
+	net.Conn
+	reader *bufio.Reader
+	remote net.Addr
+}
+
+func (c *Conn) Read(b []byte) (int, error) { return c.reader.Read(b) }
+
+func (c *Conn) RemoteAddr() net.Addr { return c.remote }
+
+// Read reads the PROXY header from conn, waiting at most timeout for it, and
+// returns the connection with the header consumed.
+func Read(conn net.Conn, timeout time.Duration) (*Conn, error) {
+	if err := conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, err
+	}
+	reader := bufio.NewReaderSize(conn, 256)
+	remote, err := readHeader(reader)
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.SetReadDeadline(time.Time{}); err != nil {
+		return nil, err
+	}
+	if remote == nil {
+		remote = conn.RemoteAddr()
+	}
+	return &Conn{Conn: conn, reader: reader, remote: remote}, nil
+}
+
+func readHeader(r *bufio.Reader) (net.Addr, error) {
+	start, err := r.Peek(len(v2Signature))
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case bytes.Equal(start, v2Signature):
+		return readV2(r)
+	case bytes.HasPrefix(start, v1Prefix):
+		return readV1(r)
+	}
+	return nil, ErrNoHeader
+}
+
+// readV1 reads the text header, e.g.
+// "PROXY TCP4 192.0.2.1 198.51.100.1 56324 22\r\n".
+func readV1(r *bufio.Reader) (net.Addr, error) {
+	var line []byte
+	for len(line) < v1MaxLength {
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		line = append(line, b)
+		if bytes.HasSuffix(line, []byte("\r\n")) {
+			break
+		}
+	}
+	if !bytes.HasSuffix(line, []byte("\r\n")) {
+		return nil, errors.New("proxyproto: v1 header too long")
+	}
+
+	fields := strings.Fields(string(line[:len(line)-2]))
+	if len(fields) >= 2 && fields[1] == "UNKNOWN" {
+		return nil, nil
+	}
+	if len(fields) != 6 || (fields[1] != "TCP4" && fields[1] != "TCP6") {
+		return nil, fmt.Errorf("proxyproto: malformed v1 header %q", line)
+	}
+	ip := net.ParseIP(fields[2])
+	port, err := strconv.ParseUint(fields[4], 10, 16)
+	if ip == nil || err != nil {
+		return nil, fmt.Errorf("proxyproto: malformed v1 source %s:%s", fields[2], fields[4])
+	}
+	return &net.TCPAddr{IP: ip, Port: int(port)}, nil
+}
+
+// readV2 reads the binary header: the signature, a version and command byte,
+// the address family, the length of what follows and then the addresses.
+func readV2(r *bufio.Reader) (net.Addr, error) {
+	header := make([]byte, len(v2Signature)+4)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+	versionCommand, family := header[12], header[13]
+	length := binary.BigEndian.Uint16(header[14:])
+	if versionCommand>>4 != 2 {
+		return nil, fmt.Errorf("proxyproto: unsupported version %d", versionCommand>>4)
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+
+	// LOCAL connections come from the balancer itself.
+	command := versionCommand & 0x0f
+	if command > 1 {
+		return nil, fmt.Errorf("proxyproto: unknown v2 command %d", command)
+	}
+	if command == 0 {
+		return nil, nil
+	}
+	switch family {
+	case 0x11, 0x12: // TCP or UDP over IPv4
+		if len(body) < 12 {
+			return nil, errors.New("proxyproto: short v2 IPv4 addresses")
+		}
+		return &net.TCPAddr{IP: net.IP(body[0:4]), Port: int(binary.BigEndian.Uint16(body[8:]))}, nil
+	case 0x21, 0x22: // TCP or UDP over IPv6
+		if len(body) < 36 {
+			return nil, errors.New("proxyproto: short v2 IPv6 addresses")
+		}
+		return &net.TCPAddr{IP: net.IP(body[0:16]), Port: int(binary.BigEndian.Uint16(body[32:]))}, nil
+	}
+	// Unix sockets and unspecified families carry no address worth keeping.
+	return nil, nil
+}
@@ -0,0 +1,121 @@
+package proxyproto
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// v2Header builds a version 2 header with the given command, family and
+// address block.
+func v2Header(command byte, family byte, body []byte) []byte {
+	header := append([]byte(nil), v2Signature...)
+	header = append(header, 0x20|command, family, 0, 0)
+	binary.BigEndian.PutUint16(header[14:], uint16(len(body)))
+	return append(header, body...)
+}
+
+func v2IPv4(src string, srcPort uint16) []byte {
+	body := append(net.ParseIP(src).To4(), net.ParseIP("198.51.100.1").To4()...)
+	body = binary.BigEndian.AppendUint16(body, srcPort)
+	return binary.BigEndian.AppendUint16(body, 22)
+}
+
+func TestReadHeader(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		header []byte
+		want   string
+	}{
+		{"v1 TCP4", []byte("PROXY TCP4 192.0.2.1 198.51.100.1 56324 22\r\n"), "192.0.2.1:56324"},
+		{"v1 TCP6", []byte("PROXY TCP6 2001:db8::1 2001:db8::2 56324 22\r\n"), "[2001:db8::1]:56324"},
+		{"v1 UNKNOWN", []byte("PROXY UNKNOWN\r\n"), ""},
+		{"v1 UNKNOWN with addresses", []byte("PROXY UNKNOWN ffff::1 ffff::2 1 2\r\n"), ""},
+		{"v2 PROXY IPv4", v2Header(1, 0x11, v2IPv4("192.0.2.1", 56324)), "192.0.2.1:56324"},
+		{"v2 LOCAL", v2Header(0, 0x11, v2IPv4("192.0.2.1", 56324)), ""},
+		{"v2 LOCAL without addresses", v2Header(0, 0x00, nil), ""},
+		{"v2 unix socket", v2Header(1, 0x31, make([]byte, 216)), ""},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			addr, err := readHeader(bufio.NewReader(bytes.NewReader(test.header)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := ""
+			if addr != nil {
+				got = addr.String()
+			}
+			if got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestReadHeaderRefusesBadHeaders(t *testing.T) {
+	full := v2Header(1, 0x11, v2IPv4("192.0.2.1", 56324))
+	for _, test := range []struct {
+		name   string
+		header []byte
+	}{
+		{"no header", []byte("SSH-2.0-OpenSSH_9.6\r\n")},
+		{"empty", nil},
+		{"v1 truncated", []byte("PROXY TCP4 192.0.2.1 198.51.100.1")},
+		{"v1 too long", []byte("PROXY TCP4 " + strings.Repeat("1", 200) + "\r\n")},
+		{"v1 missing port", []byte("PROXY TCP4 192.0.2.1 198.51.100.1 56324\r\n")},
+		{"v1 bad address", []byte("PROXY TCP4 nowhere 198.51.100.1 56324 22\r\n")},
+		{"v1 port out of range", []byte("PROXY TCP4 192.0.2.1 198.51.100.1 65536 22\r\n")},
+		{"v1 unknown protocol", []byte("PROXY UDP4 192.0.2.1 198.51.100.1 56324 22\r\n")},
+		{"v2 signature only", v2Signature},
+		{"v2 truncated length", full[:14]},
+		{"v2 truncated addresses", full[:len(full)-3]},
+		{"v2 short IPv4 block", v2Header(1, 0x11, make([]byte, 4))},
+		{"v2 short IPv6 block", v2Header(1, 0x21, make([]byte, 12))},
+		{"v2 wrong version", append(append([]byte(nil), v2Signature...), 0x11, 0x11, 0, 0)},
+		{"v2 unknown command", v2Header(2, 0x11, v2IPv4("192.0.2.1", 56324))},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if addr, err := readHeader(bufio.NewReader(bytes.NewReader(test.header))); err == nil {
+				t.Errorf("accepted %q as %v", test.header, addr)
+			}
+		})
+	}
+}
+
+func TestReadLeavesTheRestOfTheStream(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	go func() {
+		client.Write([]byte("PROXY TCP4 192.0.2.1 198.51.100.1 56324 22\r\nSSH-2.0-client\r\n"))
+	}()
+
+	conn, err := Read(server, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if got := conn.RemoteAddr().String(); got != "192.0.2.1:56324" {
+		t.Errorf("remote address is %s", got)
+	}
+	rest := make([]byte, len("SSH-2.0-client\r\n"))
+	if _, err := io.ReadFull(conn, rest); err != nil {
+		t.Fatal(err)
+	}
+	if string(rest) != "SSH-2.0-client\r\n" {
+		t.Errorf("read %q after the header", rest)
+	}
+}
+
+func TestReadTimesOut(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+	if _, err := Read(server, 10*time.Millisecond); err == nil {
+		t.Error("a connection that sent nothing was accepted")
+	}
+}
@@ -0,0 +1,116 @@
+package qr
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/skip2/go-qrcode"
+)
+
+type Options struct {
+	ModuleSize int
+	QuietZone  int
+	// ASCII draws every module as two plain characters instead of half blocks.
+	ASCII bool
+	// Plain leaves out the colours, for terminals without any; light modules
+	// are drawn in the terminal's foreground.
+	Plain bool
+}
+
+// Render draws content as a QR code using half-block characters, so every
+// text row holds two rows of modules.
+func Render(content string, opts Options) (string, error) {
+	code, err := qrcode.New(content, qrcode.Medium)
+	if err != nil {
+		return "", err
+	}
+	code.DisableBorder = true
+
+	modules := scale(pad(code.Bitmap(), opts.QuietZone), opts.ModuleSize)
+	if opts.ASCII {
+		return renderASCII(modules), nil
+	}
+	if len(modules)%2 != 0 {
+		modules = append(modules, make([]bool, len(modules[0])))
+	}
+
+	style := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#ffffff")).
+		Background(lipgloss.Color("#000000"))
+	if opts.Plain {
+		style = lipgloss.NewStyle()
+	}
+
+	lines := make([]string, 0, len(modules)/2)
+	for y := 0; y < len(modules); y += 2 {
+		var b strings.Builder
+		for x := range modules[y] {
+			// Light modules are drawn, dark ones stay as the black background.
+			top, bottom := !modules[y][x], !modules[y+1][x]
+			switch {
+			case top && bottom:
+				b.WriteString("█")
+			case top:
+				b.WriteString("▀")
+			case bottom:
+				b.WriteString("▄")
+			default:
+				b.WriteString(" ")
+			}
+		}
+		lines = append(lines, style.Render(b.String()))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// renderASCII draws light modules as "##" and dark ones as blanks, one text
+// row per module row.
+func renderASCII(modules [][]bool) string {
+	lines := make([]string, len(modules))
+	for y, row := range modules {
+		var b strings.Builder
+		for _, dark := range row {
+			if dark {
+				b.WriteString("  ")
+			} else {
+				b.WriteString("##")
+			}
+		}
+		lines[y] = b.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+func pad(bitmap [][]bool, quietZone int) [][]bool {
+	if quietZone <= 0 {
+		return bitmap
+	}
+	size := len(bitmap) + 2*quietZone
+	padded := make([][]bool, size)
+	for y := range padded {
+		padded[y] = make([]bool, size)
+		if y >= quietZone && y < quietZone+len(bitmap) {
+			copy(padded[y][quietZone:], bitmap[y-quietZone])
+		}
+	}
+	return padded
+}
+
+func scale(bitmap [][]bool, factor int) [][]bool {
+	if factor <= 1 {
+		return bitmap
+	}
+	scaled := make([][]bool, 0, len(bitmap)*factor)
+	for _, row := range bitmap {
+		scaledRow := make([]bool, 0, len(row)*factor)
+		for _, module := range row {
+			for i := 0; i < factor; i++ {
+				scaledRow = append(scaledRow, module)
+			}
+		}
+		for i := 0; i < factor; i++ {
+			scaled = append(scaled, scaledRow)
+		}
+	}
+	return scaled
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/ssh"
+	"github.com/charmbracelet/wish"
+	"golang.org/x/time/rate"
+)
+
+// idleLimiterTTL is how long an address keeps its bucket after its last
+// connection. By then the bucket has refilled anyway.
+const idleLimiterTTL = 10 * time.Minute
+
+type ipLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+type connectionLimiter struct {
+	mu       sync.Mutex
+	limit    rate.Limit
+	burst    int
+	limiters map[string]*ipLimiter
+	swept    time.Time
+}
+
+func newConnectionLimiter(perMinute int, burst int) *connectionLimiter {
+	return &connectionLimiter{
+		limit:    rate.Limit(float64(perMinute) / 60),
+		burst:    burst,
+		limiters: make(map[string]*ipLimiter),
+		swept:    time.Now(),
+	}
+}
+
+func (l *connectionLimiter) allow(ip string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(l.swept) > idleLimiterTTL {
+		for addr, entry := range l.limiters {
+			if now.Sub(entry.lastSeen) > idleLimiterTTL {
+				delete(l.limiters, addr)
+			}
+		}
+		l.swept = now
+	}
+
+	entry, ok := l.limiters[ip]
+	if !ok {
+		entry = &ipLimiter{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.limiters[ip] = entry
+	}
+	entry.lastSeen = now
+	return entry.limiter.AllowN(now, 1)
+}
+
+// rateLimit turns away addresses that open connections faster than the
+// limiter allows, before any work is spent on their session.
+func rateLimit(limiter *connectionLimiter) wish.Middleware {
+	return func(next ssh.Handler) ssh.Handler {
+		return func(s ssh.Session) {
+			ip := remoteIP(s.RemoteAddr())
+			if !limiter.allow(ip) {
+				log.Warn("rate limited connection", "ip", ip)
+				wish.Fatalln(s, "Whoa, that's a lot of connections! Please wait a minute and try again.")
+				return
+			}
+			next(s)
+		}
+	}
+}
+
+func remoteIP(addr net.Addr) string {
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+	return host
+}
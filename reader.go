@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"organize/components"
+	"organize/utils"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func (m Model) updateReaderSearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		return m, tea.Quit
+	case tea.KeyEsc:
+		m.clearReaderSearch()
+		return m, nil
+	case tea.KeyEnter:
+		m.readerSearching = false
+		m.readerInput.Blur()
+		if m.readerInput.Value() == "" {
+			m.clearReaderSearch()
+		} else if len(m.readerMatches) == 0 {
+			m.statusMessage = fmt.Sprintf("No matches for %q", m.readerInput.Value())
+		}
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.readerInput, cmd = m.readerInput.Update(msg)
+	m.findReaderMatches()
+	return m, cmd
+}
+
+// findReaderMatches collects every rendered line containing the query and
+// jumps to the first one at or below the current scroll position.
+func (m *Model) findReaderMatches() {
+	m.readerMatches = nil
+	m.readerMatch = 0
+
+	query := strings.ToLower(m.readerInput.Value())
+	if query != "" {
+		for i, line := range strings.Split(utils.StripANSI(m.renderedContent), "\n") {
+			if strings.Contains(strings.ToLower(line), query) {
+				m.readerMatches = append(m.readerMatches, i)
+			}
+		}
+	}
+
+	for i, line := range m.readerMatches {
+		if line >= m.viewport.YOffset {
+			m.readerMatch = i
+			break
+		}
+	}
+	m.showReaderMatch()
+}
+
+func (m *Model) stepReaderMatch(delta int) {
+	if len(m.readerMatches) == 0 {
+		return
+	}
+	m.readerMatch = (m.readerMatch + delta + len(m.readerMatches)) % len(m.readerMatches)
+	m.showReaderMatch()
+}
+
+// showReaderMatch redraws the reader with matches highlighted. Highlighted
+// lines are re-rendered from plain text, since splicing styles into glamour's
+// escape sequences would garble them.
+func (m *Model) showReaderMatch() {
+	if len(m.readerMatches) == 0 {
+		m.viewport.SetContent(m.renderedContent)
+		return
+	}
+
+	query := m.readerInput.Value()
+	lines := strings.Split(m.renderedContent, "\n")
+	for i, line := range m.readerMatches {
+		lines[line] = components.HighlightQuery(m.theme, utils.StripANSI(lines[line]), query, i == m.readerMatch)
+	}
+	m.viewport.SetContent(strings.Join(lines, "\n"))
+	m.viewport.SetYOffset(m.readerMatches[m.readerMatch])
+}
+
+func (m *Model) clearReaderSearch() {
+	m.readerSearching = false
+	m.readerInput.Blur()
+	m.readerInput.SetValue("")
+	m.readerMatches = nil
+	m.readerMatch = 0
+	m.viewport.SetContent(m.renderedContent)
+}
+
+func (m Model) readerSearchStatus() string {
+	if len(m.readerMatches) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("match %d/%d", m.readerMatch+1, len(m.readerMatches))
+}
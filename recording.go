@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/ssh"
+	"github.com/charmbracelet/wish"
+)
+
+// castHeader is the first line of an asciicast v2 file.
+type castHeader struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// cast writes a session's output as an asciicast v2 recording, which
+// asciinema play replays with the original timing.
+type cast struct {
+	mu      sync.Mutex
+	file    *os.File
+	started time.Time
+	// pending is the start of a character split across two writes, held
+	// back so the recording stays valid UTF-8.
+	pending []byte
+}
+
+func newCast(path string, header castHeader) (*cast, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	c := &cast{file: file, started: time.Now()}
+	header.Version, header.Timestamp = 2, c.started.Unix()
+	if err := json.NewEncoder(file).Encode(header); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *cast) event(code string, data string) {
+	line, _ := json.Marshal([]interface{}{time.Since(c.started).Seconds(), code, data})
+	c.file.Write(append(line, '\n'))
+}
+
+func (c *cast) output(p []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data := append(c.pending, p...)
+	c.pending = nil
+	// A rune takes at most four bytes, so only the last three can be the
+	// start of one still to come.
+	for i := len(data) - 1; i >= 0 && i >= len(data)-3; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				c.pending = append([]byte(nil), data[i:]...)
+				data = data[:i]
+			}
+			break
+		}
+	}
+	if len(data) > 0 {
+		c.event("o", string(data))
+	}
+}
+
+func (c *cast) resize(w ssh.Window) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.event("r", fmt.Sprintf("%dx%d", w.Width, w.Height))
+}
+
+func (c *cast) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.file.Close()
+}
+
+// recordedSession copies everything the session shows into a cast, along
+// with every resize.
+type recordedSession struct {
+	ssh.Session
+	cast    *cast
+	windows chan ssh.Window
+}
+
+func (s *recordedSession) Write(p []byte) (int, error) {
+	s.cast.output(p)
+	return s.Session.Write(p)
+}
+
+func (s *recordedSession) Pty() (ssh.Pty, <-chan ssh.Window, bool) {
+	pty, _, active := s.Session.Pty()
+	return pty, s.windows, active
+}
+
+// recordSessions records the terminal sessions of admin keys as asciicast
+// files in dir, to replay how the board behaved on a troublesome terminal.
+// Visitors are never recorded.
+func recordSessions(dir string, admins []ssh.PublicKey) wish.Middleware {
+	return func(next ssh.Handler) ssh.Handler {
+		return func(s ssh.Session) {
+			pty, windows, active := s.Pty()
+			if !active || !isAdmin(admins, sessionKey(s)) {
+				next(s)
+				return
+			}
+			id := newSessionID()
+			if record := sessionRecordFrom(s); record != nil {
+				id = record.id
+			}
+			name := fmt.Sprintf("%s-%s-%s.cast", time.Now().Format("20060102-150405"), safeFileName(s.User()), id)
+			path := filepath.Join(dir, name)
+			recording, err := newCast(path, castHeader{
+				Width:  pty.Window.Width,
+				Height: pty.Window.Height,
+				Title:  s.User() + "@organize",
+				Env:    map[string]string{"TERM": pty.Term},
+			})
+			if err != nil {
+				log.Warn("could not start recording", "path", path, "error", err)
+				next(s)
+				return
+			}
+			defer recording.Close()
+			log.Info("recording session", "user", s.User(), "path", path)
+
+			recorded := &recordedSession{Session: s, cast: recording, windows: make(chan ssh.Window)}
+			go func() {
+				defer close(recorded.windows)
+				for w := range windows {
+					recording.resize(w)
+					select {
+					case recorded.windows <- w:
+					case <-s.Context().Done():
+						return
+					}
+				}
+			}()
+			next(recorded)
+		}
+	}
+}
+
+// safeFileName keeps letters, digits, dashes and underscores, so a username
+// can go in a file name.
+func safeFileName(name string) string {
+	safe := strings.Map(func(r rune) rune {
+		if r == '-' || r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, name)
+	if safe == "" {
+		return "anonymous"
+	}
+	return safe
+}
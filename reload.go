@@ -0,0 +1,114 @@
+package main
+
+import (
+	"reflect"
+	"sync/atomic"
+
+	"organize/components"
+	"organize/config"
+
+	"github.com/charmbracelet/log"
+)
+
+// restartOnly are the settings the running server was built around: its
+// listeners, keys, stores and middleware. A reload keeps them as they were
+// and says which ones will only change on a restart.
+var restartOnly = []string{
+	"Host", "Port", "UnixSocket", "PublicPort", "HostKeyPath", "HostKeys", "Directory",
+	"PprofAddr", "HealthAddr", "HTTPAddr", "WebhookSecret", "ControlSocket", "ProxyProtocol", "TrustedProxies",
+	"Tracking", "Downloads", "MarkdownStyle", "Keys", "AdminKeys", "RecordingsDir",
+	"AnnouncementDuration", "RateLimit", "SessionLimits", "IPFilter",
+	"AccessLog", "Tracing", "Sentry", "ApplicationsPath", "DatabasePath", "ViewsPath",
+	"PreferencesPath", "GuestbookPath", "FeedbackPath", "DeadLetterPath",
+	"DiscordWebhookURL", "FeedbackToDiscord", "SMTP", "DiscordBot", "ContentSource", "ContentRepo",
+}
+
+// liveConfig is the configuration new sessions start with. A reload swaps in
+// a fresh copy; sessions already running are sent it as a configReloadedMsg.
+type liveConfig struct {
+	path    string
+	current atomic.Pointer[config.Config]
+}
+
+// configReloadedMsg hands running sessions the reloaded configuration.
+type configReloadedMsg struct {
+	config *config.Config
+}
+
+func newLiveConfig(path string, cfg *config.Config) *liveConfig {
+	live := &liveConfig{path: path}
+	live.current.Store(cfg)
+	return live
+}
+
+func (l *liveConfig) load() *config.Config {
+	return l.current.Load()
+}
+
+// reload reads the configuration file again. Settings that need a restart are
+// carried over from the running configuration and listed in kept.
+func (l *liveConfig) reload() (cfg *config.Config, kept []string, err error) {
+	cfg, err = loadConfig(l.path)
+	if err != nil {
+		return nil, nil, err
+	}
+	running := reflect.ValueOf(l.load()).Elem()
+	loaded := reflect.ValueOf(cfg).Elem()
+	for _, name := range restartOnly {
+		was, now := running.FieldByName(name), loaded.FieldByName(name)
+		if !reflect.DeepEqual(was.Interface(), now.Interface()) {
+			kept = append(kept, name)
+			now.Set(was)
+		}
+	}
+	if _, ok := components.ThemeByName(cfg.Theme); !ok {
+		log.Warn("unknown theme, keeping the running one", "theme", cfg.Theme)
+		cfg.Theme = l.load().Theme
+	}
+	l.current.Store(cfg)
+	return cfg, kept, nil
+}
+
+// reloadConfig reloads the configuration, tells running sessions and reindexes
+// the content, on SIGHUP.
+func reloadConfig(live *liveConfig, svc *services) {
+	cfg, kept, err := live.reload()
+	if err != nil {
+		log.Error("could not reload configuration, keeping the running one", "error", err)
+		return
+	}
+	setLogFormat(cfg.LogFormat)
+	log.Info("configuration reloaded", "path", live.path)
+	if len(kept) > 0 {
+		log.Warn("some changes need a restart", "settings", kept)
+	}
+	svc.sessions.broadcast(configReloadedMsg{config: cfg})
+	svc.reload()
+}
+
+func setLogFormat(format string) {
+	switch format {
+	case "json":
+		log.SetFormatter(log.JSONFormatter)
+	case "logfmt":
+		log.SetFormatter(log.LogfmtFormatter)
+	default:
+		log.SetFormatter(log.TextFormatter)
+	}
+}
+
+// applyConfig switches the session to a reloaded configuration, taking up the
+// new theme unless the visitor picked their own.
+func (m *Model) applyConfig(cfg *config.Config) {
+	m.config = cfg
+	if _, picked := components.ThemeByName(m.preferences.Theme); picked {
+		return
+	}
+	theme := defaultTheme(cfg)
+	if theme.Name == m.theme.Name {
+		return
+	}
+	theme.ASCII, theme.Profile = m.theme.ASCII, m.theme.Profile
+	m.theme = theme.ForBackground(m.theme.Light)
+	m.redrawTheme()
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"organize/config"
+	"organize/qr"
+	"organize/termimage"
+	"organize/utils"
+
+	"github.com/charmbracelet/ssh"
+	"github.com/charmbracelet/wish"
+	"github.com/muesli/termenv"
+)
+
+const remoteUsage = `Commands:
+  list          every position, one per line: file, title, description
+  cat <file>    print a position, rendered when you have a terminal
+  qr            print the QR code for our Discord
+  json          every position with its metadata as JSON
+
+Run without a command to browse interactively.`
+
+// remoteCommands answers "ssh host <command>" with plain output instead of
+// starting the TUI, so the board can be read from scripts and pipes.
+func remoteCommands(live *liveConfig, renderers *rendererCache) wish.Middleware {
+	return func(next ssh.Handler) ssh.Handler {
+		return func(s ssh.Session) {
+			cfg := live.load()
+			command := s.Command()
+			if len(command) == 0 {
+				if _, _, active := s.Pty(); !active {
+					wish.Fatalln(s, "no active terminal, try \"ssh -t\" or one of these:\n\n"+remoteUsage)
+					return
+				}
+				next(s)
+				return
+			}
+			if err := runRemote(cfg, renderers, s, command[0], command[1:]); err != nil {
+				wish.Fatalln(s, err.Error())
+			}
+		}
+	}
+}
+
+func runRemote(cfg *config.Config, renderers *rendererCache, s ssh.Session, command string, args []string) error {
+	switch command {
+	case "list":
+		positionMeta, err := utils.GetPositionMeta(cfg.Directory)
+		if err != nil {
+			return fmt.Errorf("can't read directory: %w", err)
+		}
+		for i, fileName := range positionMeta.FileNames {
+			if !utils.IsDirEntry(fileName) && !positionMeta.FileMetadata[i].Draft() {
+				wish.Printf(s, "%s\t%s\t%s\n", fileName, positionMeta.FileTitles[i], positionMeta.FileDescriptions[i])
+			}
+		}
+		return nil
+	case "cat":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: cat <file>, see \"list\" for the files")
+		}
+		if utils.IsImage(args[0]) {
+			return catImage(cfg, s, args[0])
+		}
+		body, err := readPosition(cfg, args[0])
+		if err != nil {
+			return err
+		}
+		if pty, _, active := s.Pty(); active {
+			theme := defaultTheme(cfg)
+			theme.Profile = colorProfile(pty.Term, s.Environ())
+			render := func() (string, error) { return renderers.render(theme, pty.Window.Width, body) }
+			if utils.CodeLanguage(args[0]) != "" {
+				render = func() (string, error) { return highlightCode(theme, args[0], body) }
+			}
+			if rendered, err := render(); err == nil {
+				body = utils.DowngradeColors(rendered, theme.Profile)
+			}
+		}
+		wish.Print(s, body)
+		return nil
+	case "qr":
+		pty, _, _ := s.Pty()
+		profile := colorProfile(pty.Term, s.Environ())
+		code, err := qr.Render(cfg.DiscordURL, qr.Options{ModuleSize: cfg.QR.ModuleSize, QuietZone: cfg.QR.QuietZone, Plain: profile == termenv.Ascii})
+		if err != nil {
+			return fmt.Errorf("can't render qr code: %w", err)
+		}
+		wish.Println(s, utils.DowngradeColors(code, profile))
+		wish.Println(s, cfg.DiscordURL)
+		return nil
+	case "json":
+		positions, err := listPositions(cfg)
+		if err != nil {
+			return fmt.Errorf("can't read directory: %w", err)
+		}
+		encoder := json.NewEncoder(s)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(positions)
+	case "help":
+		wish.Println(s, remoteUsage)
+		return nil
+	}
+	return fmt.Errorf("unknown command %q\n\n%s", command, remoteUsage)
+}
+
+// catImage draws an image at the size of the visitor's terminal. Without one
+// there is nothing to draw on, and the file itself is one scp away.
+func catImage(cfg *config.Config, s ssh.Session, fileName string) error {
+	pty, _, active := s.Pty()
+	if !active {
+		return fmt.Errorf("%s is an image, try \"ssh -t\" or download it with scp", fileName)
+	}
+	path, _, err := positionPath(cfg, fileName)
+	if err != nil {
+		return err
+	}
+	img, err := termimage.Load(path)
+	if err != nil {
+		return fmt.Errorf("can't read %s: %w", fileName, err)
+	}
+	profile := colorProfile(pty.Term, s.Environ())
+	wish.Println(s, termimage.RenderFit(img, pty.Window.Width, pty.Window.Height-1, termimage.ModeFor(profile)))
+	return nil
+}
+
+// readPosition reads a position as text, see positionPath.
+func readPosition(cfg *config.Config, fileName string) (string, error) {
+	path, name, err := positionPath(cfg, fileName)
+	if err != nil {
+		return "", err
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return utils.FileBody(name, string(content)), nil
+}
+
+// positionPath only finds files the positions list knows about, which keeps
+// paths like ../../etc/passwd, links out of the directory and drafts out of
+// reach. It returns the file's
+// path and its name in the list.
+func positionPath(cfg *config.Config, fileName string) (string, string, error) {
+	positionMeta, err := utils.GetPositionMeta(cfg.Directory)
+	if err != nil {
+		return "", "", fmt.Errorf("can't read directory: %w", err)
+	}
+	fileName = strings.TrimPrefix(fileName, "/")
+	for i, name := range positionMeta.FileNames {
+		if positionMeta.FileMetadata[i].Draft() {
+			continue
+		}
+		if (name == fileName || strings.TrimSuffix(name, ".md") == fileName) && !utils.IsDirEntry(name) && utils.InsideRoot(cfg.Directory, name) {
+			return filepath.Join(cfg.Directory, filepath.FromSlash(name)), name, nil
+		}
+	}
+	return "", "", fmt.Errorf("no position called %q, see \"list\" for the files", fileName)
+}
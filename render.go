@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"organize/components"
+
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/glamour/ansi"
+	"github.com/muesli/termenv"
+)
+
+// defaultWrapWidth is the column positions are wrapped at until the
+// terminal's width is known.
+const defaultWrapWidth = 80
+
+// defaultImageHeight is the number of rows images are scaled to until the
+// terminal's height is known.
+const defaultImageHeight = 20
+
+// maxRenderers bounds the renderer cache; past it the cache starts over.
+const maxRenderers = 64
+
+// rendererCache keeps a glamour renderer per theme, colour depth and wrap
+// width, since building one walks the whole style. A renderer keeps state
+// while it renders, so each renders one position at a time.
+type rendererCache struct {
+	mu        sync.Mutex
+	style     *ansi.StyleConfig
+	renderers map[rendererKey]*cachedRenderer
+}
+
+type rendererKey struct {
+	theme   string
+	light   bool
+	ascii   bool
+	profile termenv.Profile
+	width   int
+}
+
+type cachedRenderer struct {
+	mu       sync.Mutex
+	renderer *glamour.TermRenderer
+}
+
+// newRendererCache renders with the themes' own styles, or with style for
+// every theme when the operator supplied one.
+func newRendererCache(style *ansi.StyleConfig) *rendererCache {
+	return &rendererCache{style: style, renderers: make(map[rendererKey]*cachedRenderer)}
+}
+
+// loadMarkdownStyle reads a glamour style in its JSON format, see
+// https://github.com/charmbracelet/glamour/tree/master/styles.
+func loadMarkdownStyle(path string) (*ansi.StyleConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var style ansi.StyleConfig
+	if err := json.Unmarshal(data, &style); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &style, nil
+}
+
+// newRendererKey is what tells renders in theme at width apart from others.
+func newRendererKey(theme components.Theme, width int) rendererKey {
+	return rendererKey{theme: theme.Name, light: theme.Light, ascii: theme.ASCII, profile: theme.Profile, width: width}
+}
+
+func (c *rendererCache) render(theme components.Theme, width int, content string) (string, error) {
+	cached, err := c.renderer(newRendererKey(theme, width), theme)
+	if err != nil {
+		return "", err
+	}
+	cached.mu.Lock()
+	defer cached.mu.Unlock()
+	return cached.renderer.Render(content)
+}
+
+func (c *rendererCache) renderer(key rendererKey, theme components.Theme) (*cachedRenderer, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cached, ok := c.renderers[key]; ok {
+		return cached, nil
+	}
+
+	style := theme.Markdown()
+	if c.style != nil {
+		style = *c.style
+	}
+	renderer, err := glamour.NewTermRenderer(glamour.WithStyles(style), glamour.WithColorProfile(key.profile), glamour.WithWordWrap(key.width))
+	if err != nil {
+		return nil, err
+	}
+	if len(c.renderers) >= maxRenderers {
+		c.renderers = make(map[rendererKey]*cachedRenderer)
+	}
+	cached := &cachedRenderer{renderer: renderer}
+	c.renderers[key] = cached
+	return cached, nil
+}
@@ -0,0 +1,44 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Fuzzy reports whether every rune of pattern appears in text in order,
+// ignoring case. The score rewards consecutive matches and matches at the
+// start of words; matches holds the rune offsets in text that matched.
+func Fuzzy(pattern, text string) (score int, matches []int, ok bool) {
+	patternRunes := []rune(strings.ToLower(pattern))
+	if len(patternRunes) == 0 {
+		return 0, nil, true
+	}
+
+	textRunes := []rune(text)
+	p := 0
+	previous := -2
+	for i, r := range textRunes {
+		if p == len(patternRunes) {
+			break
+		}
+		if unicode.ToLower(r) != patternRunes[p] {
+			continue
+		}
+
+		score++
+		if previous == i-1 {
+			score += 2
+		}
+		if i == 0 || !unicode.IsLetter(textRunes[i-1]) && !unicode.IsDigit(textRunes[i-1]) {
+			score += 3
+		}
+		matches = append(matches, i)
+		previous = i
+		p++
+	}
+
+	if p < len(patternRunes) {
+		return 0, nil, false
+	}
+	return score, matches, true
+}
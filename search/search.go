@@ -0,0 +1,169 @@
+package search
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"organize/utils"
+)
+
+const (
+	titleWeight     = 5
+	maxDocBytes     = 64 * 1024
+	maxTermsPerDoc  = 4096
+	maxSnippetWidth = 80
+)
+
+type posting struct {
+	doc   int
+	freq  int
+	title bool
+	line  int
+}
+
+type document struct {
+	fileName string
+	title    string
+	lines    []string
+}
+
+type Index struct {
+	docs  []document
+	terms map[string][]posting
+}
+
+type Result struct {
+	FileName string
+	Title    string
+	Score    int
+	Line     int
+	Snippet  string
+}
+
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func Build(dir string, positionMeta *utils.PositionMeta) (*Index, error) {
+	idx := &Index{terms: make(map[string][]posting)}
+	for i, fileName := range positionMeta.FileNames {
+		if utils.IsDirEntry(fileName) {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(dir, fileName))
+		if err != nil {
+			return nil, err
+		}
+		if len(content) > maxDocBytes {
+			content = content[:maxDocBytes]
+		}
+		idx.add(
+			fileName,
+			positionMeta.FileTitles[i],
+			strings.Join([]string{fileName, positionMeta.FileTitles[i], positionMeta.FileDescriptions[i]}, " "),
+			strings.Split(utils.FileBody(fileName, string(content)), "\n"),
+		)
+	}
+	return idx, nil
+}
+
+func (idx *Index) add(fileName, title, titleText string, lines []string) {
+	doc := len(idx.docs)
+	idx.docs = append(idx.docs, document{fileName: fileName, title: title, lines: lines})
+
+	postings := make(map[string]*posting)
+	record := func(term string, line int, inTitle bool) {
+		p, ok := postings[term]
+		if !ok {
+			if len(postings) >= maxTermsPerDoc {
+				return
+			}
+			p = &posting{doc: doc, line: line}
+			postings[term] = p
+		}
+		p.freq++
+		if inTitle {
+			p.title = true
+		} else if p.line < 0 {
+			p.line = line
+		}
+	}
+
+	for _, term := range Tokenize(titleText) {
+		record(term, -1, true)
+	}
+	for i, line := range lines {
+		for _, term := range Tokenize(line) {
+			record(term, i, false)
+		}
+	}
+	for term, p := range postings {
+		idx.terms[term] = append(idx.terms[term], *p)
+	}
+}
+
+func (idx *Index) Search(query string, limit int) []Result {
+	if idx == nil {
+		return nil
+	}
+	terms := Tokenize(query)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	scores := make(map[int]int)
+	firstLine := make(map[int]int)
+	for _, term := range terms {
+		for _, p := range idx.terms[term] {
+			score := p.freq
+			if p.title {
+				score += titleWeight
+			}
+			scores[p.doc] += score
+			if l, ok := firstLine[p.doc]; !ok || (p.line >= 0 && (l < 0 || p.line < l)) {
+				firstLine[p.doc] = p.line
+			}
+		}
+	}
+
+	results := make([]Result, 0, len(scores))
+	for doc, score := range scores {
+		d := idx.docs[doc]
+		line := firstLine[doc]
+		snippet := ""
+		if line >= 0 {
+			snippet = trimSnippet(d.lines[line])
+		}
+		results = append(results, Result{
+			FileName: d.fileName,
+			Title:    d.title,
+			Score:    score,
+			Line:     line,
+			Snippet:  snippet,
+		})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].FileName < results[j].FileName
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+func trimSnippet(line string) string {
+	line = strings.TrimSpace(line)
+	runes := []rune(line)
+	if len(runes) > maxSnippetWidth {
+		return string(runes[:maxSnippetWidth-1]) + "…"
+	}
+	return line
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"sync"
+
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/ssh"
+	"github.com/charmbracelet/wish"
+)
+
+// sessionCounter tracks how many sessions are open in total and per address.
+// A limit of 0 means no limit.
+type sessionCounter struct {
+	mu       sync.Mutex
+	max      int
+	maxPerIP int
+	total    int
+	perIP    map[string]int
+}
+
+func newSessionCounter(max int, maxPerIP int) *sessionCounter {
+	return &sessionCounter{max: max, maxPerIP: maxPerIP, perIP: make(map[string]int)}
+}
+
+func (c *sessionCounter) acquire(ip string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if (c.max > 0 && c.total >= c.max) || (c.maxPerIP > 0 && c.perIP[ip] >= c.maxPerIP) {
+		return false
+	}
+	c.total++
+	c.perIP[ip]++
+	return true
+}
+
+func (c *sessionCounter) release(ip string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.total--
+	if c.perIP[ip]--; c.perIP[ip] <= 0 {
+		delete(c.perIP, ip)
+	}
+}
+
+// sessionCap sends a short message instead of starting a program once the
+// server or the visitor's address has as many sessions as it may hold.
+func sessionCap(counter *sessionCounter) wish.Middleware {
+	return func(next ssh.Handler) ssh.Handler {
+		return func(s ssh.Session) {
+			ip := remoteIP(s.RemoteAddr())
+			if !counter.acquire(ip) {
+				log.Warn("session limit reached", "ip", ip)
+				wish.Fatalln(s, "The server is full right now, please try again shortly.")
+				return
+			}
+			defer counter.release(ip)
+			next(s)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+
+	"organize/accesslog"
+
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/ssh"
+	"github.com/charmbracelet/wish"
+	"github.com/getsentry/sentry-go"
+)
+
+// sessionRecord is what the session log gathers about a session while it
+// lasts. A nil record gathers nothing, for when tracking is off.
+type sessionRecord struct {
+	id     string
+	mu     sync.Mutex
+	viewed []string
+	// entry is the session as the access log writes it, when there is one.
+	entry  accesslog.Entry
+	access *accesslog.Writer
+}
+
+// sessionRecordKey is where sessionLog leaves the record in the session's
+// context for the program to add to.
+type sessionRecordKey struct{}
+
+// newSessionID is a short random ID to tell sessions apart in the log.
+func newSessionID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
+
+// sessionRecordFrom is the record sessionLog started for s, or nil.
+func sessionRecordFrom(s ssh.Session) *sessionRecord {
+	record, _ := s.Context().Value(sessionRecordKey{}).(*sessionRecord)
+	return record
+}
+
+// viewedFile notes a position opened during the session, in the access log
+// every time and in the session log once.
+func (r *sessionRecord) viewedFile(fileName string) {
+	if r == nil {
+		return
+	}
+	if r.access != nil {
+		view := r.entry
+		view.Time, view.Event, view.File = time.Now(), accesslog.EventView, fileName
+		r.writeAccess(view)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, viewed := range r.viewed {
+		if viewed == fileName {
+			return
+		}
+	}
+	r.viewed = append(r.viewed, fileName)
+}
+
+func (r *sessionRecord) writeAccess(entry accesslog.Entry) {
+	if err := r.access.Write(entry); err != nil {
+		reportError(sentry.CurrentHub(), "could not write access log", err, "session", r.id)
+	}
+}
+
+func (r *sessionRecord) files() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.viewed...)
+}
+
+// sessionLog logs every session as it starts and ends, with fields a log
+// pipeline can pick apart when log_format is json or logfmt, and writes it to
+// the access log when there is one. It only runs with tracking on.
+func sessionLog(access *accesslog.Writer) wish.Middleware {
+	return func(next ssh.Handler) ssh.Handler {
+		return func(s ssh.Session) {
+			record := &sessionRecord{id: newSessionID(), access: access}
+			s.Context().SetValue(sessionRecordKey{}, record)
+			logger := log.With("session", record.id)
+
+			started := time.Now()
+			record.entry = accesslog.Entry{
+				Event:       accesslog.EventSession,
+				Session:     record.id,
+				Address:     s.RemoteAddr().String(),
+				User:        s.User(),
+				Fingerprint: keyFingerprint(sessionKey(s)),
+			}
+			fields := []interface{}{
+				"address", record.entry.Address,
+				"user", record.entry.User,
+				"fingerprint", record.entry.Fingerprint,
+			}
+			if command := s.Command(); len(command) > 0 {
+				fields = append(fields, "command", strings.Join(command, " "))
+			}
+			if pty, _, active := s.Pty(); active {
+				record.entry.Term = pty.Term
+				fields = append(fields, "term", pty.Term, "width", pty.Window.Width, "height", pty.Window.Height)
+			}
+			logger.Info("session started", fields...)
+
+			next(s)
+			duration := time.Since(started)
+			logger.Info("session ended", "duration", duration.Round(time.Millisecond), "viewed", record.files())
+			if access != nil {
+				ended := record.entry
+				ended.Time, ended.Duration = started, duration
+				record.writeAccess(ended)
+			}
+		}
+	}
+}
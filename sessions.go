@@ -0,0 +1,74 @@
+package main
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// sessionInfo is who a running session belongs to, for the debug listener.
+type sessionInfo struct {
+	User        string    `json:"user"`
+	Address     string    `json:"address"`
+	Term        string    `json:"term"`
+	ConnectedAt time.Time `json:"connected_at"`
+	// record is the session log's record, when there is one, for the ID and
+	// the positions opened so far.
+	record *sessionRecord
+}
+
+type sessionRegistry struct {
+	mu       sync.Mutex
+	programs map[*tea.Program]sessionInfo
+}
+
+func newSessionRegistry() *sessionRegistry {
+	return &sessionRegistry{programs: make(map[*tea.Program]sessionInfo)}
+}
+
+func (r *sessionRegistry) add(p *tea.Program, info sessionInfo) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.programs[p] = info
+}
+
+func (r *sessionRegistry) remove(p *tea.Program) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.programs, p)
+}
+
+func (r *sessionRegistry) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.programs)
+}
+
+// list is every running session, oldest first.
+func (r *sessionRegistry) list() []sessionInfo {
+	r.mu.Lock()
+	infos := make([]sessionInfo, 0, len(r.programs))
+	for _, info := range r.programs {
+		infos = append(infos, info)
+	}
+	r.mu.Unlock()
+	sort.Slice(infos, func(i, j int) bool { return infos[i].ConnectedAt.Before(infos[j].ConnectedAt) })
+	return infos
+}
+
+func (r *sessionRegistry) broadcast(msg tea.Msg) {
+	r.mu.Lock()
+	programs := make([]*tea.Program, 0, len(r.programs))
+	for p := range r.programs {
+		programs = append(programs, p)
+	}
+	r.mu.Unlock()
+
+	// Send blocks until the program reads the message, so never hold the
+	// lock while delivering.
+	for _, p := range programs {
+		go p.Send(msg)
+	}
+}
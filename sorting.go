@@ -0,0 +1,119 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// sortOrder is how the grid orders positions. s cycles through them.
+type sortOrder int
+
+const (
+	// sortFeatured moves positions about to close up front, then those with
+	// an order in their frontmatter, and keeps the content directory's order
+	// for the rest.
+	sortFeatured sortOrder = iota
+	sortAlphabetical
+	// sortNewest goes by the updated date in the frontmatter, or the file's
+	// modification time without one.
+	sortNewest
+	// sortDeadline puts the soonest deadline first and positions without one
+	// last.
+	sortDeadline
+	// sortPopular goes by how many sessions opened each position.
+	sortPopular
+	sortOrders
+)
+
+func (o sortOrder) String() string {
+	switch o {
+	case sortAlphabetical:
+		return "A-Z"
+	case sortNewest:
+		return "newest first"
+	case sortDeadline:
+		return "deadline"
+	case sortPopular:
+		return "most viewed"
+	}
+	return "featured"
+}
+
+func (m *Model) cycleSort() {
+	m.sortOrder = (m.sortOrder + 1) % sortOrders
+}
+
+// sortPositions orders positions in place by the session's sort order.
+func (m Model) sortPositions(positions []int) {
+	now := time.Now()
+	var less func(a, b int) bool
+	switch m.sortOrder {
+	case sortAlphabetical:
+		less = func(a, b int) bool {
+			return strings.ToLower(m.fileTitles[a]) < strings.ToLower(m.fileTitles[b])
+		}
+	case sortNewest:
+		less = func(a, b int) bool {
+			return m.fileMetadata[a].Updated.After(m.fileMetadata[b].Updated)
+		}
+	case sortDeadline:
+		less = func(a, b int) bool {
+			openA, openB := m.openDeadline(a, now), m.openDeadline(b, now)
+			if openA && openB {
+				return m.fileMetadata[a].Closes().Before(m.fileMetadata[b].Closes())
+			}
+			return openA && !openB
+		}
+	case sortPopular:
+		less = func(a, b int) bool {
+			return m.views.Count(m.fileNames[a]) > m.views.Count(m.fileNames[b])
+		}
+	default:
+		// Positions about to close go first, soonest first, then ranked ones,
+		// lowest order first.
+		less = func(a, b int) bool {
+			soonA, soonB := m.expiringSoon(a, now), m.expiringSoon(b, now)
+			if soonA && soonB {
+				return m.fileMetadata[a].Closes().Before(m.fileMetadata[b].Closes())
+			}
+			if soonA || soonB {
+				return soonA
+			}
+			orderA, orderB := m.fileMetadata[a].Order, m.fileMetadata[b].Order
+			if orderA == 0 || orderB == 0 {
+				return orderA != 0 && orderB == 0
+			}
+			return orderA < orderB
+		}
+	}
+	// Ties go by title, then file name, so equal dates, orders or view counts
+	// come out the same way on every host and after every reload.
+	primary := less
+	less = func(a, b int) bool {
+		if primary(a, b) || primary(b, a) {
+			return primary(a, b)
+		}
+		if m.sortOrder == sortFeatured && m.fileMetadata[a].Order == 0 && !m.expiringSoon(a, now) {
+			// Unranked positions keep the content directory's order.
+			return false
+		}
+		titleA, titleB := strings.ToLower(m.fileTitles[a]), strings.ToLower(m.fileTitles[b])
+		if titleA != titleB {
+			return titleA < titleB
+		}
+		return m.fileNames[a] < m.fileNames[b]
+	}
+	sort.SliceStable(positions, func(a, b int) bool {
+		i, j := positions[a], positions[b]
+		if i >= len(m.fileMetadata) || j >= len(m.fileMetadata) {
+			return false
+		}
+		return less(i, j)
+	})
+}
+
+// openDeadline reports whether a position has a deadline that hasn't passed.
+func (m Model) openDeadline(index int, now time.Time) bool {
+	return !m.fileMetadata[index].Deadline.IsZero() && !m.fileMetadata[index].Expired(now)
+}
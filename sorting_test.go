@@ -0,0 +1,65 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"organize/utils"
+	"organize/views"
+)
+
+func TestSortPositionsBreaksTies(t *testing.T) {
+	updated := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	deadline := time.Now().AddDate(0, 1, 0)
+	counter, err := views.NewCounter(views.NewFileStore(filepath.Join(t.TempDir(), "views.json")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer counter.Close()
+
+	// Every position has the same date, deadline and view count, and two of
+	// them share a title, so only the tie-breaker decides the order.
+	m := Model{
+		fileNames:  []string{"c.md", "b.md", "a/b.md", "a.md"},
+		fileTitles: []string{"Core team", "volunteer", "Volunteer", "Apply"},
+		views:      counter,
+	}
+	for range m.fileNames {
+		m.fileMetadata = append(m.fileMetadata, utils.Frontmatter{Updated: updated, Deadline: deadline})
+	}
+
+	want := []int{3, 0, 2, 1}
+	for _, order := range []sortOrder{sortAlphabetical, sortNewest, sortDeadline, sortPopular} {
+		m.sortOrder = order
+		for _, start := range [][]int{{0, 1, 2, 3}, {3, 2, 1, 0}, {1, 3, 0, 2}} {
+			positions := append([]int(nil), start...)
+			m.sortPositions(positions)
+			if !reflect.DeepEqual(positions, want) {
+				t.Errorf("%s from %v: got %v, want %v", order, start, positions, want)
+			}
+		}
+	}
+}
+
+func TestFeaturedSortFollowsOrder(t *testing.T) {
+	// Two positions share order 1, so they go by title; the unranked ones
+	// keep the directory's order after them.
+	m := Model{
+		fileNames:  []string{"a.md", "b.md", "c.md", "d.md", "e.md"},
+		fileTitles: []string{"Zeta", "Mentor", "Beta", "Alpha", "Gamma"},
+	}
+	for _, order := range []int{0, 2, 1, 0, 1} {
+		m.fileMetadata = append(m.fileMetadata, utils.Frontmatter{Order: order})
+	}
+
+	want := []int{2, 4, 1, 0, 3}
+	for _, start := range [][]int{{0, 1, 2, 3, 4}, {4, 2, 1, 0, 3}} {
+		positions := append([]int(nil), start...)
+		m.sortPositions(positions)
+		if !reflect.DeepEqual(positions, want) {
+			t.Errorf("from %v: got %v, want %v", start, positions, want)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// The splash types the banner one character per splashDelay and leaves the
+// screen to the list after splashFrames of them.
+const (
+	splashDelay  = 30 * time.Millisecond
+	splashFrames = 60
+)
+
+// splashMsg moves the splash on a frame.
+type splashMsg struct{}
+
+func splashTick() tea.Cmd {
+	return tea.Tick(splashDelay, func(time.Time) tea.Msg {
+		return splashMsg{}
+	})
+}
+
+func (m Model) updateSplash() (tea.Model, tea.Cmd) {
+	if !m.splashing {
+		return m, nil
+	}
+	m.splashFrame++
+	if m.splashFrame >= splashFrames {
+		m.splashing = false
+		return m, nil
+	}
+	return m, splashTick()
+}
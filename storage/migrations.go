@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// migrations are applied in order, each one exactly once. The database keeps
+// how many have run in PRAGMA user_version, so new ones must only ever be
+// appended.
+var migrations = []string{
+	`CREATE TABLE applications (
+		id           INTEGER PRIMARY KEY,
+		position     TEXT NOT NULL,
+		title        TEXT NOT NULL,
+		name         TEXT NOT NULL,
+		email        TEXT NOT NULL,
+		github       TEXT NOT NULL,
+		answer       TEXT NOT NULL,
+		user         TEXT NOT NULL,
+		submitted_at DATETIME NOT NULL
+	);
+	CREATE TABLE views (
+		position TEXT PRIMARY KEY,
+		count    INTEGER NOT NULL
+	);
+	CREATE TABLE visits (
+		id           INTEGER PRIMARY KEY,
+		user         TEXT NOT NULL,
+		address      TEXT NOT NULL,
+		connected_at DATETIME NOT NULL,
+		duration_ms  INTEGER NOT NULL
+	);`,
+	`ALTER TABLE visits ADD COLUMN fingerprint TEXT NOT NULL DEFAULT '';
+	CREATE TABLE preferences (
+		fingerprint TEXT PRIMARY KEY,
+		data        TEXT NOT NULL
+	);`,
+	`CREATE TABLE guestbook (
+		id          INTEGER PRIMARY KEY,
+		user        TEXT NOT NULL,
+		fingerprint TEXT NOT NULL,
+		message     TEXT NOT NULL,
+		signed_at   DATETIME NOT NULL
+	);`,
+	`CREATE TABLE feedback (
+		id           INTEGER PRIMARY KEY,
+		topic        TEXT NOT NULL,
+		message      TEXT NOT NULL,
+		user         TEXT NOT NULL,
+		fingerprint  TEXT NOT NULL,
+		submitted_at DATETIME NOT NULL
+	);`,
+}
+
+func migrate(db *sql.DB) error {
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return err
+	}
+	if version > len(migrations) {
+		return fmt.Errorf("database is at schema version %d, this build only knows %d", version, len(migrations))
+	}
+
+	for i := version; i < len(migrations); i++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(migrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+		// PRAGMA does not take bound parameters.
+		if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", i+1)); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,242 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"organize/applications"
+	"organize/feedback"
+	"organize/guestbook"
+	"organize/preferences"
+
+	_ "modernc.org/sqlite"
+)
+
+// Repository is everything the server keeps between restarts: applications
+// and feedback sent through the forms, how often each position was opened,
+// who visited, what returning visitors prefer and what they wrote in the
+// guestbook.
+type Repository interface {
+	applications.Store
+	feedback.Store
+	preferences.Store
+	guestbook.Store
+	ViewCounts() (map[string]int, error)
+	AddViews(deltas map[string]int) error
+	RecordVisit(visit Visit) error
+	Close() error
+}
+
+type Visit struct {
+	User        string
+	Fingerprint string
+	Address     string
+	ConnectedAt time.Time
+	Duration    time.Duration
+}
+
+type SQLite struct {
+	db *sql.DB
+}
+
+// Open opens the database at path, creating it if needed, and brings its
+// schema up to date.
+func Open(path string) (*SQLite, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows a single writer, so sessions queue up on one connection
+	// instead of failing with SQLITE_BUSY.
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec("PRAGMA journal_mode = WAL; PRAGMA busy_timeout = 5000"); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &SQLite{db: db}, nil
+}
+
+func (s *SQLite) Close() error {
+	return s.db.Close()
+}
+
+func (s *SQLite) Save(application applications.Application) error {
+	_, err := s.db.Exec(
+		`INSERT INTO applications (position, title, name, email, github, answer, user, submitted_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		application.Position,
+		application.Title,
+		application.Name,
+		application.Email,
+		application.GitHub,
+		application.Answer,
+		application.User,
+		application.SubmittedAt.UTC(),
+	)
+	return err
+}
+
+func (s *SQLite) Applications() ([]applications.Application, error) {
+	rows, err := s.db.Query(
+		`SELECT position, title, name, email, github, answer, user, submitted_at
+		FROM applications ORDER BY submitted_at`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var submitted []applications.Application
+	for rows.Next() {
+		var a applications.Application
+		if err := rows.Scan(&a.Position, &a.Title, &a.Name, &a.Email, &a.GitHub, &a.Answer, &a.User, &a.SubmittedAt); err != nil {
+			return nil, err
+		}
+		submitted = append(submitted, a)
+	}
+	return submitted, rows.Err()
+}
+
+func (s *SQLite) AddViews(deltas map[string]int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	for position, n := range deltas {
+		_, err := tx.Exec(
+			`INSERT INTO views (position, count) VALUES (?, ?)
+			ON CONFLICT (position) DO UPDATE SET count = count + excluded.count`,
+			position, n,
+		)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *SQLite) ViewCounts() (map[string]int, error) {
+	rows, err := s.db.Query(`SELECT position, count FROM views`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var position string
+		var count int
+		if err := rows.Scan(&position, &count); err != nil {
+			return nil, err
+		}
+		counts[position] = count
+	}
+	return counts, rows.Err()
+}
+
+func (s *SQLite) RecordVisit(visit Visit) error {
+	_, err := s.db.Exec(
+		`INSERT INTO visits (user, fingerprint, address, connected_at, duration_ms) VALUES (?, ?, ?, ?, ?)`,
+		visit.User,
+		visit.Fingerprint,
+		visit.Address,
+		visit.ConnectedAt.UTC(),
+		visit.Duration.Milliseconds(),
+	)
+	return err
+}
+
+func (s *SQLite) Preferences(fingerprint string) (preferences.Preferences, error) {
+	var p preferences.Preferences
+	var data string
+	err := s.db.QueryRow(`SELECT data FROM preferences WHERE fingerprint = ?`, fingerprint).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return p, nil
+	}
+	if err != nil {
+		return p, err
+	}
+	return p, json.Unmarshal([]byte(data), &p)
+}
+
+func (s *SQLite) SavePreferences(fingerprint string, p preferences.Preferences) error {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(
+		`INSERT INTO preferences (fingerprint, data) VALUES (?, ?)
+		ON CONFLICT (fingerprint) DO UPDATE SET data = excluded.data`,
+		fingerprint,
+		string(data),
+	)
+	return err
+}
+
+func (s *SQLite) Sign(entry guestbook.Entry) error {
+	_, err := s.db.Exec(
+		`INSERT INTO guestbook (user, fingerprint, message, signed_at) VALUES (?, ?, ?, ?)`,
+		entry.User,
+		entry.Fingerprint,
+		entry.Message,
+		entry.SignedAt.UTC(),
+	)
+	return err
+}
+
+func (s *SQLite) Entries() ([]guestbook.Entry, error) {
+	rows, err := s.db.Query(`SELECT user, fingerprint, message, signed_at FROM guestbook ORDER BY signed_at DESC, id DESC LIMIT ?`, guestbook.MaxEntries)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []guestbook.Entry
+	for rows.Next() {
+		var e guestbook.Entry
+		if err := rows.Scan(&e.User, &e.Fingerprint, &e.Message, &e.SignedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+func (s *SQLite) SaveFeedback(f feedback.Feedback) error {
+	_, err := s.db.Exec(
+		`INSERT INTO feedback (topic, message, user, fingerprint, submitted_at) VALUES (?, ?, ?, ?, ?)`,
+		f.Topic,
+		f.Message,
+		f.User,
+		f.Fingerprint,
+		f.SubmittedAt.UTC(),
+	)
+	return err
+}
+
+func (s *SQLite) Feedback() ([]feedback.Feedback, error) {
+	rows, err := s.db.Query(`SELECT topic, message, user, fingerprint, submitted_at FROM feedback ORDER BY submitted_at`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sent []feedback.Feedback
+	for rows.Next() {
+		var f feedback.Feedback
+		if err := rows.Scan(&f.Topic, &f.Message, &f.User, &f.Fingerprint, &f.SubmittedAt); err != nil {
+			return nil, err
+		}
+		sent = append(sent, f)
+	}
+	return sent, rows.Err()
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"strings"
+
+	"organize/components"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// positionsTab is the first tab, the Model's own list and reader. The other
+// tabs are sections.
+const positionsTab = 0
+
+// section is a top-level tab next to Positions, keeping its own state.
+type section interface {
+	title() string
+	// resize lays the section out again for a body width by height cells,
+	// after the terminal, theme or characters changed.
+	resize(m *Model, width int, height int)
+	// update handles the keys the tab bar leaves alone.
+	update(m *Model, msg tea.KeyMsg) tea.Cmd
+	view(m Model) string
+}
+
+// newSections builds the tabs after Positions for one session.
+func newSections() []section {
+	return []section{&archiveSection{}, &eventsSection{}, &teamSection{}, &guestbookSection{}, &feedbackSection{}, &aboutSection{}, &linksSection{}}
+}
+
+// typer is a section with an input open, which gets every key and every
+// message its input sends itself.
+type typer interface {
+	typing() bool
+	updateInput(m *Model, msg tea.Msg) tea.Cmd
+}
+
+// opener is a section that loads what it shows when its tab is opened, rather
+// than on every resize.
+type opener interface {
+	open(m *Model)
+}
+
+// typingSection is the open section while it has an input open.
+func (m Model) typingSection() (typer, bool) {
+	if m.tab == positionsTab {
+		return nil, false
+	}
+	t, ok := m.sections[m.tab-1].(typer)
+	return t, ok && t.typing()
+}
+
+// newSectionViewport scrolls a section with the session's keys.
+func newSectionViewport(keys keyMap) viewport.Model {
+	vp := viewport.New(0, 0)
+	vp.KeyMap.Up = keys.Up
+	vp.KeyMap.Down = keys.Down
+	vp.KeyMap.PageUp = keys.PageUp
+	vp.KeyMap.PageDown = keys.PageDown
+	vp.KeyMap.HalfPageUp = keys.HalfPageUp
+	vp.KeyMap.HalfPageDown = keys.HalfPageDown
+	return vp
+}
+
+func (m Model) tabTitles() []string {
+	titles := []string{"Positions"}
+	for _, s := range m.sections {
+		titles = append(titles, s.title())
+	}
+	return titles
+}
+
+func (m Model) tabBarView() string {
+	return components.TabBarView(m.theme, m.viewport.Width, m.tabTitles(), m.tab)
+}
+
+// tabFor is the tab a key from the Section binding picks, or -1.
+func (m Model) tabFor(msg tea.KeyMsg) int {
+	for i, k := range m.keys.Section.Keys() {
+		if k == msg.String() && i <= len(m.sections) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m *Model) switchTab(tab int) {
+	if tab < 0 || tab > len(m.sections) {
+		return
+	}
+	m.tab = tab
+	if tab == positionsTab {
+		return
+	}
+	if o, ok := m.sections[tab-1].(opener); ok {
+		o.open(m)
+	}
+	m.resizeSection()
+}
+
+// resizeSection lays the open section out for the screen.
+func (m *Model) resizeSection() {
+	if m.tab == positionsTab {
+		return
+	}
+	height := m.terminalHeight - strings.Count(m.announcementView()+m.tabBarView(), "\n") - 1
+	m.sections[m.tab-1].resize(m, m.viewport.Width, height)
+}
+
+func (m Model) updateSection(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.statusMessage = ""
+	switch {
+	case key.Matches(msg, m.keys.Quit):
+		return m, tea.Quit
+	case key.Matches(msg, m.keys.Section):
+		m.switchTab(m.tabFor(msg))
+	case key.Matches(msg, m.keys.Help):
+		m.previousTab = m.tab
+		m.tab = positionsTab
+		m.openHelp()
+	case key.Matches(msg, m.keys.Theme):
+		m.cycleTheme()
+	case key.Matches(msg, m.keys.Background):
+		m.toggleBackground()
+	case key.Matches(msg, m.keys.ASCII):
+		m.toggleASCII()
+	default:
+		return m, m.sections[m.tab-1].update(&m, msg)
+	}
+	return m, nil
+}
+
+// sectionView is the open section under the tab bar, with the status or the
+// short help below it.
+func (m Model) sectionView() string {
+	footer := lipgloss.PlaceHorizontal(m.viewport.Width, lipgloss.Right, m.help.View(m.keys))
+	if m.statusMessage != "" {
+		footer = strings.TrimSuffix(components.StatusMessageView(m.theme, m.statusMessage), "\n")
+	}
+	return m.tabBarView() + m.sections[m.tab-1].view(m) + "\n" + footer
+}
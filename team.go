@@ -0,0 +1,82 @@
+package main
+
+import (
+	"image"
+
+	"organize/components"
+	"organize/team"
+	"organize/termimage"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+)
+
+// teamSection shows the club's core team from team_file, each member with
+// their avatar.
+type teamSection struct {
+	viewport viewport.Model
+	members  []team.Member
+	// avatars are decoded once; they are drawn again for every theme.
+	avatars []image.Image
+}
+
+func (s *teamSection) title() string { return "Team" }
+
+func (s *teamSection) resize(m *Model, width int, height int) {
+	if s.viewport.Width == 0 {
+		s.viewport = newSectionViewport(m.keys)
+		s.load(m)
+	}
+	s.viewport.Width, s.viewport.Height = width, height
+
+	mode := termimage.ModeFor(m.theme.Profile)
+	if m.theme.ASCII {
+		mode = termimage.ASCII
+	}
+	names := make([]string, len(s.members))
+	roles := make([]string, len(s.members))
+	handles := make([]string, len(s.members))
+	avatars := make([]string, len(s.members))
+	for i, member := range s.members {
+		names[i], roles[i], handles[i] = member.Name, member.Role, member.GitHub
+		if s.avatars[i] != nil {
+			avatars[i] = termimage.RenderFit(s.avatars[i], components.AvatarHeight*2, components.AvatarHeight, mode)
+		}
+	}
+	s.viewport.SetContent(components.TeamView(m.theme, width, names, roles, handles, avatars))
+}
+
+// load reads the team file and the members' avatars. An avatar that cannot
+// be read is left out for the member's initials.
+func (s *teamSection) load(m *Model) {
+	members, err := team.Load(m.config.TeamFile)
+	if err != nil {
+		log.Warn("could not load team", "error", err)
+	}
+	s.members = members
+	s.avatars = make([]image.Image, len(members))
+	for i, member := range members {
+		if member.Avatar == "" {
+			continue
+		}
+		if s.avatars[i], err = termimage.Load(member.Avatar); err != nil {
+			log.Warn("could not load avatar", "member", member.Name, "error", err)
+		}
+	}
+}
+
+func (s *teamSection) update(m *Model, msg tea.KeyMsg) tea.Cmd {
+	var cmd tea.Cmd
+	s.viewport, cmd = s.viewport.Update(msg)
+	return cmd
+}
+
+func (s *teamSection) view(m Model) string {
+	if len(s.members) == 0 {
+		empty := lipgloss.NewStyle().Faint(true).Render("The team has not introduced itself yet.")
+		return lipgloss.Place(s.viewport.Width, s.viewport.Height, lipgloss.Center, lipgloss.Center, empty)
+	}
+	return s.viewport.View()
+}
@@ -0,0 +1,49 @@
+package team
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Member is someone on the club's core team.
+type Member struct {
+	Name   string `yaml:"name"`
+	Role   string `yaml:"role"`
+	GitHub string `yaml:"github"`
+	// Avatar is a JPEG or PNG, relative to the team file.
+	Avatar string `yaml:"avatar"`
+}
+
+type file struct {
+	Members []Member `yaml:"members"`
+}
+
+// Load reads the members listed in the YAML file at path, in order. A missing
+// file lists nobody.
+func Load(path string) ([]Member, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var f file
+	if err := yaml.Unmarshal(content, &f); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for i, member := range f.Members {
+		if member.Name == "" {
+			return nil, fmt.Errorf("%s: member %d has no name", path, i+1)
+		}
+		if member.Avatar != "" && !filepath.IsAbs(member.Avatar) {
+			f.Members[i].Avatar = filepath.Join(filepath.Dir(path), member.Avatar)
+		}
+	}
+	return f.Members, nil
+}
@@ -0,0 +1,146 @@
+package termimage
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+)
+
+// Protocol is how a terminal is sent pictures.
+type Protocol int
+
+const (
+	// Cells draws pictures with characters, see Render.
+	Cells Protocol = iota
+	// Sixel sends the bitmap as DEC sixels.
+	Sixel
+	// Kitty sends the bitmap with the kitty graphics protocol.
+	Kitty
+)
+
+func (p Protocol) String() string {
+	switch p {
+	case Sixel:
+		return "sixel"
+	case Kitty:
+		return "kitty"
+	}
+	return "cells"
+}
+
+// kittyChunk is the most base64 the kitty protocol takes in one escape.
+const kittyChunk = 4096
+
+// Scale resizes img to exactly width by height pixels.
+func Scale(img image.Image, width int, height int) image.Image {
+	scaled := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y, row := range resize(img, width, height) {
+		for x, p := range row {
+			scaled.Set(x, y, color.RGBA{R: uint8(p.r), G: uint8(p.g), B: uint8(p.b), A: 255})
+		}
+	}
+	return scaled
+}
+
+// EncodeSixel encodes img as a sixel image in the colours of the 6x6x6 cube
+// 256-colour terminals use, which every sixel terminal has room for.
+func EncodeSixel(img image.Image) string {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	pixels := resize(img, width, height)
+
+	var b strings.Builder
+	// P2=1 leaves pixels no colour is drawn in alone.
+	fmt.Fprintf(&b, "\x1bP0;1q\"1;1;%d;%d", width, height)
+	for i := 0; i < 216; i++ {
+		fmt.Fprintf(&b, "#%d;2;%d;%d;%d", i, i/36*20, i/6%6*20, i%6*20)
+	}
+
+	for band := 0; band < height; band += 6 {
+		// Every colour in the band gets one pass over it, with a bit set for
+		// each of the six rows of a column it is drawn in.
+		passes := make(map[int][]byte)
+		var order []int
+		for dy := 0; dy < 6 && band+dy < height; dy++ {
+			for x, p := range pixels[band+dy] {
+				c := p.cube()
+				if passes[c] == nil {
+					passes[c] = make([]byte, width)
+					order = append(order, c)
+				}
+				passes[c][x] |= 1 << dy
+			}
+		}
+		for i, c := range order {
+			if i > 0 {
+				b.WriteByte('$')
+			}
+			fmt.Fprintf(&b, "#%d", c)
+			writeSixels(&b, passes[c])
+		}
+		b.WriteByte('-')
+	}
+	b.WriteString("\x1b\\")
+	return b.String()
+}
+
+// writeSixels writes one pass of sixels, run-length encoding repeats.
+func writeSixels(b *strings.Builder, sixels []byte) {
+	for i := 0; i < len(sixels); {
+		run := 1
+		for i+run < len(sixels) && sixels[i+run] == sixels[i] {
+			run++
+		}
+		if run > 3 {
+			fmt.Fprintf(b, "!%d%c", run, sixels[i]+63)
+		} else {
+			b.WriteString(strings.Repeat(string(rune(sixels[i]+63)), run))
+		}
+		i += run
+	}
+}
+
+// KittyTransmit stores img in a kitty graphics terminal as image id, without
+// showing it yet.
+func KittyTransmit(img image.Image, id int) (string, error) {
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, img); err != nil {
+		return "", err
+	}
+	data := base64.StdEncoding.EncodeToString(encoded.Bytes())
+
+	var b strings.Builder
+	for first := true; first || data != ""; first = false {
+		chunk := data
+		if len(chunk) > kittyChunk {
+			chunk = chunk[:kittyChunk]
+		}
+		data = data[len(chunk):]
+		more := 0
+		if data != "" {
+			more = 1
+		}
+		if first {
+			fmt.Fprintf(&b, "\x1b_Ga=t,f=100,i=%d,q=2,m=%d;%s\x1b\\", id, more, chunk)
+		} else {
+			fmt.Fprintf(&b, "\x1b_Gm=%d;%s\x1b\\", more, chunk)
+		}
+	}
+	return b.String(), nil
+}
+
+// KittyPlace shows image id at the cursor, stretched over columns by rows
+// cells, in place of where it was shown before. The cursor stays put.
+func KittyPlace(id int, columns int, rows int) string {
+	return fmt.Sprintf("\x1b_Ga=p,i=%d,p=1,c=%d,r=%d,C=1,q=2\x1b\\", id, columns, rows)
+}
+
+// KittyDelete takes image id off the screen. The terminal keeps it for the
+// next KittyPlace.
+func KittyDelete(id int) string {
+	return fmt.Sprintf("\x1b_Ga=d,d=i,i=%d,q=2\x1b\\", id)
+}
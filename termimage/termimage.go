@@ -0,0 +1,184 @@
+package termimage
+
+import (
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"strings"
+
+	"github.com/muesli/termenv"
+)
+
+type Mode int
+
+const (
+	TrueColor Mode = iota
+	ANSI256
+	ASCII
+)
+
+const asciiRamp = " .:-=+*#%@"
+
+// ModeFor picks the mode for a terminal's colour profile. Half blocks need at
+// least 256 colours to look like anything, so fewer get ASCII.
+func ModeFor(profile termenv.Profile) Mode {
+	switch profile {
+	case termenv.TrueColor:
+		return TrueColor
+	case termenv.ANSI256:
+		return ANSI256
+	}
+	return ASCII
+}
+
+func Load(path string) (image.Image, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	return img, err
+}
+
+func RenderFile(path string, height int, mode Mode) (string, error) {
+	img, err := Load(path)
+	if err != nil {
+		return "", err
+	}
+	return Render(img, height, mode), nil
+}
+
+// Render scales img to height terminal rows. In the colour modes every cell is
+// an upper half block carrying two pixels; in ASCII mode every cell is one
+// character from a brightness ramp.
+func Render(img image.Image, height int, mode Mode) string {
+	bounds := img.Bounds()
+	if height <= 0 || bounds.Dx() == 0 || bounds.Dy() == 0 {
+		return ""
+	}
+
+	// Terminal cells are roughly twice as tall as they are wide.
+	width := bounds.Dx() * height * 2 / bounds.Dy()
+	if width <= 0 {
+		width = 1
+	}
+
+	if mode == ASCII {
+		pixels := resize(img, width, height)
+		lines := make([]string, height)
+		for y := range pixels {
+			var b strings.Builder
+			for _, p := range pixels[y] {
+				b.WriteByte(asciiRamp[p.luminance()*(len(asciiRamp)-1)/255])
+			}
+			lines[y] = b.String()
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	pixels := resize(img, width, height*2)
+	lines := make([]string, height)
+	for y := 0; y < height; y++ {
+		var b strings.Builder
+		for x := 0; x < width; x++ {
+			top, bottom := pixels[2*y][x], pixels[2*y+1][x]
+			if mode == ANSI256 {
+				fmt.Fprintf(&b, "\x1b[38;5;%dm\x1b[48;5;%dm▀", top.ansi256(), bottom.ansi256())
+				continue
+			}
+			fmt.Fprintf(&b, "\x1b[38;2;%d;%d;%dm\x1b[48;2;%d;%d;%dm▀", top.r, top.g, top.b, bottom.r, bottom.g, bottom.b)
+		}
+		b.WriteString("\x1b[0m")
+		lines[y] = b.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// RenderFit scales img to the largest size that fits in width columns and
+// height rows without stretching it.
+func RenderFit(img image.Image, width int, height int, mode Mode) string {
+	bounds := img.Bounds()
+	if bounds.Dx() > 0 && width*bounds.Dy() < height*2*bounds.Dx() {
+		height = width * bounds.Dy() / (2 * bounds.Dx())
+	}
+	return Render(img, height, mode)
+}
+
+type pixel struct {
+	r, g, b int
+}
+
+func (p pixel) luminance() int {
+	return (299*p.r + 587*p.g + 114*p.b) / 1000
+}
+
+// ansi256 maps the pixel onto the 6x6x6 colour cube, or onto the grayscale
+// ramp when the channels are close enough to be a shade of gray.
+func (p pixel) ansi256() int {
+	spread := abs(p.r - p.g)
+	if d := abs(p.g - p.b); d > spread {
+		spread = d
+	}
+	if d := abs(p.r - p.b); d > spread {
+		spread = d
+	}
+	if spread < 10 {
+		if p.r < 8 {
+			return 16
+		}
+		if p.r > 238 {
+			return 231
+		}
+		return 232 + (p.r-8)*24/231
+	}
+	return 16 + p.cube()
+}
+
+// cube is the pixel's index in the 6x6x6 colour cube.
+func (p pixel) cube() int {
+	level := func(c int) int { return (c*5 + 127) / 255 }
+	return 36*level(p.r) + 6*level(p.g) + level(p.b)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// resize downsamples img by averaging every source pixel that falls inside
+// each target pixel.
+func resize(img image.Image, width, height int) [][]pixel {
+	bounds := img.Bounds()
+	pixels := make([][]pixel, height)
+	for y := 0; y < height; y++ {
+		pixels[y] = make([]pixel, width)
+		y0 := bounds.Min.Y + y*bounds.Dy()/height
+		y1 := bounds.Min.Y + (y+1)*bounds.Dy()/height
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*bounds.Dx()/width
+			x1 := bounds.Min.X + (x+1)*bounds.Dx()/width
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+
+			var r, g, b, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, _ := img.At(sx, sy).RGBA()
+					r, g, b, n = r+uint64(cr), g+uint64(cg), b+uint64(cb), n+1
+				}
+			}
+			pixels[y][x] = pixel{int(r / n >> 8), int(g / n >> 8), int(b / n >> 8)}
+		}
+	}
+	return pixels
+}
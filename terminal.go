@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"organize/termimage"
+
+	"github.com/charmbracelet/ssh"
+	"github.com/muesli/termenv"
+)
+
+// queryTimeout bounds how long a session waits for the terminal to answer
+// its queries before going with the client's environment.
+const queryTimeout = 500 * time.Millisecond
+
+// The usual size of a character cell in pixels, for terminals that don't say.
+const (
+	defaultCellWidth  = 10
+	defaultCellHeight = 20
+)
+
+// terminalQueries ask for the background colour, whether kitty graphics
+// work, the size of a cell in pixels and the device attributes, which list
+// sixel support. The cursor position query comes last, see query.
+const terminalQueries = termenv.OSC + "11;?" + termenv.ST +
+	"\x1b_Gi=31,s=1,v=1,a=q,t=d,f=24;AAAA" + termenv.ST +
+	termenv.CSI + "16t" +
+	termenv.CSI + "c" +
+	termenv.CSI + "6n"
+
+var (
+	backgroundReply = regexp.MustCompile(`\x1b\]11;rgb:([0-9a-fA-F]{1,4})/([0-9a-fA-F]{1,4})/([0-9a-fA-F]{1,4})`)
+	kittyReply      = regexp.MustCompile(`\x1b_Gi=31;OK`)
+	cellSizeReply   = regexp.MustCompile(`\x1b\[6;(\d+);(\d+)t`)
+	attributesReply = regexp.MustCompile(`\x1b\[\?([0-9;]*)c`)
+)
+
+// terminal is what a session found out about the visitor's terminal when it
+// connected. It stays on the session's context for the farewell.
+type terminal struct {
+	light    bool
+	ascii    bool
+	profile  termenv.Profile
+	graphics termimage.Protocol
+	// cellWidth and cellHeight are the size of a character cell in pixels.
+	cellWidth  int
+	cellHeight int
+}
+
+type terminalKey struct{}
+
+// asciiTerminals garble box drawing or emoji.
+var asciiTerminals = []string{"dumb", "vt52", "vt100", "vt102", "vt220", "ansi"}
+
+// asciiTerminal tells terminals that only draw ASCII apart by their TERM, or
+// by a locale forwarded without UTF-8.
+func asciiTerminal(term string, environ []string) bool {
+	for _, name := range asciiTerminals {
+		if term == name {
+			return true
+		}
+	}
+	// The first of these that is set decides, like it does for the C library.
+	for _, name := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		for _, env := range environ {
+			if !strings.HasPrefix(env, name+"=") {
+				continue
+			}
+			locale := strings.ToLower(strings.TrimPrefix(env, name+"="))
+			if locale == "" {
+				break
+			}
+			return !strings.Contains(locale, "utf-8") && !strings.Contains(locale, "utf8")
+		}
+	}
+	return false
+}
+
+// colorProfile is the colour depth the client advertises. NO_COLOR asks for
+// none, see https://no-color.org; otherwise COLORTERM and the TERM name tell
+// truecolor, 256-colour and 16-colour terminals apart.
+func colorProfile(term string, environ []string) termenv.Profile {
+	for _, env := range environ {
+		name, value, _ := strings.Cut(env, "=")
+		switch {
+		case name == "NO_COLOR" && value != "":
+			return termenv.Ascii
+		case name == "COLORTERM" && (value == "truecolor" || value == "24bit"):
+			return termenv.TrueColor
+		}
+	}
+	switch {
+	case term == "dumb":
+		return termenv.Ascii
+	case strings.Contains(term, "truecolor") || strings.Contains(term, "24bit") || strings.Contains(term, "direct"):
+		return termenv.TrueColor
+	case strings.Contains(term, "256color"):
+		return termenv.ANSI256
+	}
+	return termenv.ANSI
+}
+
+// sessionInput is what the bubbletea program reads keys from. It reads the
+// session on its own goroutine so the background query can give up on a
+// terminal that stays quiet without losing the keys typed afterwards.
+type sessionInput struct {
+	chunks  chan []byte
+	pending []byte
+}
+
+func newSessionInput(s ssh.Session) *sessionInput {
+	input := &sessionInput{chunks: make(chan []byte)}
+	go func() {
+		defer close(input.chunks)
+		for {
+			buf := make([]byte, 1024)
+			n, err := s.Read(buf)
+			if n > 0 {
+				select {
+				case input.chunks <- buf[:n]:
+				case <-s.Context().Done():
+					return
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return input
+}
+
+func (i *sessionInput) Read(p []byte) (int, error) {
+	if len(i.pending) == 0 {
+		chunk, ok := <-i.chunks
+		if !ok {
+			return 0, io.EOF
+		}
+		i.pending = chunk
+	}
+	n := copy(p, i.pending)
+	i.pending = i.pending[n:]
+	return n, nil
+}
+
+// probeTerminal asks the terminal what it can do and looks at the client's
+// environment where it doesn't say.
+func (i *sessionInput) probeTerminal(s ssh.Session, pty ssh.Pty) terminal {
+	reply := i.query(s)
+	term := terminal{
+		light:      !isDark(reply, s.Environ()),
+		ascii:      asciiTerminal(pty.Term, s.Environ()),
+		profile:    colorProfile(pty.Term, s.Environ()),
+		graphics:   graphicsProtocol(reply, pty.Term),
+		cellWidth:  defaultCellWidth,
+		cellHeight: defaultCellHeight,
+	}
+	if match := cellSizeReply.FindSubmatch(reply); match != nil {
+		height, _ := strconv.Atoi(string(match[1]))
+		width, _ := strconv.Atoi(string(match[2]))
+		if width > 0 && height > 0 {
+			term.cellWidth, term.cellHeight = width, height
+		}
+	}
+	return term
+}
+
+// query sends terminalQueries and returns the replies. The cursor position
+// query sent last is answered by every terminal, so its reply marks the end
+// of the answers even when the others are not supported. A terminal that
+// stays quiet gets no say.
+func (i *sessionInput) query(s ssh.Session) []byte {
+	if _, err := io.WriteString(s, terminalQueries); err != nil {
+		return nil
+	}
+
+	var reply []byte
+	timeout := time.After(queryTimeout)
+	for {
+		select {
+		case chunk, ok := <-i.chunks:
+			if !ok {
+				return nil
+			}
+			reply = append(reply, chunk...)
+			if end := cursorReplyEnd(reply); end >= 0 {
+				i.pending = reply[end:]
+				return reply[:end]
+			}
+		case <-timeout:
+			i.pending = reply
+			return nil
+		}
+	}
+}
+
+// graphicsProtocol picks kitty graphics when the terminal answered the kitty
+// query, sixel when its device attributes include sixel graphics (4), and
+// characters otherwise.
+func graphicsProtocol(reply []byte, term string) termimage.Protocol {
+	if kittyReply.Match(reply) || (reply == nil && term == "xterm-kitty") {
+		return termimage.Kitty
+	}
+	if match := attributesReply.FindSubmatch(reply); match != nil {
+		for _, attribute := range strings.Split(string(match[1]), ";") {
+			if attribute == "4" {
+				return termimage.Sixel
+			}
+		}
+	}
+	return termimage.Cells
+}
+
+// cursorReplyEnd finds the end of a "ESC [ row ; col R" reply.
+func cursorReplyEnd(b []byte) int {
+	start := bytes.Index(b, []byte(termenv.CSI))
+	for start >= 0 {
+		j := start + len(termenv.CSI)
+		for j < len(b) && (b[j] >= '0' && b[j] <= '9' || b[j] == ';') {
+			j++
+		}
+		if j < len(b) && b[j] == 'R' && j > start+len(termenv.CSI) {
+			return j + 1
+		}
+		next := bytes.Index(b[start+1:], []byte(termenv.CSI))
+		if next < 0 {
+			return -1
+		}
+		start += 1 + next
+	}
+	return -1
+}
+
+func isDark(reply []byte, environ []string) bool {
+	if match := backgroundReply.FindSubmatch(reply); match != nil {
+		var rgb [3]float64
+		for c := range rgb {
+			value, _ := strconv.ParseUint(string(match[c+1]), 16, 16)
+			rgb[c] = float64(value) / float64(uint64(1)<<(4*len(match[c+1]))-1)
+		}
+		return 0.2126*rgb[0]+0.7152*rgb[1]+0.0722*rgb[2] < 0.5
+	}
+	for _, env := range environ {
+		if strings.HasPrefix(env, "COLORFGBG=") {
+			parts := strings.Split(strings.TrimPrefix(env, "COLORFGBG="), ";")
+			if bg, err := strconv.Atoi(parts[len(parts)-1]); err == nil {
+				return bg != 7 && bg != 15
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"strings"
+
+	"organize/components"
+	"organize/utils"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/reflow/truncate"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// heading is an entry in a position's table of contents.
+type heading struct {
+	level int
+	title string
+	// line is where the heading ended up in the rendered position.
+	line int
+}
+
+// tableOfContents lists the headings of a markdown position with the lines
+// glamour rendered them on. Headings are looked for in order, so one that
+// repeats an earlier heading's text is found below it.
+func tableOfContents(source string, rendered string) []heading {
+	var headings []heading
+	root := markdown.Parser().Parse(text.NewReader([]byte(source)))
+	ast.Walk(root, func(node ast.Node, entering bool) (ast.WalkStatus, error) {
+		if h, ok := node.(*ast.Heading); ok && entering {
+			title := strings.TrimSpace(string(h.Text([]byte(source))))
+			if title != "" {
+				headings = append(headings, heading{level: h.Level, title: title})
+			}
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+
+	lines := strings.Split(utils.StripANSI(rendered), "\n")
+	found := headings[:0]
+	next := 0
+	for _, h := range headings {
+		for i := next; i < len(lines); i++ {
+			if strings.Contains(lines[i], h.title) {
+				h.line = i
+				found = append(found, h)
+				next = i + 1
+				break
+			}
+		}
+	}
+	return found
+}
+
+// buildContents refreshes the table of contents after the open file is
+// rendered. Only markdown positions have one.
+func (m *Model) buildContents() {
+	m.contents = nil
+	if !m.markdownOpen() {
+		return
+	}
+	m.contents = tableOfContents(m.fileContent, m.renderedContent)
+	m.contentsCursor = utils.Min(m.contentsCursor, utils.Max(0, len(m.contents)-1))
+}
+
+func (m *Model) openContents() {
+	if len(m.contents) == 0 {
+		m.statusMessage = "This position has no headings"
+		return
+	}
+	m.contentsOpen = true
+	m.contentsCursor = utils.Max(0, m.currentSection())
+}
+
+// currentSection is the heading the reader is scrolled to, or -1 above the
+// first one.
+func (m Model) currentSection() int {
+	current := -1
+	for i, h := range m.contents {
+		if h.line <= m.viewport.YOffset {
+			current = i
+		}
+	}
+	return current
+}
+
+func (m Model) updateContents(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Quit):
+		return m, tea.Quit
+	case key.Matches(msg, m.keys.Contents), key.Matches(msg, m.keys.Back):
+		m.contentsOpen = false
+	case key.Matches(msg, m.keys.Up):
+		m.contentsCursor = utils.Max(0, m.contentsCursor-1)
+	case key.Matches(msg, m.keys.Down):
+		m.contentsCursor = utils.Min(len(m.contents)-1, m.contentsCursor+1)
+	case key.Matches(msg, m.keys.Enter):
+		m.viewport.SetYOffset(m.contents[m.contentsCursor].line)
+		m.contentsOpen = false
+	}
+	return m, nil
+}
+
+// contentsWidth is how wide the table of contents is: a sidebar next to the
+// position, or all of a narrow terminal.
+func (m Model) contentsWidth() int {
+	if m.viewport.Width < components.NarrowWidth {
+		return m.viewport.Width
+	}
+	return utils.Min(36, m.viewport.Width/3)
+}
+
+// readerView is the open position with a scrollbar down its right-hand side
+// when it is longer than the screen, and the table of contents next to it
+// while that is open. The QR code to apply takes its place while it is shown.
+func (m Model) readerView() string {
+	if m.applyQROpen {
+		return m.applyQRView()
+	}
+	width := m.viewport.Width
+	sidebar := ""
+	if m.contentsOpen {
+		titles := make([]string, len(m.contents))
+		levels := make([]int, len(m.contents))
+		for i, h := range m.contents {
+			titles[i], levels[i] = h.title, h.level
+		}
+		sidebarWidth := m.contentsWidth()
+		sidebar = components.ContentsView(m.theme, sidebarWidth, m.viewport.Height, titles, levels, m.contentsCursor, m.currentSection())
+		if sidebarWidth >= width {
+			return sidebar
+		}
+		width -= sidebarWidth
+	}
+
+	var scrollbar []string
+	if total := m.viewport.TotalLineCount(); total > m.viewport.Height {
+		scrollbar = strings.Split(components.ScrollbarView(m.theme, m.viewport.Height, total, m.viewport.YOffset), "\n")
+		width--
+	}
+	if width == m.viewport.Width {
+		return m.viewport.View()
+	}
+
+	lines := strings.Split(m.viewport.View(), "\n")
+	for i, line := range lines {
+		lines[i] = truncate.String(line, uint(width))
+		lines[i] += strings.Repeat(" ", utils.Max(0, width-lipgloss.Width(lines[i])))
+		if i < len(scrollbar) {
+			lines[i] += scrollbar[i]
+		}
+	}
+	if sidebar == "" {
+		return strings.Join(lines, "\n")
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Top, strings.Join(lines, "\n"), sidebar)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	"organize/config"
+
+	"github.com/charmbracelet/ssh"
+	"github.com/charmbracelet/wish"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.20.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer starts the server's spans. Until setupTracing installs a provider it
+// hands out spans that record nothing, so tracing costs nothing while off.
+var tracer = otel.Tracer("organize")
+
+// traceContextKey is where traceSession leaves the session's span in its
+// context for the program to start its own spans under.
+type traceContextKey struct{}
+
+// setupTracing exports spans to the collector at cfg.Endpoint, when one is
+// set, and returns a function that flushes what is left on shutdown.
+func setupTracing(cfg config.Tracing) (func(context.Context) error, error) {
+	if cfg.Endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+	options := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.Endpoint)}
+	if cfg.Insecure {
+		options = append(options, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(context.Background(), options...)
+	if err != nil {
+		return nil, err
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName("organize"))),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// traceSession wraps each session in a span, from the connect until the
+// visitor leaves, that everything done for the session is traced under.
+func traceSession() wish.Middleware {
+	return func(next ssh.Handler) ssh.Handler {
+		return func(s ssh.Session) {
+			attributes := []attribute.KeyValue{
+				semconv.NetSockPeerAddr(remoteIP(s.RemoteAddr())),
+				attribute.String("ssh.user", s.User()),
+			}
+			if record := sessionRecordFrom(s); record != nil {
+				attributes = append(attributes, attribute.String("session", record.id))
+			}
+			if command := s.Command(); len(command) > 0 {
+				attributes = append(attributes, attribute.String("ssh.command", strings.Join(command, " ")))
+			}
+			if pty, _, active := s.Pty(); active {
+				attributes = append(attributes, attribute.String("ssh.term", pty.Term))
+			}
+			ctx, span := tracer.Start(context.Background(), "ssh.session",
+				trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(attributes...))
+			defer span.End()
+			s.Context().SetValue(traceContextKey{}, ctx)
+			next(s)
+		}
+	}
+}
+
+// sessionTrace is the context traceSession started for s, for spans that
+// belong to the session.
+func sessionTrace(s ssh.Session) context.Context {
+	if ctx, ok := s.Context().Value(traceContextKey{}).(context.Context); ok {
+		return ctx
+	}
+	return context.Background()
+}
+
+// endSpan records err on span, if there was one, and ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
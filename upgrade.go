@@ -0,0 +1,259 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+const (
+	// listenFDsEnv hands a new process the listeners of the one it replaces,
+	// as name=fd pairs.
+	listenFDsEnv = "JODC_LISTEN_FDS"
+	// readyFDEnv is the pipe a new process writes to once it takes connections.
+	readyFDEnv = "JODC_READY_FD"
+	// listenFDsStart is the first descriptor systemd passes sockets on.
+	listenFDsStart = 3
+	// upgradeTimeout is how long the new process gets to start taking
+	// connections before the old one gives up on it and carries on.
+	upgradeTimeout = time.Minute
+)
+
+// listeners opens the server's sockets, or takes them over from systemd or the
+// process this one replaces, and can pass them on to the next one in turn. The
+// kernel keeps queueing connections throughout, so none are refused during a
+// deploy.
+type listeners struct {
+	mu        sync.Mutex
+	inherited map[string]net.Listener
+	names     []string
+	open      map[string]net.Listener
+}
+
+// newListeners picks up whatever listeners the process was started with.
+func newListeners() *listeners {
+	l := &listeners{inherited: make(map[string]net.Listener), open: make(map[string]net.Listener)}
+	fds := os.Getenv(listenFDsEnv)
+	os.Unsetenv(listenFDsEnv)
+	for _, pair := range strings.Split(fds, ",") {
+		name, fd, found := strings.Cut(pair, "=")
+		if !found {
+			continue
+		}
+		n, err := strconv.Atoi(fd)
+		if err != nil {
+			log.Warn("ignoring inherited listener", "name", name, "fd", fd)
+			continue
+		}
+		l.inherit(name, n)
+	}
+	l.activated()
+	return l
+}
+
+// activated picks up the sockets systemd passes under socket activation,
+// named after FileDescriptorName= in the .socket unit. A single socket left
+// unnamed is taken for ssh.
+func (l *listeners) activated() {
+	pid, _ := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	count, _ := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+	// They are meant for this process only, not a new one started on SIGUSR2.
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+	if pid != os.Getpid() {
+		return
+	}
+	for i := 0; i < count; i++ {
+		name := ""
+		if i < len(names) {
+			name = names[i]
+		}
+		switch name {
+		case "ssh", "web", "health", "pprof":
+		default:
+			if count > 1 {
+				log.Warn("ignoring socket from systemd, name it ssh, web, health or pprof", "name", name)
+				syscall.Close(listenFDsStart + i)
+				continue
+			}
+			name = "ssh"
+		}
+		l.inherit(name, listenFDsStart+i)
+	}
+}
+
+func (l *listeners) inherit(name string, fd int) {
+	file := os.NewFile(uintptr(fd), name)
+	listener, err := net.FileListener(file)
+	file.Close()
+	if err != nil {
+		log.Warn("ignoring inherited listener", "name", name, "error", err)
+		return
+	}
+	l.inherited[name] = listener
+}
+
+// listen returns the inherited listener called name, or listens on addr,
+// which is a host and port or unix: and a socket path.
+func (l *listeners) listen(name string, addr string) (net.Listener, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	listener, ok := l.inherited[name]
+	if ok {
+		delete(l.inherited, name)
+		log.Info("took over listener", "name", name, "addr", listener.Addr().String())
+	} else {
+		var err error
+		if listener, err = listenAddr(addr); err != nil {
+			return nil, err
+		}
+	}
+	l.names = append(l.names, name)
+	l.open[name] = listener
+	return listener, nil
+}
+
+// ready tells the process this one replaces that it can stop taking
+// connections, and closes whatever it passed down that went unused.
+func (l *listeners) ready() {
+	l.mu.Lock()
+	for name, listener := range l.inherited {
+		log.Warn("closing inherited listener that is no longer configured", "name", name)
+		listener.Close()
+	}
+	l.inherited = nil
+	l.mu.Unlock()
+
+	fd, err := strconv.Atoi(os.Getenv(readyFDEnv))
+	os.Unsetenv(readyFDEnv)
+	if err != nil {
+		return
+	}
+	ready := os.NewFile(uintptr(fd), "ready")
+	ready.Write([]byte{1})
+	ready.Close()
+}
+
+// handOver starts a new copy of the server on the same listeners and waits
+// until it takes connections. The caller stops accepting afterwards and lets
+// its own sessions finish.
+func (l *listeners) handOver() error {
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	ready, readyWriter, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer ready.Close()
+
+	l.mu.Lock()
+	files := []*os.File{readyWriter}
+	var fds []string
+	for _, name := range l.names {
+		listener, ok := l.open[name].(interface{ File() (*os.File, error) })
+		if !ok {
+			continue
+		}
+		file, err := listener.File()
+		if err != nil {
+			l.mu.Unlock()
+			closeFiles(files)
+			return fmt.Errorf("%s listener: %w", name, err)
+		}
+		// ExtraFiles start at fd 3.
+		fds = append(fds, fmt.Sprintf("%s=%d", name, 3+len(files)))
+		files = append(files, file)
+	}
+	l.mu.Unlock()
+
+	cmd := exec.Command(executable, os.Args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.ExtraFiles = files
+	cmd.Env = append(os.Environ(), listenFDsEnv+"="+strings.Join(fds, ","), readyFDEnv+"=3")
+	err = cmd.Start()
+	closeFiles(files)
+	if err != nil {
+		return err
+	}
+	log.Info("started new process", "pid", cmd.Process.Pid)
+
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+	readied := make(chan struct{})
+	go func() {
+		// A process that dies first closes the pipe without writing to it.
+		if n, _ := ready.Read(make([]byte, 1)); n == 1 {
+			close(readied)
+		}
+	}()
+	select {
+	case <-readied:
+		return nil
+	case err := <-exited:
+		return fmt.Errorf("new process exited before taking connections: %v", err)
+	case <-time.After(upgradeTimeout):
+		cmd.Process.Kill()
+		return errors.New("new process did not take connections in time")
+	}
+}
+
+// close stops taking connections on every listener.
+func (l *listeners) close() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, listener := range l.open {
+		// The new process serves the same socket file, so it has to stay.
+		if unix, ok := listener.(*net.UnixListener); ok {
+			unix.SetUnlinkOnClose(false)
+		}
+		listener.Close()
+	}
+}
+
+// listenAddr listens on a host and port, or on unix: and a socket path,
+// replacing a socket file left behind by an earlier run.
+func listenAddr(addr string) (net.Listener, error) {
+	if !strings.HasPrefix(addr, "unix:") {
+		return net.Listen("tcp", addr)
+	}
+	path := strings.TrimPrefix(addr, "unix:")
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return net.Listen("unix", path)
+}
+
+func closeFiles(files []*os.File) {
+	for _, file := range files {
+		file.Close()
+	}
+}
+
+// serveHTTP serves server on the listener called name in the background. The
+// listener closing means it was handed over, not that the server failed.
+func serveHTTP(ls *listeners, name string, server *http.Server) {
+	listener, err := ls.listen(name, server.Addr)
+	if err != nil {
+		log.Error("could not start "+name+" server", "error", err)
+		return
+	}
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) && !errors.Is(err, net.ErrClosed) {
+			log.Error("could not start "+name+" server", "error", err)
+		}
+	}()
+}
@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const frontmatterDelimiter = "---"
+
+var errUnterminatedFrontmatter = errors.New("frontmatter is missing its closing ---")
+
+type Frontmatter struct {
+	Title       string    `yaml:"title"`
+	Description string    `yaml:"description"`
+	Tags        []string  `yaml:"tags"`
+	Deadline    time.Time `yaml:"deadline"`
+	Status      string    `yaml:"status"`
+	Updated     time.Time `yaml:"updated"` // the file's modification time when left out
+	ApplyURL    string    `yaml:"apply_url"`
+	Order       int       `yaml:"order"` // ranks the position in the featured order, lowest first; 0 leaves it unranked
+}
+
+// Draft reports whether the position is still being written, for admins'
+// eyes only.
+func (f Frontmatter) Draft() bool {
+	return f.Status == "draft"
+}
+
+// ArchiveDir is the directory in the content directory that positions are
+// moved into once they are filled.
+const ArchiveDir = "archive"
+
+// Archived reports whether a position has closed for good: its status says
+// closed or it was moved into ArchiveDir.
+func Archived(fileName string, f Frontmatter) bool {
+	return f.Status == "closed" || strings.HasPrefix(fileName, ArchiveDir+"/")
+}
+
+// Closes is when applications for the position close: its deadline, or the
+// end of that day for a deadline without a time.
+func (f Frontmatter) Closes() time.Time {
+	d := f.Deadline
+	if d.Equal(time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, d.Location())) {
+		return d.AddDate(0, 0, 1)
+	}
+	return d
+}
+
+// Expired reports whether the position's deadline has passed by now.
+func (f Frontmatter) Expired(now time.Time) bool {
+	return !f.Deadline.IsZero() && !now.Before(f.Closes())
+}
+
+// ParsePosition splits a position file into its frontmatter and markdown body.
+// Files without a frontmatter block fall back to the older layout, where the
+// first line is the description and the second line is blank.
+func ParsePosition(content string) (Frontmatter, string, error) {
+	var frontmatter Frontmatter
+
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	if !strings.HasPrefix(content, frontmatterDelimiter+"\n") {
+		lines := strings.Split(content, "\n")
+		frontmatter.Description = strings.TrimSpace(strings.TrimPrefix(lines[0], "->"))
+		if len(lines) < 2 {
+			return frontmatter, "", nil
+		}
+		return frontmatter, strings.Join(lines[2:], "\n"), nil
+	}
+
+	body, err := ParseFrontmatter(content, &frontmatter)
+	if err != nil {
+		return frontmatter, content, err
+	}
+	return frontmatter, body, nil
+}
+
+// ParseFrontmatter decodes the YAML block content starts with into out and
+// returns the markdown body after it. Content without a block is all body.
+func ParseFrontmatter(content string, out interface{}) (string, error) {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	if !strings.HasPrefix(content, frontmatterDelimiter+"\n") {
+		return content, nil
+	}
+
+	rest := content[len(frontmatterDelimiter)+1:]
+	end := strings.Index(rest, "\n"+frontmatterDelimiter+"\n")
+	if end < 0 {
+		if !strings.HasSuffix(rest, "\n"+frontmatterDelimiter) {
+			return content, errUnterminatedFrontmatter
+		}
+		end = len(rest) - len(frontmatterDelimiter) - 1
+	}
+
+	if err := yaml.Unmarshal([]byte(rest[:end]), out); err != nil {
+		return content, err
+	}
+	body := ""
+	if bodyStart := end + len(frontmatterDelimiter) + 2; bodyStart < len(rest) {
+		body = strings.TrimLeft(rest[bodyStart:], "\n")
+	}
+	return body, nil
+}
@@ -1,14 +1,30 @@
 package utils
 
 import (
-	"bufio"
 	"fmt"
+	"io"
 	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
 	"time"
+	"unicode"
 
+	"github.com/aymanbagabas/go-osc52/v2"
+	"github.com/charmbracelet/log"
 	"github.com/charmbracelet/ssh"
+	"github.com/mattn/go-runewidth"
+	"github.com/muesli/reflow/truncate"
+	"github.com/muesli/termenv"
 )
 
+var ansiPattern = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]`)
+
+var sgrPattern = regexp.MustCompile(`\x1b\[([0-9;]*)m`)
+
 func Max(a, b int) int {
 	if a > b {
 		return a
@@ -16,38 +32,227 @@ func Max(a, b int) int {
 	return b
 }
 
+func Min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func Abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func Truncate(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	return truncate.StringWithTail(s, uint(width), "…")
+}
+
 type PositionMeta struct {
 	FileNames        []string
+	FileTitles       []string
 	FileDescriptions []string
+	FileMetadata     []Frontmatter
 }
 
+// GetPositionMeta lists the positions under dir and its subdirectories,
+// leaving out any it cannot read or parse and links that point outside dir.
+// Names are slash-separated paths relative to dir. Every subdirectory gets an
+// entry of its own, named with a trailing slash and listed after the files
+// next to it, followed by everything inside it.
 func GetPositionMeta(dir string) (*PositionMeta, error) {
-	files, err := os.ReadDir(dir)
-	if err != nil {
+	var positionMeta PositionMeta
+	if err := positionMeta.walk(dir, ""); err != nil {
 		return nil, err
 	}
+	return &positionMeta, nil
+}
 
-	fileNames := make([]string, len(files))
-	fileDescriptions := make([]string, len(files))
-	for i := range files {
-		fileName := files[i].Name()
-		fileNames[i] = fileName
+func (p *PositionMeta) walk(root string, rel string) error {
+	files, err := os.ReadDir(filepath.Join(root, filepath.FromSlash(rel)))
+	if err != nil {
+		return err
+	}
 
-		file, err := os.Open("directory/" + fileName)
-		if err != nil {
-			return nil, err
+	var dirs []string
+	for _, file := range files {
+		fileName := path.Join(rel, file.Name())
+		if file.IsDir() {
+			// Skip .git and friends when the content directory is a checkout.
+			if !strings.HasPrefix(file.Name(), ".") {
+				dirs = append(dirs, fileName)
+			}
+			continue
+		}
+
+		// A symlink to /etc/passwd would otherwise be served like a position.
+		if !file.Type().IsRegular() && !InsideRoot(root, fileName) {
+			log.Warn("skipping link that leaves the content directory", "file", fileName)
+			continue
+		}
+
+		var frontmatter Frontmatter
+		if language := CodeLanguage(fileName); language != "" {
+			// Source files have no frontmatter, and their first lines are code.
+			frontmatter = Frontmatter{Title: file.Name(), Description: language + " source"}
+		} else if IsImage(fileName) {
+			frontmatter = Frontmatter{Title: file.Name(), Description: "Image"}
 		} else {
-			defer file.Close()
-			scanner := bufio.NewScanner(file)
-			scanner.Scan()
-			fileDescriptions[i] = scanner.Text()
+			// One broken position is left off the board rather than taking
+			// every other one down with it; validate says what is wrong.
+			content, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(fileName)))
+			if err != nil {
+				log.Warn("skipping unreadable position", "file", fileName, "error", err)
+				continue
+			}
+			frontmatter, _, err = ParsePosition(string(content))
+			if err != nil {
+				log.Warn("skipping position with bad frontmatter", "file", fileName, "error", err)
+				continue
+			}
+		}
+		if frontmatter.Title == "" {
+			frontmatter.Title = strings.TrimSuffix(file.Name(), filepath.Ext(fileName))
+		}
+		if frontmatter.Updated.IsZero() {
+			if info, err := file.Info(); err == nil {
+				frontmatter.Updated = info.ModTime()
+			}
 		}
+		p.add(fileName, frontmatter.Title, frontmatter.Description, frontmatter)
 	}
-	positionMetas := PositionMeta{
-		FileNames:        fileNames,
-		FileDescriptions: fileDescriptions,
+
+	for _, dir := range dirs {
+		entry := len(p.FileNames)
+		p.add(dir+"/", path.Base(dir)+"/", "", Frontmatter{})
+		before := p.PositionCount()
+		if err := p.walk(root, dir); err != nil {
+			return err
+		}
+		count := p.PositionCount() - before
+		p.FileDescriptions[entry] = fmt.Sprintf("%d positions", count)
+		if count == 1 {
+			p.FileDescriptions[entry] = "1 position"
+		}
+	}
+	return nil
+}
+
+// InsideRoot reports whether name, a slash-separated path under root, is
+// still under root once every symlink on the way is resolved. Paths that
+// don't exist are not.
+func InsideRoot(root string, name string) bool {
+	resolvedRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return false
+	}
+	resolved, err := filepath.EvalSymlinks(filepath.Join(root, filepath.FromSlash(name)))
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(resolvedRoot, resolved)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// LockFile takes an exclusive lock on path with ".lock" added, waiting for
+// whoever holds it. While SIGUSR2 hands over to a new process the old one
+// keeps serving its sessions, and stores that read a file, change it and
+// write it back hold this lock so neither process undoes the other's write.
+// The returned func releases it.
+func LockFile(path string) (func(), error) {
+	file, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX); err != nil {
+		file.Close()
+		return nil, err
+	}
+	// Closing the file drops the lock.
+	return func() { file.Close() }, nil
+}
+
+func (p *PositionMeta) add(fileName string, title string, description string, frontmatter Frontmatter) {
+	p.FileNames = append(p.FileNames, fileName)
+	p.FileTitles = append(p.FileTitles, title)
+	p.FileDescriptions = append(p.FileDescriptions, description)
+	p.FileMetadata = append(p.FileMetadata, frontmatter)
+}
+
+// PositionCount is the number of positions, not counting directory entries.
+func (p *PositionMeta) PositionCount() int {
+	count := 0
+	for _, fileName := range p.FileNames {
+		if !IsDirEntry(fileName) {
+			count++
+		}
 	}
-	return &positionMetas, nil
+	return count
+}
+
+// IsDirEntry reports whether name is a directory entry from GetPositionMeta.
+func IsDirEntry(name string) bool {
+	return strings.HasSuffix(name, "/")
+}
+
+// ParentDir returns the directory an entry from GetPositionMeta lives in, ""
+// for the top level.
+func ParentDir(name string) string {
+	dir := path.Dir(strings.TrimSuffix(name, "/"))
+	if dir == "." {
+		return ""
+	}
+	return dir
+}
+
+// codeLanguages are the source files, by extension, that are shown with
+// syntax highlighting instead of being rendered as markdown.
+var codeLanguages = map[string]string{
+	".go":   "Go",
+	".py":   "Python",
+	".json": "JSON",
+	".yaml": "YAML",
+	".yml":  "YAML",
+}
+
+// CodeLanguage is the language of a source file in the content directory, or
+// "" for a position.
+func CodeLanguage(name string) string {
+	return codeLanguages[strings.ToLower(path.Ext(name))]
+}
+
+// IsImage reports whether name is an image, like an event poster, that is
+// drawn in the terminal instead of shown as text.
+func IsImage(name string) bool {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".png", ".jpg", ".jpeg":
+		return true
+	}
+	return false
+}
+
+// FileBody is what visitors are shown of a file as text: all of a source
+// file, none of an image, the markdown body of a position.
+func FileBody(name string, content string) string {
+	switch {
+	case CodeLanguage(name) != "":
+		return content
+	case IsImage(name):
+		return ""
+	}
+	return PositionBody(content)
+}
+
+// PositionBody strips the metadata from a position file, leaving the markdown
+// that is shown to visitors.
+func PositionBody(content string) string {
+	_, body, _ := ParsePosition(content)
+	return body
 }
 
 func Typewrite(s ssh.Session, text string, duration time.Duration) {
@@ -57,3 +262,137 @@ func Typewrite(s ssh.Session, text string, duration time.Duration) {
 	}
 	fmt.Fprint(s, "\n")
 }
+
+func CopyToClipboard(w io.Writer, term string, text string) error {
+	seq := osc52.New(text)
+	switch {
+	case strings.HasPrefix(term, "screen"):
+		seq = seq.Screen()
+	case strings.HasPrefix(term, "tmux"):
+		seq = seq.Tmux()
+	}
+	_, err := seq.WriteTo(w)
+	return err
+}
+
+func StripANSI(s string) string {
+	return ansiPattern.ReplaceAllString(s, "")
+}
+
+func PlainText(s string) string {
+	lines := strings.Split(StripANSI(s), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n")) + "\n"
+}
+
+// Printable drops the control characters and anything else a terminal
+// wouldn't print from text a visitor or a commit supplied, so it can't move
+// the cursor, retitle the window or recolour the screen of whoever sees it.
+func Printable(s string) string {
+	return strings.Map(func(r rune) rune {
+		if !unicode.IsPrint(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// asciiReplacements are plain stand-ins for the box drawing, block and
+// punctuation characters the views draw with, each as wide as the original.
+var asciiReplacements = map[rune]string{
+	'─': "-", '━': "-", '│': "|", '┃': "|",
+	'┌': "+", '┐': "+", '└': "+", '┘': "+",
+	'┏': "+", '┓': "+", '┗': "+", '┛': "+",
+	'╭': "+", '╮': "+", '╰': "+", '╯': "+",
+	'├': "+", '┤': "+", '┬': "+", '┴': "+", '┼': "+",
+	'═': "=", '║': "|", '╔': "+", '╗': "+", '╚': "+", '╝': "+",
+	'▀': "\"", '▄': ",", '█': "#", '░': ".", '▒': ":", '▓': "%",
+	'•': "*", '★': "*", '›': ">", '‹': "<", '·': "-", '…': ".",
+	'←': "<", '→': ">", '↑': "^", '↓': "v",
+	'‘': "'", '’': "'", '“': "\"", '”': "\"", '–': "-", '—': "-",
+}
+
+// ToASCII redraws s for terminals that garble anything beyond ASCII. Box
+// drawing and blocks become plain characters and emoji become blanks of the
+// same width, so borders stay lined up. Escape sequences are kept.
+func ToASCII(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r < 0x80 {
+			b.WriteRune(r)
+			continue
+		}
+		if replacement, ok := asciiReplacements[r]; ok {
+			b.WriteString(replacement)
+			continue
+		}
+		b.WriteString(strings.Repeat(" ", runewidth.RuneWidth(r)))
+	}
+	return b.String()
+}
+
+// DowngradeColors rewrites the colours in s for a terminal with the given
+// colour depth. Without any colours, backgrounds become reverse video so
+// highlights and selections stay visible.
+func DowngradeColors(s string, profile termenv.Profile) string {
+	if profile == termenv.TrueColor {
+		return s
+	}
+	return sgrPattern.ReplaceAllStringFunc(s, func(seq string) string {
+		params := strings.Split(sgrPattern.FindStringSubmatch(seq)[1], ";")
+		kept := make([]string, 0, len(params))
+		for i := 0; i < len(params); i++ {
+			param := params[i]
+			background := param == "48"
+			var color termenv.Color
+			switch {
+			case (param == "38" || background) && i+2 < len(params) && params[i+1] == "5":
+				n, _ := strconv.Atoi(params[i+2])
+				color = termenv.ANSI256Color(n)
+				i += 2
+			case (param == "38" || background) && i+4 < len(params) && params[i+1] == "2":
+				rgb := make([]int, 3)
+				for j := range rgb {
+					rgb[j], _ = strconv.Atoi(params[i+2+j])
+				}
+				color = termenv.RGBColor(fmt.Sprintf("#%02x%02x%02x", rgb[0], rgb[1], rgb[2]))
+				i += 4
+			case isBasicColor(param):
+				if profile != termenv.Ascii {
+					kept = append(kept, param)
+				} else if n, _ := strconv.Atoi(param); (n >= 40 && n <= 47) || n >= 100 {
+					kept = append(kept, "7")
+				}
+				continue
+			default:
+				kept = append(kept, param)
+				continue
+			}
+			if profile == termenv.Ascii {
+				if background {
+					kept = append(kept, "7")
+				}
+				continue
+			}
+			if sequence := profile.Convert(color).Sequence(background); sequence != "" {
+				kept = append(kept, sequence)
+			}
+		}
+		if len(kept) == 0 {
+			return ""
+		}
+		return "\x1b[" + strings.Join(kept, ";") + "m"
+	})
+}
+
+// isBasicColor reports whether an SGR parameter sets or resets one of the 16
+// basic colours.
+func isBasicColor(param string) bool {
+	n, err := strconv.Atoi(param)
+	if err != nil {
+		return false
+	}
+	return (n >= 30 && n <= 39) || (n >= 40 && n <= 49) || (n >= 90 && n <= 97) || (n >= 100 && n <= 107)
+}
@@ -0,0 +1,177 @@
+package views
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"organize/utils"
+
+	"github.com/charmbracelet/log"
+)
+
+const flushInterval = 30 * time.Second
+
+// Store persists view counts. AddViews adds to the stored totals rather than
+// replacing them.
+type Store interface {
+	ViewCounts() (map[string]int, error)
+	AddViews(deltas map[string]int) error
+}
+
+// Counter keeps view counts in memory for every session to share and writes
+// the new views to its store every flushInterval and on Close.
+type Counter struct {
+	mu      sync.Mutex
+	counts  map[string]int
+	pending map[string]int
+	store   Store
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+func NewCounter(store Store) (*Counter, error) {
+	counts, err := store.ViewCounts()
+	if err != nil {
+		return nil, err
+	}
+	c := &Counter{
+		counts:  counts,
+		pending: make(map[string]int),
+		store:   store,
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go c.run()
+	return c, nil
+}
+
+func (c *Counter) Record(position string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counts[position]++
+	c.pending[position]++
+}
+
+func (c *Counter) Count(position string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.counts[position]
+}
+
+func (c *Counter) Flush() error {
+	c.mu.Lock()
+	pending := c.pending
+	c.pending = make(map[string]int)
+	c.mu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+	if err := c.store.AddViews(pending); err != nil {
+		// Keep the views around for the next flush.
+		c.mu.Lock()
+		for position, n := range pending {
+			c.pending[position] += n
+		}
+		c.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+func (c *Counter) run() {
+	defer close(c.stopped)
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := c.Flush(); err != nil {
+				log.Error("could not save view counts", "error", err)
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// Close stops the periodic flush and writes out whatever is left.
+func (c *Counter) Close() error {
+	close(c.done)
+	<-c.stopped
+	return c.Flush()
+}
+
+// FileStore keeps the totals as a JSON object of position to views.
+type FileStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+func (s *FileStore) ViewCounts() (map[string]int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.read()
+}
+
+func (s *FileStore) AddViews(deltas map[string]int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := utils.LockFile(s.path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	counts, err := s.read()
+	if err != nil {
+		return err
+	}
+	for position, n := range deltas {
+		counts[position] += n
+	}
+	content, err := json.MarshalIndent(counts, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write next to the file and rename over it, so a crash mid-write never
+	// leaves half a JSON object behind.
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+func (s *FileStore) read() (map[string]int, error) {
+	counts := make(map[string]int)
+	content, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return counts, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(content, &counts); err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
@@ -0,0 +1,92 @@
+package watcher
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/fsnotify/fsnotify"
+)
+
+// debounce groups the burst of events editors produce when saving a file
+// into a single reload.
+const debounce = 200 * time.Millisecond
+
+type Watcher struct {
+	fsWatcher *fsnotify.Watcher
+	done      chan struct{}
+}
+
+// Watch calls onChange whenever files in dir or any of its subdirectories are
+// created, written, renamed or removed, at most once per debounce window.
+func Watch(dir string, onChange func()) (*Watcher, error) {
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if err := addTree(fsWatcher, dir); err != nil {
+		fsWatcher.Close()
+		return nil, err
+	}
+
+	w := &Watcher{fsWatcher: fsWatcher, done: make(chan struct{})}
+	go w.run(onChange)
+	return w, nil
+}
+
+func (w *Watcher) run(onChange func()) {
+	var timer *time.Timer
+	for {
+		select {
+		case event, ok := <-w.fsWatcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			// fsnotify does not recurse, so new subdirectories are watched as they appear.
+			if event.Op.Has(fsnotify.Create) && !strings.HasPrefix(filepath.Base(event.Name), ".") {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if err := addTree(w.fsWatcher, event.Name); err != nil {
+						log.Error("could not watch new directory", "path", event.Name, "error", err)
+					}
+				}
+			}
+			if timer != nil {
+				timer.Stop()
+			}
+			timer = time.AfterFunc(debounce, onChange)
+		case err, ok := <-w.fsWatcher.Errors:
+			if !ok {
+				return
+			}
+			log.Error("content watcher failed", "error", err)
+		case <-w.done:
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+		}
+	}
+}
+
+func addTree(fsWatcher *fsnotify.Watcher, root string) error {
+	return filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.IsDir() {
+			return err
+		}
+		if path != root && strings.HasPrefix(entry.Name(), ".") {
+			return filepath.SkipDir
+		}
+		return fsWatcher.Add(path)
+	})
+}
+
+func (w *Watcher) Close() error {
+	close(w.done)
+	return w.fsWatcher.Close()
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"organize/config"
+	"organize/utils"
+
+	"github.com/charmbracelet/log"
+)
+
+// positionJSON is how positions are published to the website and the Discord
+// bot, from "ssh host json" and the web server alike.
+type positionJSON struct {
+	File        string     `json:"file"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Tags        []string   `json:"tags"`
+	Status      string     `json:"status"`
+	Deadline    *time.Time `json:"deadline,omitempty"`
+	Updated     time.Time  `json:"updated"`
+	ApplyURL    string     `json:"apply_url"`
+}
+
+// positionApplyURL is where to apply for a position: its own apply_url, or
+// the server's.
+func positionApplyURL(cfg *config.Config, meta utils.Frontmatter) string {
+	if meta.ApplyURL != "" {
+		return meta.ApplyURL
+	}
+	return cfg.ApplyURL
+}
+
+func listPositions(cfg *config.Config) ([]positionJSON, error) {
+	positionMeta, err := utils.GetPositionMeta(cfg.Directory)
+	if err != nil {
+		return nil, err
+	}
+	return publishedPositions(cfg, positionMeta), nil
+}
+
+// publishedPositions is every position outside the server may see, leaving
+// out drafts and, with hide_expired, positions past their deadline.
+func publishedPositions(cfg *config.Config, positionMeta *utils.PositionMeta) []positionJSON {
+	now := time.Now()
+	positions := make([]positionJSON, 0, positionMeta.PositionCount())
+	for i, fileName := range positionMeta.FileNames {
+		if utils.IsDirEntry(fileName) {
+			continue
+		}
+		meta := positionMeta.FileMetadata[i]
+		if meta.Draft() {
+			continue
+		}
+		position := positionJSON{
+			File:        fileName,
+			Title:       positionMeta.FileTitles[i],
+			Description: positionMeta.FileDescriptions[i],
+			Tags:        meta.Tags,
+			Status:      meta.Status,
+			Updated:     meta.Updated,
+			ApplyURL:    positionApplyURL(cfg, meta),
+		}
+		if position.Tags == nil {
+			position.Tags = []string{}
+		}
+		if position.Status == "" {
+			position.Status = "open"
+		}
+		if utils.Archived(fileName, meta) {
+			position.Status = "closed"
+		}
+		if meta.Expired(now) {
+			if cfg.HideExpired {
+				continue
+			}
+			position.Status = "closed"
+		}
+		if !meta.Deadline.IsZero() {
+			deadline := meta.Deadline
+			position.Deadline = &deadline
+		}
+		positions = append(positions, position)
+	}
+	return positions
+}
+
+// newWebServer publishes the board over HTTP for people and programs without
+// an SSH client, and takes push webhooks when webhook is set.
+func newWebServer(addr string, live *liveConfig, webhook http.Handler) *http.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/positions.json", func(w http.ResponseWriter, r *http.Request) {
+		positions, err := listPositions(live.load())
+		if err != nil {
+			log.Error("could not list positions", "error", err)
+			http.Error(w, "could not list positions", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		json.NewEncoder(w).Encode(positions)
+	})
+	mux.HandleFunc("/feed.xml", func(w http.ResponseWriter, r *http.Request) {
+		feed, err := buildFeed(live.load())
+		if err != nil {
+			log.Error("could not build feed", "error", err)
+			http.Error(w, "could not build feed", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+		w.Write(feed)
+	})
+	if webhook != nil {
+		mux.Handle("/webhooks/push", webhook)
+	}
+	mirrorHandlers(mux, live)
+	return &http.Server{Addr: addr, Handler: mux}
+}